@@ -1,5 +1,31 @@
-async function spawnWorker(params, timeout, progress) {
-	let worker = new Worker('./sia.worker.js', { type: 'module' });
+let lastScanID = 0;
+
+// nextScanID returns a new ID to start a cancellable recovery scan with
+function nextScanID() {
+	lastScanID++;
+
+	return `scan-${lastScanID}`;
+}
+
+// spawnWorker aborting signal cancels the recovery scan started with scanID
+async function spawnWorker(params, timeout, progress, signal, signer, scanID) {
+	let worker = new Worker('./sia.worker.js', { type: 'module' }),
+		started = false,
+		cancelled = false;
+
+	// aborting asks the running scan to stop, it still resolves with the partial results. A cancel
+	// before the worker is ready is sent after the scan starts, otherwise it would be lost
+	const cancel = () => {
+		cancelled = true;
+
+		if (started && worker)
+			worker.postMessage(['cancelRecovery', scanID]);
+	};
+
+	if (signal && signal.aborted)
+		cancelled = true;
+	else if (signal)
+		signal.addEventListener('abort', cancel, { once: true });
 
 	const work = new Promise((resolve, reject) => {
 		const workerDeadline = setTimeout(() => {
@@ -13,6 +39,11 @@ async function spawnWorker(params, timeout, progress) {
 
 			if (data === 'ready') {
 				worker.postMessage(params);
+				started = true;
+
+				if (cancelled)
+					worker.postMessage(['cancelRecovery', scanID]);
+
 				return;
 			}
 
@@ -31,6 +62,9 @@ async function spawnWorker(params, timeout, progress) {
 
 				progress(data[1]);
 				return;
+			case 'sign':
+				signRequest(worker, signer, data[1], data[2]);
+				return;
 			case null:
 				resolve(data[1]);
 				return;
@@ -48,28 +82,379 @@ async function spawnWorker(params, timeout, progress) {
 	return work;
 }
 
+// signRequest signs the worker's request with the external signer and posts the result back
+async function signRequest(worker, signer, id, req) {
+	let err = null, sig = null;
+
+	try {
+		if (typeof signer !== 'function')
+			throw new Error('no signer provided');
+
+		sig = await signer(req);
+	} catch (ex) {
+		err = ex.message || String(ex);
+	}
+
+	// posting is ignored if the worker was terminated while waiting for the signer
+	worker.postMessage(['signature', id, err, sig]);
+}
+
+export function getCurrencies() {
+	return spawnWorker(['getCurrencies'], 15000);
+}
+
 export function generateSeed(type) {
 	return spawnWorker(['generateSeed', type], 15000);
 }
 
-export function generateAddresses(seed, currency, i, n) {
-	return spawnWorker(['generateAddresses', seed, currency, i, n], 15000);
+export function validateSeed(seed, currency) {
+	return spawnWorker(['validateSeed', seed, currency], 15000);
+}
+
+// verifySeedChecksum only checks the words and checksum of the seed for feedback while typing, run
+// validateSeed once it passes
+export function verifySeedChecksum(seed, currency) {
+	return spawnWorker(['verifySeedChecksum', seed, currency], 15000);
+}
+
+// progress is called with the stage of the probe and the range being checked
+export function detectWalletType(seed, currency, progress) {
+	return spawnWorker(['detectWalletType', seed, currency], 30000, progress);
+}
+
+export function autoDetectCurrency(seed, progress) {
+	return spawnWorker(['autoDetectCurrency', seed], 30000, progress);
+}
+
+export function walletFingerprint(seed, currency) {
+	return spawnWorker(['walletFingerprint', seed, currency], 15000);
+}
+
+const wordlists = {};
+
+// getWordlist resolves with the ordered wordlist of the seed type, cached after the first call
+export async function getWordlist(type) {
+	if (!wordlists[type])
+		wordlists[type] = spawnWorker(['getWordlist', type], 15000);
+
+	try {
+		return await wordlists[type];
+	} catch (ex) {
+		delete wordlists[type];
+		throw ex;
+	}
+}
+
+export function getLabelKey(seed, currency) {
+	return spawnWorker(['getLabelKey', seed, currency], 15000);
+}
+
+// deriveLabelNonce resolves with the hex secretbox nonce for encrypting a version of the address's
+// label with the label key. Store the version with the ciphertext and increment it every time the
+// label changes, a nonce must never encrypt two different labels
+export function deriveLabelNonce(seed, currency, address, version) {
+	return spawnWorker(['deriveLabelNonce', seed, currency, address, version], 15000);
+}
+
+export function generateAddresses(seed, currency, i, n, accountOffset = 0) {
+	return spawnWorker(['generateAddresses', seed, currency, i, n, accountOffset], 15000);
+}
+
+// generateAddressMap resolves with an object of the address strings keyed by index
+export function generateAddressMap(seed, currency, i, n, accountOffset = 0) {
+	return spawnWorker(['generateAddressMap', seed, currency, i, n, accountOffset], 15000);
+}
+
+export function generateAddressesBatch(seed, currency, indices, accountOffset = 0) {
+	return spawnWorker(['generateAddressesBatch', seed, currency, JSON.stringify(indices), accountOffset], 15000);
+}
+
+// verifyAddresses resolves with whether each address belongs to the seed and its index, every
+// address up to and including maxIndex is checked. A maxIndex above 1,000,000 is rejected
+export function verifyAddresses(seed, currency, addresses, maxIndex = 2500, accountOffset = 0) {
+	return spawnWorker(['verifyAddresses', seed, currency, JSON.stringify(addresses), maxIndex, accountOffset], 30000);
+}
+
+// exportWatchOnly exports the public keys of count addresses starting at start. Sia keys cannot be
+// derived from a public key, so a watch-only wallet only has the exported addresses
+export function exportWatchOnly(seed, currency, start, count, accountOffset = 0) {
+	return spawnWorker(['exportWatchOnly', seed, currency, start, count, accountOffset], 15000);
+}
+
+export function openWatchOnly(exported, currency) {
+	return spawnWorker(['openWatchOnly', JSON.stringify(exported), currency], 15000);
+}
+
+// exportAddressPool derives the first count addresses of the account into a pool that can be cached
+// and reloaded with importAddressPool. The pool is bound to the seed and account offset, a modified
+// pool is rejected on import
+export function exportAddressPool(seed, currency, count, accountOffset = 0) {
+	return spawnWorker(['exportAddressPool', seed, currency, count, accountOffset], 15000);
+}
+
+export function importAddressPool(seed, currency, pool, accountOffset = 0) {
+	return spawnWorker(['importAddressPool', seed, currency, JSON.stringify(pool), accountOffset], 15000);
+}
+
+// hashCoveredFields resolves with the sig hash of each signature, the exact hash signTransaction
+// signs. Without coverage each signature is hashed with its own covered fields
+export function hashCoveredFields(txn, currency, coverage = null) {
+	return spawnWorker(['hashCoveredFields', JSON.stringify(txn), coverage ? JSON.stringify(coverage) : '', currency], 15000);
+}
+
+// signTransactionExternal signs the transaction without the seed. signer is called with each
+// signature's { index, parent_id, public_key, sig_hash } and returns the hex encoded ed25519
+// signature of sig_hash, or a promise resolving to it, for example from a hardware wallet
+export function signTransactionExternal(currency, txn, signer, coverage = null) {
+	return spawnWorker(['signTransactionExternal', currency, JSON.stringify(txn), coverage ? JSON.stringify(coverage) : ''], 15000, null, null, signer);
+}
+
+// requiredKeys resolves with the wallet's key indices in each input's unlock conditions, keys past
+// maxIndex are not checked. A maxIndex above 1,000,000 is rejected. Inputs the wallet cannot sign alone
+// have signable unset
+export function requiredKeys(txn, seed, currency, maxIndex = 2500, accountOffset = 0) {
+	return spawnWorker(['requiredKeys', JSON.stringify(txn), seed, currency, maxIndex, accountOffset], 30000);
+}
+
+export function signTransactions(seed, currency, unsigned, accountOffset = 0) {
+	return spawnWorker(['signTransactions', seed, currency, JSON.stringify(unsigned), accountOffset], 15000);
+}
+
+// validateTransaction spent are the outputs spent by the transaction's inputs. Resolves with the
+// list of consensus rule violations, empty if the transaction is valid
+export function validateTransaction(txn, spent, currency) {
+	return spawnWorker(['validateTransaction', JSON.stringify(txn), JSON.stringify(spent), currency], 15000);
+}
+
+// balanceCheck outputValues maps the id of each output spent by the transaction to its value.
+// Resolves with the input and output sums and any excess or shortfall
+export function balanceCheck(txn, outputValues) {
+	return spawnWorker(['balanceCheck', JSON.stringify(txn), JSON.stringify(outputValues)], 15000);
+}
+
+// decodeArbitraryData resolves with each of the transaction's arbitrary data fields. Host
+// announcements and other known prefixes are decoded, every field includes its hex
+export function decodeArbitraryData(txn) {
+	return spawnWorker(['decodeArbitraryData', JSON.stringify(txn)], 15000);
+}
+
+// validateMultisig resolves with the reasons an address with the unlock conditions could not be
+// spent from, empty if the unlock conditions are valid
+export function validateMultisig(unlockConditions) {
+	return spawnWorker(['validateMultisig', JSON.stringify(unlockConditions)], 15000);
+}
+
+export function buildTransactionSet(seed, currency, unsigned, parents = [], accountOffset = 0) {
+	return spawnWorker(['buildTransactionSet', seed, currency, JSON.stringify(unsigned), JSON.stringify(parents), accountOffset], 15000);
+}
+
+// getTransactions flags the unspent outputs not worth spending at feePerByte as dust, an empty fee
+// skips the check. seen are the transactions from the previous call, any that were confirmed but
+// are no longer in the history are returned in invalidated_transactions
+export function getTransactions(addresses, currency, pending = [], feePerByte = '', seen = []) {
+	const seenTxns = seen.map(t => ({ transaction_id: t.transaction_id, block_height: t.block_height, pending: t.pending }));
+
+	return spawnWorker(['getTransactions', addresses, currency, pending, JSON.stringify(seenTxns), feePerByte], 30000);
+}
+
+export function getAddressDetails(address, currency) {
+	return spawnWorker(['getAddressDetails', address, currency], 30000);
+}
+
+// addressPrivacyScore resolves with a score from 0 to 100 and the flags, like reused or
+// received_after_send, that lowered it
+export function addressPrivacyScore(address, currency) {
+	return spawnWorker(['addressPrivacyScore', address, currency], 30000);
+}
+
+// counterpartyExposure resolves with the external addresses the most siacoins were sent to or
+// received from, the wallet's own addresses are never counted
+export function counterpartyExposure(addresses, currency) {
+	return spawnWorker(['counterpartyExposure', JSON.stringify(addresses), currency], 30000);
+}
+
+// describeTransaction resolves with a short sentence describing the transaction from the point of
+// view of the wallet's addresses, for notifications
+export function describeTransaction(txn, addresses, currency) {
+	return spawnWorker(['describeTransaction', JSON.stringify(txn), JSON.stringify(addresses), currency], 15000);
+}
+
+export function getWalletStats(addresses, currency) {
+	return spawnWorker(['getWalletStats', addresses, currency], 30000);
 }
 
-export function signTransactions(seed, currency, unsigned) {
-	return spawnWorker(['signTransactions', seed, currency, JSON.stringify(unsigned)], 15000);
+// estimateCreationHeight resolves with the lowest block height any of the addresses first
+// appeared at, found is false if none of them have confirmed activity
+export function estimateCreationHeight(addresses, currency) {
+	return spawnWorker(['estimateCreationHeight', JSON.stringify(addresses), currency], 30000);
 }
 
-export function getTransactions(addresses, currency) {
-	return spawnWorker(['getTransactions', addresses, currency], 30000);
+export function getOutputsInRange(addresses, currency, fromHeight, toHeight) {
+	return spawnWorker(['getOutputsInRange', addresses, currency, fromHeight, toHeight], 30000);
 }
 
 export async function exportTransactions(addresses, currency, min, max, progress) {
 	return spawnWorker(['exportTransactions', addresses, currency, min, max], 30000, progress);
 }
 
-export function signTransaction(seed, currency, txn, indexes) {
-	return spawnWorker(['signTransaction', seed, currency, JSON.stringify(txn), indexes], 15000);
+// formatAmount formats a base unit string, hastings for siacoins, of the asset 'siacoin' or
+// 'siafund' as a decimal string using the currency's precision
+export function formatAmount(value, currency, asset = 'siacoin') {
+	return spawnWorker(['formatAmount', value, currency, asset], 15000);
+}
+
+// parseAmount parses a decimal string of the asset into a base unit string. Rejects amounts more
+// precise than the smallest unit, like fractional siafunds
+export function parseAmount(str, currency, asset = 'siacoin') {
+	return spawnWorker(['parseAmount', str, currency, asset], 15000);
+}
+
+export function dustThreshold(currency, feePerByte) {
+	return spawnWorker(['dustThreshold', currency, feePerByte], 15000);
+}
+
+export function getTotalClaims(addresses, currency) {
+	return spawnWorker(['getTotalClaims', JSON.stringify(addresses), currency], 30000);
+}
+
+// getBalanceDelta known is the previous getTransactions result, or any object with its
+// unspent_siacoin_outputs and unspent_siafund_outputs. Resolves with the new, changed and spent
+// outputs since then
+export function getBalanceDelta(known, addresses, currency) {
+	const outputs = {
+		unspent_siacoin_outputs: known.unspent_siacoin_outputs || [],
+		unspent_siafund_outputs: known.unspent_siafund_outputs || []
+	};
+
+	return spawnWorker(['getBalanceDelta', JSON.stringify(outputs), JSON.stringify(addresses), currency], 30000);
+}
+
+// getEffectiveBalance resolves with the siacoins spendable right now under the policy and the reason
+// each other output was excluded. The policy's fee_per_byte excludes dust, pending excludes the outputs
+// spent by broadcast transactions, and timelocks maps timelocked addresses to their timelock height
+export function getEffectiveBalance(addresses, currency, policy = {}) {
+	const spendPolicy = {
+		min_confirmations: policy.min_confirmations || 0,
+		fee_per_byte: policy.fee_per_byte || '0',
+		pending: policy.pending || [],
+		timelocks: policy.timelocks || {}
+	};
+
+	return spawnWorker(['getEffectiveBalance', JSON.stringify(addresses), currency, JSON.stringify(spendPolicy)], 30000);
+}
+
+export function getPendingTransactions(addresses, currency) {
+	return spawnWorker(['getPendingTransactions', JSON.stringify(addresses), currency], 30000);
+}
+
+// estimateFeeForTarget resolves with the fee per byte likely to confirm a transaction within
+// targetBlocks blocks and the current fee market
+export function estimateFeeForTarget(currency, targetBlocks) {
+	return spawnWorker(['estimateFeeForTarget', currency, targetBlocks], 30000);
+}
+
+export function diagnoseTransaction(id, currency) {
+	return spawnWorker(['diagnoseTransaction', id, currency], 30000);
+}
+
+export function exportHistoryCSV(addresses, currency) {
+	return spawnWorker(['exportHistoryCSV', JSON.stringify(addresses), currency], 30000);
+}
+
+export function pingAPI(currency) {
+	return spawnWorker(['pingAPI', currency], 30000);
+}
+
+export function getBlockHeight(currency) {
+	return spawnWorker(['getBlockHeight', currency], 30000);
+}
+
+// getConfirmations returns the confirmations of an output created at blockHeight, unconfirmed
+// outputs have a height of 0 and no confirmations
+export function getConfirmations(blockHeight, currency) {
+	return spawnWorker(['getConfirmations', blockHeight, currency], 30000);
+}
+
+export function reconcileBalance(addresses, currency) {
+	return spawnWorker(['reconcileBalance', addresses, currency], 30000);
+}
+
+export function buildDefrag(outputs, currency, recipient, feePerByte, keep = 0, dustThreshold = '0') {
+	return spawnWorker(['buildDefrag', JSON.stringify(outputs), currency, recipient, feePerByte, keep, dustThreshold], 30000);
+}
+
+// estimateSweep resolves with the number of transactions, total fee, and amount that arrives when
+// every spendable output is sent to destination
+export function estimateSweep(outputs, currency, destination, feePerByte) {
+	return spawnWorker(['estimateSweep', JSON.stringify(outputs), currency, destination, feePerByte], 30000);
+}
+
+export function buildPaymentURI(address, amount = '', label = '') {
+	return spawnWorker(['buildPaymentURI', address, amount, label], 15000);
+}
+
+// generateReceiveRequest resolves with the address at index and a payment request URI for the
+// amount in hastings and label, both optional
+export function generateReceiveRequest(seed, currency, index, amount = '', label = '', accountOffset = 0) {
+	return spawnWorker(['generateReceiveRequest', seed, currency, index, amount, label, accountOffset], 15000);
+}
+
+export function parsePaymentURI(uri) {
+	return spawnWorker(['parsePaymentURI', uri], 15000);
+}
+
+// previewSend strategy is the coin selection strategy, 'smallest-first' or 'largest-first', empty
+// for the default. feeInclusive takes the fee out of the amount instead of adding it on top, for
+// batch sends it is taken out of the first recipient's amount. outputsHeight is the block height
+// the outputs were fetched at, passing it builds the send without the API for offline signing
+export function previewSend(seed, currency, recipient, amount, feePerByte, outputs, strategy = '', accountOffset = 0, feeInclusive = false, outputsHeight = 0) {
+	return spawnWorker(['previewSend', seed, currency, recipient, amount, feePerByte, JSON.stringify(outputs), strategy, accountOffset, feeInclusive, outputsHeight], 30000);
+}
+
+// spendOutput builds and signs a transaction spending only the output with the id, the whole
+// output less the fee is sent unless an amount is given
+export function spendOutput(seed, currency, outputID, destination, feePerByte, outputs, amount = '0', accountOffset = 0) {
+	return spawnWorker(['spendOutput', seed, currency, outputID, destination, amount, feePerByte, JSON.stringify(outputs), accountOffset], 30000);
+}
+
+export function previewBatchSend(seed, currency, recipients, feePerByte, outputs, strategy = '', accountOffset = 0, feeInclusive = false, outputsHeight = 0) {
+	return spawnWorker(['previewBatchSend', seed, currency, JSON.stringify(recipients), feePerByte, JSON.stringify(outputs), strategy, accountOffset, feeInclusive, outputsHeight], 30000);
+}
+
+// countSendInputs resolves with the number of inputs sending amount to recipients recipients
+// would spend, and if the send needs the wallet to be defragged first
+export function countSendInputs(currency, amount, feePerByte, outputs, recipients = 1, outputsHeight = 0) {
+	return spawnWorker(['countSendInputs', currency, amount, feePerByte, recipients, JSON.stringify(outputs), outputsHeight], 30000);
+}
+
+// buildUnsignedSend builds the transaction without the seed. The result can be passed to
+// signTransactions to sign it, and only needs to be signed again if the inputs change
+export function buildUnsignedSend(currency, recipients, feePerByte, outputs, strategy = '', feeInclusive = false, outputsHeight = 0) {
+	return spawnWorker(['buildUnsignedSend', currency, JSON.stringify(recipients), feePerByte, JSON.stringify(outputs), strategy, feeInclusive, outputsHeight], 30000);
+}
+
+// verifyChange rejects if the change of a preview from buildUnsignedSend would not return to one of
+// the seed's addresses up to and including maxIndex, it should be checked before signing. recipients
+// are the { address, amount } recipients the preview was built for, every other output is change. A
+// maxIndex above 1,000,000 is rejected like verifyAddresses
+export function verifyChange(seed, currency, preview, recipients, maxIndex = 2500, accountOffset = 0) {
+	return spawnWorker(['verifyChange', seed, currency, JSON.stringify(preview.transaction), JSON.stringify(recipients), maxIndex, accountOffset], 30000);
+}
+
+export function signTransaction(seed, currency, txn, indexes, accountOffset = 0) {
+	return spawnWorker(['signTransaction', seed, currency, JSON.stringify(txn), indexes, accountOffset], 15000);
+}
+
+// signSighash resolves with the hex encoded signature of a sig hash computed outside of the wallet
+// and the public key of the address at the index
+export function signSighash(seed, currency, index, sighash, accountOffset = 0) {
+	return spawnWorker(['signSighash', seed, currency, index, sighash, accountOffset], 15000);
+}
+
+// signTransactionCoverage signs with signatures that only commit to the covered fields, any
+// field left uncovered can be changed by anyone after signing
+export function signTransactionCoverage(seed, currency, txn, indexes, coveredFields, accountOffset = 0) {
+	return spawnWorker(['signTransactionCoverage', seed, currency, JSON.stringify(txn), indexes, JSON.stringify(coveredFields), accountOffset], 15000);
 }
 
 export function encodeTransaction(txn) {
@@ -80,10 +465,99 @@ export function encodeUnlockHash(unlockconditions) {
 	return spawnWorker(['encodeUnlockHash', JSON.stringify(unlockconditions)], 15000);
 }
 
+// computeUnlockHash unlock conditions use the same format as the unlock_conditions of generated
+// addresses
+export function computeUnlockHash(unlockConditions) {
+	return spawnWorker(['computeUnlockHash', JSON.stringify(unlockConditions)], 15000);
+}
+
 export function encodeUnlockHashes(unencoded) {
 	return spawnWorker(['encodeUnlockHashes', unencoded.map(u => JSON.stringify(u))], 15000);
 }
 
-export async function recoverAddresses(seed, currency, i = 0, n = 10, count = 2500, last = 0, progress) {
-	return spawnWorker(['recoverAddresses', seed, currency, i, n, count, last], 30000, progress);
-}
\ No newline at end of file
+// recoverAddresses options are passed to the scan as its RecoveryOptions, any option left unset
+// uses the default below. ranges are { start, end } inclusive index ranges scanned in full after
+// the primary scan. Progress is sent at most once every progress_interval_ms, the resolved value
+// contains any addresses not yet sent as progress. skip_lookahead leaves out the unused address
+// after a wallet's last send. resend_all includes every found address in the resolved value.
+// Rounds smaller than min_round_size are raised to it, 0 uses the default floor and 1 disables it.
+// Aborting signal stops the scan, the resolved value has cancelled set and the addresses found so far.
+// Failed requests are retried up to max_retries times in total, after that the scan resolves with
+// incomplete set. A non-zero address_gap_limit stops the scan after that many consecutive unused
+// addresses instead of after max_empty_rounds empty rounds. verify re-derives every found address
+// from its index and fails the scan on a mismatch. account_offset derives the addresses of a
+// different account of the seed, every function deriving keys for the wallet must be passed the
+// same offset
+// Each found address has the assets it used, the payloads' asset_index has the highest used
+// index of each asset. It is informational, the gap and lookahead use the highest index of both
+// assets. incremental treats last_known_index as the highest used index of a previous recovery and
+// only scans and returns the addresses after it
+export async function recoverAddresses(seed, currency, options = {}, progress, signal = null) {
+	const opts = {
+		start_index: 0,
+		max_empty_rounds: 10,
+		address_count: 2500,
+		last_known_index: 0,
+		ranges: [],
+		compress: false,
+		progress_interval_ms: 0,
+		skip_lookahead: false,
+		resend_all: false,
+		min_round_size: 0,
+		max_retries: 10,
+		address_gap_limit: 0,
+		verify: false,
+		account_offset: 0,
+		incremental: false,
+		...options,
+		scan_id: nextScanID()
+	};
+
+	return spawnWorker(['recoverAddresses', seed, currency, JSON.stringify(opts)], 30000, progress, signal, null, opts.scan_id);
+}
+
+// recoverSiafundAddresses resolves with only the addresses that have used siafunds. Only siafund usage
+// counts toward the gap, a gapLimit of 0 uses the default of 100,000 addresses. progress is called
+// each time siafund addresses are found. Aborting signal stops the scan, the resolved value has
+// cancelled set
+export async function recoverSiafundAddresses(seed, currency, i = 0, count = 2500, gapLimit = 0, progress, maxRetries = 10, accountOffset = 0, signal = null) {
+	const scanID = nextScanID();
+
+	return spawnWorker(['recoverSiafundAddresses', seed, currency, scanID, i, count, gapLimit, maxRetries, accountOffset], 30000, progress, signal, null, scanID);
+}
+
+// recoverIndices checks only the listed indices for usage without scanning the gaps between them
+export function recoverIndices(seed, currency, indices, compress = false, accountOffset = 0) {
+	return spawnWorker(['recoverIndices', seed, currency, indices, compress, accountOffset], 30000);
+}
+
+// decompressPayload decodes a gzipped JSON payload returned when compression is requested
+export async function decompressPayload(data) {
+	const stream = new Blob([data]).stream().pipeThrough(new DecompressionStream('gzip'));
+
+	return JSON.parse(await new Response(stream).text());
+}
+
+export function auditAddress(seed, currency, address, index, accountOffset = 0) {
+	return spawnWorker(['auditAddress', seed, currency, address, index, accountOffset], 30000);
+}
+
+// compareAddressSets resolves with the expected addresses that were not found, the found addresses
+// that were not expected, and the number in both
+export function compareAddressSets(expected, found) {
+	return spawnWorker(['compareAddressSets', JSON.stringify(expected), JSON.stringify(found)], 15000);
+}
+
+export function estimateRecoveryTime(currency, count = 2500, n = 10) {
+	return spawnWorker(['estimateRecoveryTime', currency, count, n], 30000);
+}
+
+// benchmarkDerivation resolves with the time in milliseconds to derive count addresses and the
+// addresses derived per second on this device
+export function benchmarkDerivation(seed, currency, count = 1000) {
+	return spawnWorker(['benchmarkDerivation', seed, currency, count], 30000);
+}
+
+export async function findGaps(seed, currency, n = 10, count = 2500, progress, accountOffset = 0) {
+	return spawnWorker(['findGaps', seed, currency, n, count, accountOffset], 30000, progress);
+}
@@ -13,6 +13,20 @@ async function load() {
 }
 
 const loaded = load();
+const pendingSignatures = {};
+
+let signatureID = 0;
+
+// requestSignature asks the page to sign the request with the external signer, resolves with the
+// hex encoded signature
+function requestSignature(req) {
+	const id = signatureID++;
+
+	return new Promise((resolve, reject) => {
+		pendingSignatures[id] = { resolve, reject };
+		postMessage(['sign', id, req]);
+	});
+}
 
 onmessage = async(e) => {
 	try {
@@ -23,6 +37,23 @@ onmessage = async(e) => {
 
 		let params = [];
 
+		if (action === 'signature') {
+			const [id, err, sig] = e.data.slice(1),
+				pending = pendingSignatures[id];
+
+			if (!pending)
+				return;
+
+			delete pendingSignatures[id];
+
+			if (err)
+				pending.reject(new Error(err));
+			else
+				pending.resolve(sig);
+
+			return;
+		}
+
 		if (e.data.length > 1)
 			params = e.data.slice(1);
 
@@ -33,6 +64,10 @@ onmessage = async(e) => {
 			return;
 		}
 
+		// the signer cannot be posted to the worker, signatures are requested from the page instead
+		if (action === 'signTransactionExternal')
+			params.push(requestSignature);
+
 		params.push((err, value) => {
 			postMessage([err, value]);
 		});
@@ -19,7 +19,12 @@ export default {
 				startIndex = lastKnownIndex - maxLookahead;
 		}
 
-		await recoverAddresses(wallet.seed, wallet.currency, startIndex, Math.ceil(maxLookahead / 500), 500, lastKnownIndex, async(progress) => {
+		const completed = await recoverAddresses(wallet.seed, wallet.currency, {
+			start_index: startIndex,
+			max_empty_rounds: Math.ceil(maxLookahead / 500),
+			address_count: 500,
+			last_known_index: lastKnownIndex
+		}, async(progress) => {
 			if (!progress || !Array.isArray(progress.addresses))
 				return;
 
@@ -30,6 +35,14 @@ export default {
 				};
 			}));
 		});
+
+		// the completion payload holds any addresses not sent as progress
+		if (completed && Array.isArray(completed.addresses) && completed.addresses.length !== 0) {
+			await saveAddresses(completed.addresses.map(a => ({
+				...a,
+				wallet_id: wallet.id
+			})));
+		}
 	},
 	fullScan: async function(wallet) {
 		let maxLookahead = Store.state.addressLookahead;
@@ -37,7 +50,10 @@ export default {
 		if (typeof maxLookahead !== 'number' || maxLookahead < 0 || maxLookahead > 500000)
 			maxLookahead = 25000;
 
-		await recoverAddresses(wallet.seed, wallet.currency, 0, Math.ceil(maxLookahead / 500), 500, 0, async(progress) => {
+		const completed = await recoverAddresses(wallet.seed, wallet.currency, {
+			max_empty_rounds: Math.ceil(maxLookahead / 500),
+			address_count: 500
+		}, async(progress) => {
 			if (!progress || !Array.isArray(progress.addresses))
 				return;
 
@@ -46,6 +62,13 @@ export default {
 				wallet_id: wallet.id
 			})));
 		});
+
+		if (completed && Array.isArray(completed.addresses) && completed.addresses.length !== 0) {
+			await saveAddresses(completed.addresses.map(a => ({
+				...a,
+				wallet_id: wallet.id
+			})));
+		}
 	},
 	scanTransactions: async function(wallet) {
 		const addresses = await getWalletAddresses(wallet.id);
@@ -53,7 +76,8 @@ export default {
 		if (!Array.isArray(addresses) || addresses.length === 0)
 			throw new Error('wallet has no addresses');
 
-		const balance = await getTransactions(addresses.map(a => a.address), wallet.currency);
+		// the previous scan's transactions are passed to catch any removed by a reorg
+		const balance = await getTransactions(addresses.map(a => a.address), wallet.currency, [], '', wallet.transactions || []);
 
 		wallet = new Wallet({
 			...wallet,
@@ -3,25 +3,98 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"syscall/js"
 	"time"
 
+	apitypes "github.com/siacentral/apisdkgo/types"
 	"github.com/siacentral/sia-lite-wallet-web/wasm/modules"
 	siatypes "gitlab.com/NebulousLabs/Sia/types"
 )
 
 func main() {
 	js.Global().Set("sia", map[string]interface{}{
-		"generateSeed":       js.FuncOf(generateSeed),
-		"generateAddresses":  js.FuncOf(generateAddresses),
-		"recoverAddresses":   js.FuncOf(recoverAddresses),
-		"getTransactions":    js.FuncOf(getTransactions),
-		"encodeTransaction":  js.FuncOf(encodeTransaction),
-		"signTransaction":    js.FuncOf(signTransaction),
-		"signTransactions":   js.FuncOf(signTransactions),
-		"encodeUnlockHash":   js.FuncOf(encodeUnlockHash),
-		"encodeUnlockHashes": js.FuncOf(encodeUnlockHashes),
-		"exportTransactions": js.FuncOf(exportTransactions),
+		"generateSeed":            js.FuncOf(generateSeed),
+		"getWordlist":             js.FuncOf(getWordlist),
+		"generateAddresses":       js.FuncOf(generateAddresses),
+		"generateAddressMap":      js.FuncOf(generateAddressMap),
+		"generateAddressesBatch":  js.FuncOf(generateAddressesBatch),
+		"verifyAddresses":         js.FuncOf(verifyAddresses),
+		"exportWatchOnly":         js.FuncOf(exportWatchOnly),
+		"openWatchOnly":           js.FuncOf(openWatchOnly),
+		"exportAddressPool":       js.FuncOf(exportAddressPool),
+		"importAddressPool":       js.FuncOf(importAddressPool),
+		"recoverAddresses":        js.FuncOf(recoverAddresses),
+		"cancelRecovery":          js.FuncOf(cancelRecovery),
+		"recoverIndices":          js.FuncOf(recoverIndices),
+		"getTransactions":         js.FuncOf(getTransactions),
+		"encodeTransaction":       js.FuncOf(encodeTransaction),
+		"signTransaction":         js.FuncOf(signTransaction),
+		"signTransactionCoverage": js.FuncOf(signTransactionCoverage),
+		"validateTransaction":     js.FuncOf(validateTransaction),
+		"validateMultisig":        js.FuncOf(validateMultisig),
+		"hashCoveredFields":       js.FuncOf(hashCoveredFields),
+		"signTransactions":        js.FuncOf(signTransactions),
+		"signTransactionExternal": js.FuncOf(signTransactionExternal),
+		"requiredKeys":            js.FuncOf(requiredKeys),
+		"buildTransactionSet":     js.FuncOf(buildTransactionSet),
+		"encodeUnlockHash":        js.FuncOf(encodeUnlockHash),
+		"encodeUnlockHashes":      js.FuncOf(encodeUnlockHashes),
+		"exportTransactions":      js.FuncOf(exportTransactions),
+		"dustThreshold":           js.FuncOf(dustThreshold),
+		"getTotalClaims":          js.FuncOf(getTotalClaims),
+		"getBalanceDelta":         js.FuncOf(getBalanceDelta),
+		"getEffectiveBalance":     js.FuncOf(getEffectiveBalance),
+		"getPendingTransactions":  js.FuncOf(getPendingTransactions),
+		"exportHistoryCSV":        js.FuncOf(exportHistoryCSV),
+		"setAddressLabel":         js.FuncOf(setAddressLabel),
+		"getAddressLabels":        js.FuncOf(getAddressLabels),
+		"exportLabels":            js.FuncOf(exportLabels),
+		"importLabels":            js.FuncOf(importLabels),
+		"reconcileBalance":        js.FuncOf(reconcileBalance),
+		"pingAPI":                 js.FuncOf(pingAPI),
+		"estimateRecoveryTime":    js.FuncOf(estimateRecoveryTime),
+		"benchmarkDerivation":     js.FuncOf(benchmarkDerivation),
+		"auditAddress":            js.FuncOf(auditAddress),
+		"computeUnlockHash":       js.FuncOf(computeUnlockHash),
+		"getAddressDetails":       js.FuncOf(getAddressDetails),
+		"getOutputsInRange":       js.FuncOf(getOutputsInRange),
+		"getWalletStats":          js.FuncOf(getWalletStats),
+		"getBlockHeight":          js.FuncOf(getBlockHeight),
+		"getConfirmations":        js.FuncOf(getConfirmations),
+		"buildDefrag":             js.FuncOf(buildDefrag),
+		"estimateSweep":           js.FuncOf(estimateSweep),
+		"findGaps":                js.FuncOf(findGaps),
+		"detectWalletType":        js.FuncOf(detectWalletType),
+		"autoDetectCurrency":      js.FuncOf(autoDetectCurrency),
+		"validateSeed":            js.FuncOf(validateSeed),
+		"getLabelKey":             js.FuncOf(getLabelKey),
+		"deriveLabelNonce":        js.FuncOf(deriveLabelNonce),
+		"walletFingerprint":       js.FuncOf(walletFingerprint),
+		"getCurrencies":           js.FuncOf(getCurrencies),
+		"formatAmount":            js.FuncOf(formatAmount),
+		"parseAmount":             js.FuncOf(parseAmount),
+		"buildPaymentURI":         js.FuncOf(buildPaymentURI),
+		"parsePaymentURI":         js.FuncOf(parsePaymentURI),
+		"generateReceiveRequest":  js.FuncOf(generateReceiveRequest),
+		"previewSend":             js.FuncOf(previewSend),
+		"buildUnsignedSend":       js.FuncOf(buildUnsignedSend),
+		"countSendInputs":         js.FuncOf(countSendInputs),
+		"previewBatchSend":        js.FuncOf(previewBatchSend),
+		"diagnoseTransaction":     js.FuncOf(diagnoseTransaction),
+		"balanceCheck":            js.FuncOf(balanceCheck),
+		"estimateFeeForTarget":    js.FuncOf(estimateFeeForTarget),
+		"decodeArbitraryData":     js.FuncOf(decodeArbitraryData),
+		"compareAddressSets":      js.FuncOf(compareAddressSets),
+		"estimateCreationHeight":  js.FuncOf(estimateCreationHeight),
+		"addressPrivacyScore":     js.FuncOf(addressPrivacyScore),
+		"spendOutput":             js.FuncOf(spendOutput),
+		"verifySeedChecksum":      js.FuncOf(verifySeedChecksum),
+		"signSighash":             js.FuncOf(signSighash),
+		"counterpartyExposure":    js.FuncOf(counterpartyExposure),
+		"describeTransaction":     js.FuncOf(describeTransaction),
+		"verifyChange":            js.FuncOf(verifyChange),
+		"recoverSiafundAddresses": js.FuncOf(recoverSiafundAddresses),
 	})
 
 	c := make(chan bool, 1)
@@ -43,6 +116,17 @@ func checkArgs(args []js.Value, argTypes ...js.Type) error {
 	return nil
 }
 
+// parseCurrency parses a base 10 hastings string
+func parseCurrency(str string) (siatypes.Currency, error) {
+	i, ok := new(big.Int).SetString(str, 10)
+
+	if !ok || i.Sign() < 0 {
+		return siatypes.ZeroCurrency, fmt.Errorf("unable to parse currency %q", str)
+	}
+
+	return siatypes.NewCurrency(i), nil
+}
+
 func encodeTransaction(this js.Value, args []js.Value) interface{} {
 	if err := checkArgs(args, js.TypeString, js.TypeFunction); err != nil {
 		return err.Error()
@@ -66,7 +150,7 @@ func encodeTransaction(this js.Value, args []js.Value) interface{} {
 func signTransaction(this js.Value, args []js.Value) interface{} {
 	var txn siatypes.Transaction
 
-	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeObject, js.TypeFunction); err != nil {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeObject, js.TypeNumber, js.TypeFunction); err != nil {
 		return err.Error()
 	}
 
@@ -74,7 +158,39 @@ func signTransaction(this js.Value, args []js.Value) interface{} {
 	currency := args[1].String()
 	jsonTxn := args[2].String()
 	length := args[3].Length()
-	callback := args[4]
+	accountOffset := uint64(args[4].Int())
+	callback := args[5]
+	requiredSigs := make([]uint64, length)
+
+	if err := json.Unmarshal([]byte(jsonTxn), &txn); err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return err.Error()
+	}
+
+	for i := 0; i < length; i++ {
+		requiredSigs[i] = uint64(args[3].Index(i).Int())
+	}
+
+	go modules.SignTransaction(txn, phrase, currency, requiredSigs, nil, accountOffset, callback)
+
+	return nil
+}
+
+func signTransactionCoverage(this js.Value, args []js.Value) interface{} {
+	var txn siatypes.Transaction
+	var coverage siatypes.CoveredFields
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeObject, js.TypeString, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	phrase := args[0].String()
+	currency := args[1].String()
+	jsonTxn := args[2].String()
+	length := args[3].Length()
+	jsonCoverage := args[4].String()
+	accountOffset := uint64(args[5].Int())
+	callback := args[6]
 	requiredSigs := make([]uint64, length)
 
 	if err := json.Unmarshal([]byte(jsonTxn), &txn); err != nil {
@@ -82,11 +198,103 @@ func signTransaction(this js.Value, args []js.Value) interface{} {
 		return err.Error()
 	}
 
+	if err := json.Unmarshal([]byte(jsonCoverage), &coverage); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding coverage: %s", err), js.Null())
+		return err.Error()
+	}
+
 	for i := 0; i < length; i++ {
 		requiredSigs[i] = uint64(args[3].Index(i).Int())
 	}
 
-	go modules.SignTransaction(txn, phrase, currency, requiredSigs, callback)
+	go modules.SignTransaction(txn, phrase, currency, requiredSigs, &coverage, accountOffset, callback)
+
+	return nil
+}
+
+func hashCoveredFields(this js.Value, args []js.Value) interface{} {
+	var txn siatypes.Transaction
+	var coverage *siatypes.CoveredFields
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	jsonTxn := args[0].String()
+	jsonCoverage := args[1].String()
+	currency := args[2].String()
+	callback := args[3]
+
+	if err := json.Unmarshal([]byte(jsonTxn), &txn); err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return err.Error()
+	}
+
+	// an empty coverage hashes each signature with its own covered fields
+	if len(jsonCoverage) != 0 {
+		if err := json.Unmarshal([]byte(jsonCoverage), &coverage); err != nil {
+			callback.Invoke(fmt.Sprintf("error decoding coverage: %s", err), js.Null())
+			return err.Error()
+		}
+	}
+
+	go modules.HashCoveredFields(txn, coverage, currency, callback)
+
+	return nil
+}
+
+func signTransactionExternal(this js.Value, args []js.Value) interface{} {
+	var txn siatypes.Transaction
+	var coverage *siatypes.CoveredFields
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeFunction, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	currency := args[0].String()
+	jsonTxn := args[1].String()
+	jsonCoverage := args[2].String()
+	signer := args[3]
+	callback := args[4]
+
+	if err := json.Unmarshal([]byte(jsonTxn), &txn); err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return err.Error()
+	}
+
+	// an empty coverage signs each signature with its own covered fields
+	if len(jsonCoverage) != 0 {
+		if err := json.Unmarshal([]byte(jsonCoverage), &coverage); err != nil {
+			callback.Invoke(fmt.Sprintf("error decoding coverage: %s", err), js.Null())
+			return err.Error()
+		}
+	}
+
+	go modules.SignTransactionExternal(txn, coverage, currency, signer, callback)
+
+	return nil
+}
+
+func requiredKeys(this js.Value, args []js.Value) interface{} {
+	var txn siatypes.Transaction
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeNumber, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	jsonTxn := args[0].String()
+	seed := args[1].String()
+	currency := args[2].String()
+	maxIndex := uint64(args[3].Int())
+	accountOffset := uint64(args[4].Int())
+	callback := args[5]
+
+	if err := json.Unmarshal([]byte(jsonTxn), &txn); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding transaction: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.RequiredKeys(txn, seed, currency, maxIndex, accountOffset, callback)
 
 	return nil
 }
@@ -94,21 +302,107 @@ func signTransaction(this js.Value, args []js.Value) interface{} {
 func signTransactions(this js.Value, args []js.Value) interface{} {
 	var unsigned []modules.UnsignedTransaction
 
-	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeNumber, js.TypeFunction); err != nil {
 		return err.Error()
 	}
 
 	phrase := args[0].String()
 	currency := args[1].String()
 	jsonTxns := args[2].String()
+	accountOffset := uint64(args[3].Int())
+	callback := args[4]
+
+	if err := json.Unmarshal([]byte(jsonTxns), &unsigned); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding transactions: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.SignTransactions(unsigned, phrase, currency, accountOffset, callback)
+
+	return nil
+}
+
+func validateTransaction(this js.Value, args []js.Value) interface{} {
+	var txn siatypes.Transaction
+	var spent []apitypes.SiacoinOutput
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	jsonTxn := args[0].String()
+	jsonSpent := args[1].String()
+	currency := args[2].String()
 	callback := args[3]
 
+	if err := json.Unmarshal([]byte(jsonTxn), &txn); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding transaction: %s", err), js.Null())
+		return err.Error()
+	}
+
+	if err := json.Unmarshal([]byte(jsonSpent), &spent); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding spent outputs: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.ValidateTransaction(txn, spent, currency, callback)
+
+	return nil
+}
+
+func balanceCheck(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	txnJSON := args[0].String()
+	outputValuesJSON := args[1].String()
+	callback := args[2]
+
+	go modules.BalanceCheck(txnJSON, outputValuesJSON, callback)
+
+	return nil
+}
+
+func validateMultisig(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	unlockConditionsJSON := args[0].String()
+	callback := args[1]
+
+	go modules.ValidateMultisig(unlockConditionsJSON, callback)
+
+	return nil
+}
+
+func buildTransactionSet(this js.Value, args []js.Value) interface{} {
+	var unsigned []modules.UnsignedTransaction
+	var parents []siatypes.Transaction
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeString, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	phrase := args[0].String()
+	currency := args[1].String()
+	jsonTxns := args[2].String()
+	jsonParents := args[3].String()
+	accountOffset := uint64(args[4].Int())
+	callback := args[5]
+
 	if err := json.Unmarshal([]byte(jsonTxns), &unsigned); err != nil {
 		callback.Invoke(fmt.Sprintf("error decoding transactions: %s", err), js.Null())
 		return err.Error()
 	}
 
-	go modules.SignTransactions(unsigned, phrase, currency, callback)
+	if err := json.Unmarshal([]byte(jsonParents), &parents); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding parent transactions: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.BuildTransactionSet(phrase, currency, unsigned, parents, accountOffset, callback)
 
 	return nil
 }
@@ -135,6 +429,19 @@ func encodeUnlockHash(this js.Value, args []js.Value) interface{} {
 	return nil
 }
 
+func computeUnlockHash(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	unlockConditionsJSON := args[0].String()
+	callback := args[1]
+
+	go modules.ComputeUnlockHash(unlockConditionsJSON, callback)
+
+	return nil
+}
+
 func encodeUnlockHashes(this js.Value, args []js.Value) interface{} {
 	if err := checkArgs(args, js.TypeObject, js.TypeFunction); err != nil {
 		return err.Error()
@@ -176,7 +483,7 @@ func generateSeed(this js.Value, args []js.Value) interface{} {
 }
 
 func generateAddresses(this js.Value, args []js.Value) interface{} {
-	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeNumber, js.TypeNumber, js.TypeFunction); err != nil {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeNumber, js.TypeNumber, js.TypeNumber, js.TypeFunction); err != nil {
 		return err.Error()
 	}
 
@@ -184,77 +491,1326 @@ func generateAddresses(this js.Value, args []js.Value) interface{} {
 	currency := args[1].String()
 	i := args[2].Int()
 	n := args[3].Int()
-	callback := args[4]
+	accountOffset := uint64(args[4].Int())
+	callback := args[5]
 
-	go modules.GetAddresses(phrase, currency, uint64(i), uint64(n), callback)
+	go modules.GetAddresses(phrase, currency, uint64(i), uint64(n), accountOffset, callback)
 
 	return nil
 }
 
-func recoverAddresses(this js.Value, args []js.Value) interface{} {
-	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeNumber, js.TypeNumber, js.TypeNumber, js.TypeNumber, js.TypeFunction); err != nil {
+func generateAddressMap(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeNumber, js.TypeNumber, js.TypeNumber, js.TypeFunction); err != nil {
 		return err.Error()
 	}
 
-	seed := args[0].String()
+	phrase := args[0].String()
 	currency := args[1].String()
 	i := uint64(args[2].Int())
-	maxEmptyRounds := uint64(args[3].Int())
-	addressCount := uint64(args[4].Int())
-	lastKnownIdx := uint64(args[5].Int())
-	callback := args[6]
+	n := uint64(args[3].Int())
+	accountOffset := uint64(args[4].Int())
+	callback := args[5]
 
-	go modules.RecoverAddresses(seed, currency, i, maxEmptyRounds, addressCount, lastKnownIdx, callback)
+	go modules.GenerateAddressMap(phrase, currency, i, n, accountOffset, callback)
 
 	return nil
 }
 
-func getTransactions(this js.Value, args []js.Value) interface{} {
-	if err := checkArgs(args, js.TypeObject, js.TypeString, js.TypeFunction); err != nil {
+func exportWatchOnly(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeNumber, js.TypeNumber, js.TypeNumber, js.TypeFunction); err != nil {
 		return err.Error()
 	}
 
-	count := args[0].Length()
+	phrase := args[0].String()
+	currency := args[1].String()
+	start := uint64(args[2].Int())
+	count := uint64(args[3].Int())
+	accountOffset := uint64(args[4].Int())
+	callback := args[5]
+
+	go modules.ExportWatchOnly(phrase, currency, start, count, accountOffset, callback)
+
+	return nil
+}
+
+func openWatchOnly(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	exportJSON := args[0].String()
 	currency := args[1].String()
 	callback := args[2]
-	addresses := make([]string, count)
 
-	for i := 0; i < count; i++ {
-		addresses[i] = args[0].Index(i).String()
+	go modules.OpenWatchOnly(exportJSON, currency, callback)
+
+	return nil
+}
+
+func exportAddressPool(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeNumber, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	phrase := args[0].String()
+	currency := args[1].String()
+	count := uint64(args[2].Int())
+	accountOffset := uint64(args[3].Int())
+	callback := args[4]
+
+	go modules.ExportAddressPool(phrase, currency, count, accountOffset, callback)
+
+	return nil
+}
+
+func importAddressPool(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
 	}
 
-	go modules.GetTransactions(addresses, currency, callback)
+	phrase := args[0].String()
+	currency := args[1].String()
+	poolJSON := args[2].String()
+	accountOffset := uint64(args[3].Int())
+	callback := args[4]
+
+	go modules.ImportAddressPool(phrase, currency, poolJSON, accountOffset, callback)
 
 	return nil
 }
 
-func exportTransactions(this js.Value, args []js.Value) interface{} {
-	var min, max time.Time
+func generateAddressesBatch(this js.Value, args []js.Value) interface{} {
+	var indices []uint64
 
-	if err := checkArgs(args, js.TypeObject, js.TypeString, js.TypeNumber, js.TypeNumber, js.TypeFunction); err != nil {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeNumber, js.TypeFunction); err != nil {
 		return err.Error()
 	}
 
-	count := args[0].Length()
+	phrase := args[0].String()
 	currency := args[1].String()
-	minTimestamp := args[2].Int()
-	maxTimestamp := args[3].Int()
+	indicesJSON := args[2].String()
+	accountOffset := uint64(args[3].Int())
 	callback := args[4]
-	addresses := make([]string, count)
 
-	for i := 0; i < count; i++ {
-		addresses[i] = args[0].Index(i).String()
+	if err := json.Unmarshal([]byte(indicesJSON), &indices); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding indices: %s", err), js.Null())
+		return err.Error()
 	}
 
-	if minTimestamp > 0 {
-		min = time.Unix(int64(minTimestamp), 0)
+	go modules.GenerateAddressesBatch(phrase, currency, indices, accountOffset, callback)
+
+	return nil
+}
+
+func verifyAddresses(this js.Value, args []js.Value) interface{} {
+	var addresses []string
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeNumber, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
 	}
 
-	if maxTimestamp > 0 {
-		max = time.Unix(int64(maxTimestamp), 0)
+	phrase := args[0].String()
+	currency := args[1].String()
+	addressesJSON := args[2].String()
+	maxIndex := uint64(args[3].Int())
+	accountOffset := uint64(args[4].Int())
+	callback := args[5]
+
+	if err := json.Unmarshal([]byte(addressesJSON), &addresses); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding addresses: %s", err), js.Null())
+		return err.Error()
 	}
 
-	go modules.ExportTransactions(addresses, currency, min, max, callback)
+	go modules.VerifyAddresses(phrase, currency, addresses, maxIndex, accountOffset, callback)
+
+	return nil
+}
+
+func recoverAddresses(this js.Value, args []js.Value) interface{} {
+	var opts modules.RecoveryOptions
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	seed := args[0].String()
+	currency := args[1].String()
+	optsJSON := args[2].String()
+	callback := args[3]
+
+	if err := json.Unmarshal([]byte(optsJSON), &opts); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding options: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.RecoverAddresses(seed, currency, opts, callback)
+
+	return nil
+}
+
+// cancelRecovery does not invoke the callback, the cancelled scan's completion resolves the request
+func cancelRecovery(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	modules.CancelRecovery(args[0].String())
+
+	return nil
+}
+
+func recoverIndices(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeObject, js.TypeBoolean, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	seed := args[0].String()
+	currency := args[1].String()
+	count := args[2].Length()
+	compress := args[3].Bool()
+	accountOffset := uint64(args[4].Int())
+	callback := args[5]
+	indices := make([]uint64, count)
+
+	for i := 0; i < count; i++ {
+		indices[i] = uint64(args[2].Index(i).Int())
+	}
+
+	go modules.RecoverIndices(seed, currency, indices, compress, accountOffset, callback)
+
+	return nil
+}
+
+func getTransactions(this js.Value, args []js.Value) interface{} {
+	var feePerByte siatypes.Currency
+	var seen []modules.SeenTransaction
+
+	if err := checkArgs(args, js.TypeObject, js.TypeString, js.TypeObject, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	count := args[0].Length()
+	currency := args[1].String()
+	pendingCount := args[2].Length()
+	jsonSeen := args[3].String()
+	callback := args[5]
+
+	if err := json.Unmarshal([]byte(jsonSeen), &seen); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding seen transactions: %s", err), js.Null())
+		return err.Error()
+	}
+
+	// an empty fee skips flagging dust outputs
+	if str := args[4].String(); str != "" {
+		var err error
+
+		if feePerByte, err = parseCurrency(str); err != nil {
+			callback.Invoke(err.Error(), js.Null())
+			return err.Error()
+		}
+	}
+	addresses := make([]string, count)
+	pending := make([]string, pendingCount)
+
+	for i := 0; i < count; i++ {
+		addresses[i] = args[0].Index(i).String()
+	}
+
+	for i := 0; i < pendingCount; i++ {
+		pending[i] = args[2].Index(i).String()
+	}
+
+	go modules.GetTransactions(addresses, currency, pending, seen, feePerByte, callback)
+
+	return nil
+}
+
+func getAddressDetails(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	address := args[0].String()
+	currency := args[1].String()
+	callback := args[2]
+
+	go modules.GetAddressDetails(address, currency, callback)
+
+	return nil
+}
+
+func getWalletStats(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeObject, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	count := args[0].Length()
+	currency := args[1].String()
+	callback := args[2]
+	addresses := make([]string, count)
+
+	for i := 0; i < count; i++ {
+		addresses[i] = args[0].Index(i).String()
+	}
+
+	go modules.GetWalletStats(addresses, currency, callback)
+
+	return nil
+}
+
+func getOutputsInRange(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeObject, js.TypeString, js.TypeNumber, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	count := args[0].Length()
+	currency := args[1].String()
+	fromHeight := uint64(args[2].Int())
+	toHeight := uint64(args[3].Int())
+	callback := args[4]
+	addresses := make([]string, count)
+
+	for i := 0; i < count; i++ {
+		addresses[i] = args[0].Index(i).String()
+	}
+
+	go modules.GetOutputsInRange(addresses, currency, fromHeight, toHeight, callback)
+
+	return nil
+}
+
+func exportTransactions(this js.Value, args []js.Value) interface{} {
+	var min, max time.Time
+
+	if err := checkArgs(args, js.TypeObject, js.TypeString, js.TypeNumber, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	count := args[0].Length()
+	currency := args[1].String()
+	minTimestamp := args[2].Int()
+	maxTimestamp := args[3].Int()
+	callback := args[4]
+	addresses := make([]string, count)
+
+	for i := 0; i < count; i++ {
+		addresses[i] = args[0].Index(i).String()
+	}
+
+	if minTimestamp > 0 {
+		min = time.Unix(int64(minTimestamp), 0)
+	}
+
+	if maxTimestamp > 0 {
+		max = time.Unix(int64(maxTimestamp), 0)
+	}
+
+	go modules.ExportTransactions(addresses, currency, min, max, callback)
+
+	return nil
+}
+
+func dustThreshold(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	currency := args[0].String()
+	callback := args[2]
+
+	feePerByte, err := parseCurrency(args[1].String())
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return err.Error()
+	}
+
+	go modules.DustThreshold(currency, feePerByte, callback)
+
+	return nil
+}
+
+func formatAmount(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	currency := args[1].String()
+	asset := args[2].String()
+	callback := args[3]
+
+	value, err := parseCurrency(args[0].String())
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return err.Error()
+	}
+
+	go modules.FormatCurrency(value, currency, asset, callback)
+
+	return nil
+}
+
+func parseAmount(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	str := args[0].String()
+	currency := args[1].String()
+	asset := args[2].String()
+	callback := args[3]
+
+	go modules.ParseCurrency(str, currency, asset, callback)
+
+	return nil
+}
+
+func getTotalClaims(this js.Value, args []js.Value) interface{} {
+	var addresses []string
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	jsonAddresses := args[0].String()
+	currency := args[1].String()
+	callback := args[2]
+
+	if err := json.Unmarshal([]byte(jsonAddresses), &addresses); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding addresses: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.GetTotalClaims(addresses, currency, callback)
+
+	return nil
+}
+
+func getBalanceDelta(this js.Value, args []js.Value) interface{} {
+	var known modules.KnownOutputs
+	var addresses []string
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	jsonKnown := args[0].String()
+	jsonAddresses := args[1].String()
+	currency := args[2].String()
+	callback := args[3]
+
+	if err := json.Unmarshal([]byte(jsonKnown), &known); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding known outputs: %s", err), js.Null())
+		return err.Error()
+	}
+
+	if err := json.Unmarshal([]byte(jsonAddresses), &addresses); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding addresses: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.GetBalanceDelta(known, addresses, currency, callback)
+
+	return nil
+}
+
+func getEffectiveBalance(this js.Value, args []js.Value) interface{} {
+	var addresses []string
+	var policy modules.SpendPolicy
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	jsonAddresses := args[0].String()
+	currency := args[1].String()
+	jsonPolicy := args[2].String()
+	callback := args[3]
+
+	if err := json.Unmarshal([]byte(jsonAddresses), &addresses); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding addresses: %s", err), js.Null())
+		return err.Error()
+	}
+
+	if err := json.Unmarshal([]byte(jsonPolicy), &policy); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding spend policy: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.GetEffectiveBalance(addresses, currency, policy, callback)
+
+	return nil
+}
+
+func getPendingTransactions(this js.Value, args []js.Value) interface{} {
+	var addresses []string
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	jsonAddresses := args[0].String()
+	currency := args[1].String()
+	callback := args[2]
+
+	if err := json.Unmarshal([]byte(jsonAddresses), &addresses); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding addresses: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.GetPendingTransactions(addresses, currency, callback)
+
+	return nil
+}
+
+func exportHistoryCSV(this js.Value, args []js.Value) interface{} {
+	var addresses []string
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	jsonAddresses := args[0].String()
+	currency := args[1].String()
+	callback := args[2]
+
+	if err := json.Unmarshal([]byte(jsonAddresses), &addresses); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding addresses: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.ExportHistoryCSV(addresses, currency, callback)
+
+	return nil
+}
+
+func setAddressLabel(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	address := args[0].String()
+	label := args[1].String()
+	callback := args[2]
+
+	go func() {
+		modules.SetAddressLabel(address, label)
+
+		callback.Invoke(js.Null(), js.Null())
+	}()
+
+	return nil
+}
+
+func getAddressLabels(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeObject, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	count := args[0].Length()
+	callback := args[1]
+	addresses := make([]string, count)
+
+	for i := 0; i < count; i++ {
+		addresses[i] = args[0].Index(i).String()
+	}
+
+	go modules.GetAddressLabels(addresses, callback)
+
+	return nil
+}
+
+func exportLabels(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	callback := args[0]
+
+	go modules.ExportAddressLabels(callback)
+
+	return nil
+}
+
+func importLabels(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	labelsJSON := args[0].String()
+	callback := args[1]
+
+	go modules.ImportAddressLabels(labelsJSON, callback)
+
+	return nil
+}
+
+func reconcileBalance(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeObject, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	count := args[0].Length()
+	currency := args[1].String()
+	callback := args[2]
+	addresses := make([]string, count)
+
+	for i := 0; i < count; i++ {
+		addresses[i] = args[0].Index(i).String()
+	}
+
+	go modules.ReconcileBalance(addresses, currency, callback)
+
+	return nil
+}
+
+func auditAddress(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeNumber, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	seed := args[0].String()
+	currency := args[1].String()
+	address := args[2].String()
+	index := uint64(args[3].Int())
+	accountOffset := uint64(args[4].Int())
+	callback := args[5]
+
+	go modules.AuditAddress(seed, currency, address, index, accountOffset, callback)
+
+	return nil
+}
+
+func estimateRecoveryTime(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeNumber, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	currency := args[0].String()
+	addressCount := uint64(args[1].Int())
+	maxEmptyRounds := uint64(args[2].Int())
+	callback := args[3]
+
+	go modules.EstimateRecoveryTime(currency, addressCount, maxEmptyRounds, callback)
+
+	return nil
+}
+
+func getBlockHeight(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	currency := args[0].String()
+	callback := args[1]
+
+	go modules.GetBlockHeight(currency, callback)
+
+	return nil
+}
+
+func getConfirmations(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeNumber, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	outputBlockHeight := uint64(args[0].Int())
+	currency := args[1].String()
+	callback := args[2]
+
+	go modules.Confirmations(outputBlockHeight, currency, callback)
+
+	return nil
+}
+
+func benchmarkDerivation(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	seed := args[0].String()
+	currency := args[1].String()
+	count := uint64(args[2].Int())
+	callback := args[3]
+
+	go modules.BenchmarkDerivation(seed, currency, count, callback)
+
+	return nil
+}
+
+func pingAPI(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	currency := args[0].String()
+	callback := args[1]
+
+	go modules.PingAPI(currency, callback)
+
+	return nil
+}
+
+func buildDefrag(this js.Value, args []js.Value) interface{} {
+	var outputs []modules.SpendableOutput
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeString, js.TypeNumber, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	outputsJSON := args[0].String()
+	currency := args[1].String()
+	recipient := args[2].String()
+	keep := args[4].Int()
+	callback := args[6]
+
+	if err := json.Unmarshal([]byte(outputsJSON), &outputs); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding outputs: %s", err), js.Null())
+		return err.Error()
+	}
+
+	feePerByte, err := parseCurrency(args[3].String())
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return err.Error()
+	}
+
+	dustThreshold, err := parseCurrency(args[5].String())
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return err.Error()
+	}
+
+	go modules.BuildDefragTransactions(outputs, currency, recipient, feePerByte, keep, dustThreshold, callback)
+
+	return nil
+}
+
+func estimateSweep(this js.Value, args []js.Value) interface{} {
+	var outputs []modules.SpendableOutput
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	outputsJSON := args[0].String()
+	currency := args[1].String()
+	destination := args[2].String()
+	callback := args[4]
+
+	if err := json.Unmarshal([]byte(outputsJSON), &outputs); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding outputs: %s", err), js.Null())
+		return err.Error()
+	}
+
+	feePerByte, err := parseCurrency(args[3].String())
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return err.Error()
+	}
+
+	go modules.EstimateSweep(outputs, currency, destination, feePerByte, callback)
+
+	return nil
+}
+
+func findGaps(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeNumber, js.TypeNumber, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	seed := args[0].String()
+	currency := args[1].String()
+	maxEmptyRounds := uint64(args[2].Int())
+	addressCount := uint64(args[3].Int())
+	accountOffset := uint64(args[4].Int())
+	callback := args[5]
+
+	go modules.FindGaps(seed, currency, maxEmptyRounds, addressCount, accountOffset, callback)
+
+	return nil
+}
+
+func detectWalletType(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	seed := args[0].String()
+	currency := args[1].String()
+	callback := args[2]
+
+	go modules.DetectWalletType(seed, currency, callback)
+
+	return nil
+}
+
+func autoDetectCurrency(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	seed := args[0].String()
+	callback := args[1]
+
+	go modules.AutoDetectCurrency(seed, callback)
+
+	return nil
+}
+
+func validateSeed(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	phrase := args[0].String()
+	currency := args[1].String()
+	callback := args[2]
+
+	go modules.ValidateSeed(phrase, currency, callback)
+
+	return nil
+}
+
+func walletFingerprint(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	phrase := args[0].String()
+	currency := args[1].String()
+	callback := args[2]
+
+	go modules.WalletFingerprint(phrase, currency, callback)
+
+	return nil
+}
+
+func getWordlist(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	seedType := args[0].String()
+	callback := args[1]
+
+	go modules.GetWordlist(seedType, callback)
+
+	return nil
+}
+
+func getLabelKey(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	phrase := args[0].String()
+	currency := args[1].String()
+	callback := args[2]
+
+	go modules.GetLabelKey(phrase, currency, callback)
+
+	return nil
+}
+
+func deriveLabelNonce(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	phrase := args[0].String()
+	currency := args[1].String()
+	address := args[2].String()
+	version := uint64(args[3].Int())
+	callback := args[4]
+
+	go modules.DeriveLabelNonce(phrase, currency, address, version, callback)
+
+	return nil
+}
+
+func getCurrencies(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	callback := args[0]
+
+	go modules.GetSupportedCurrencies(callback)
+
+	return nil
+}
+
+func buildPaymentURI(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	address := args[0].String()
+	label := args[2].String()
+	callback := args[3]
+	amount := siatypes.ZeroCurrency
+
+	if str := args[1].String(); len(str) != 0 {
+		var err error
+
+		if amount, err = parseCurrency(str); err != nil {
+			callback.Invoke(err.Error(), js.Null())
+			return err.Error()
+		}
+	}
+
+	go modules.BuildPaymentURI(address, amount, label, callback)
+
+	return nil
+}
+
+func generateReceiveRequest(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeNumber, js.TypeString, js.TypeString, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	phrase := args[0].String()
+	currency := args[1].String()
+	index := uint64(args[2].Int())
+	label := args[4].String()
+	accountOffset := uint64(args[5].Int())
+	callback := args[6]
+	amount := siatypes.ZeroCurrency
+
+	if str := args[3].String(); len(str) != 0 {
+		var err error
+
+		if amount, err = parseCurrency(str); err != nil {
+			callback.Invoke(err.Error(), js.Null())
+			return err.Error()
+		}
+	}
+
+	go modules.GenerateReceiveRequest(phrase, currency, index, amount, label, accountOffset, callback)
+
+	return nil
+}
+
+func parsePaymentURI(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	uri := args[0].String()
+	callback := args[1]
+
+	go modules.ParsePaymentURI(uri, callback)
+
+	return nil
+}
+
+func previewSend(this js.Value, args []js.Value) interface{} {
+	var outputs []modules.SpendableOutput
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeString, js.TypeString, js.TypeString, js.TypeString, js.TypeNumber, js.TypeBoolean, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	phrase := args[0].String()
+	currency := args[1].String()
+	outputsJSON := args[5].String()
+	strategy := args[6].String()
+	accountOffset := uint64(args[7].Int())
+	feeInclusive := args[8].Bool()
+	outputsHeight := uint64(args[9].Int())
+	callback := args[10]
+
+	amount, err := parseCurrency(args[3].String())
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return err.Error()
+	}
+
+	recipients := []modules.SendRecipient{
+		{Address: args[2].String(), Amount: amount},
+	}
+
+	feePerByte, err := parseCurrency(args[4].String())
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return err.Error()
+	}
+
+	if err := json.Unmarshal([]byte(outputsJSON), &outputs); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding outputs: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.PreviewSend(phrase, currency, recipients, feePerByte, outputs, strategy, accountOffset, feeInclusive, outputsHeight, callback)
+
+	return nil
+}
+
+func countSendInputs(this js.Value, args []js.Value) interface{} {
+	var outputs []modules.SpendableOutput
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeNumber, js.TypeString, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	currency := args[0].String()
+	recipientCount := args[3].Int()
+	outputsJSON := args[4].String()
+	outputsHeight := uint64(args[5].Int())
+	callback := args[6]
+
+	amount, err := parseCurrency(args[1].String())
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return err.Error()
+	}
+
+	feePerByte, err := parseCurrency(args[2].String())
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return err.Error()
+	}
+
+	if err := json.Unmarshal([]byte(outputsJSON), &outputs); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding outputs: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.CountSendInputs(currency, amount, feePerByte, recipientCount, outputs, outputsHeight, callback)
+
+	return nil
+}
+
+func buildUnsignedSend(this js.Value, args []js.Value) interface{} {
+	var recipients []modules.SendRecipient
+	var outputs []modules.SpendableOutput
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeString, js.TypeString, js.TypeBoolean, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	currency := args[0].String()
+	recipientsJSON := args[1].String()
+	outputsJSON := args[3].String()
+	strategy := args[4].String()
+	feeInclusive := args[5].Bool()
+	outputsHeight := uint64(args[6].Int())
+	callback := args[7]
+
+	if err := json.Unmarshal([]byte(recipientsJSON), &recipients); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding recipients: %s", err), js.Null())
+		return err.Error()
+	}
+
+	feePerByte, err := parseCurrency(args[2].String())
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return err.Error()
+	}
+
+	if err := json.Unmarshal([]byte(outputsJSON), &outputs); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding outputs: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.BuildUnsignedSend(currency, recipients, feePerByte, outputs, strategy, feeInclusive, outputsHeight, callback)
+
+	return nil
+}
+
+func previewBatchSend(this js.Value, args []js.Value) interface{} {
+	var recipients []modules.SendRecipient
+	var outputs []modules.SpendableOutput
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeString, js.TypeString, js.TypeString, js.TypeNumber, js.TypeBoolean, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	phrase := args[0].String()
+	currency := args[1].String()
+	recipientsJSON := args[2].String()
+	outputsJSON := args[4].String()
+	strategy := args[5].String()
+	accountOffset := uint64(args[6].Int())
+	feeInclusive := args[7].Bool()
+	outputsHeight := uint64(args[8].Int())
+	callback := args[9]
+
+	if err := json.Unmarshal([]byte(recipientsJSON), &recipients); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding recipients: %s", err), js.Null())
+		return err.Error()
+	}
+
+	feePerByte, err := parseCurrency(args[3].String())
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return err.Error()
+	}
+
+	if err := json.Unmarshal([]byte(outputsJSON), &outputs); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding outputs: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.PreviewSend(phrase, currency, recipients, feePerByte, outputs, strategy, accountOffset, feeInclusive, outputsHeight, callback)
+
+	return nil
+}
+
+func diagnoseTransaction(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	id := args[0].String()
+	currency := args[1].String()
+	callback := args[2]
+
+	go modules.DiagnoseTransaction(id, currency, callback)
+
+	return nil
+}
+
+func estimateFeeForTarget(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	currency := args[0].String()
+	targetBlocks := uint64(args[1].Int())
+	callback := args[2]
+
+	go modules.EstimateFeeForTarget(currency, targetBlocks, callback)
+
+	return nil
+}
+
+func decodeArbitraryData(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	txnJSON := args[0].String()
+	callback := args[1]
+
+	go modules.DecodeArbitraryData(txnJSON, callback)
+
+	return nil
+}
+
+func compareAddressSets(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	expectedJSON := args[0].String()
+	foundJSON := args[1].String()
+	callback := args[2]
+
+	go modules.CompareAddressSets(expectedJSON, foundJSON, callback)
+
+	return nil
+}
+
+func estimateCreationHeight(this js.Value, args []js.Value) interface{} {
+	var addresses []string
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	jsonAddresses := args[0].String()
+	currency := args[1].String()
+	callback := args[2]
+
+	if err := json.Unmarshal([]byte(jsonAddresses), &addresses); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding addresses: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.EstimateCreationHeight(addresses, currency, callback)
+
+	return nil
+}
+
+func addressPrivacyScore(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	address := args[0].String()
+	currency := args[1].String()
+	callback := args[2]
+
+	go modules.AddressPrivacyScore(address, currency, callback)
+
+	return nil
+}
+
+func spendOutput(this js.Value, args []js.Value) interface{} {
+	var outputs []modules.SpendableOutput
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeString, js.TypeString, js.TypeString, js.TypeString, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	phrase := args[0].String()
+	currency := args[1].String()
+	outputID := args[2].String()
+	destination := args[3].String()
+	outputsJSON := args[6].String()
+	accountOffset := uint64(args[7].Int())
+	callback := args[8]
+
+	amount, err := parseCurrency(args[4].String())
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return err.Error()
+	}
+
+	feePerByte, err := parseCurrency(args[5].String())
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return err.Error()
+	}
+
+	if err := json.Unmarshal([]byte(outputsJSON), &outputs); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding outputs: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.SpendOutput(phrase, currency, outputID, destination, amount, feePerByte, outputs, accountOffset, callback)
+
+	return nil
+}
+
+func verifySeedChecksum(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	phrase := args[0].String()
+	currency := args[1].String()
+	callback := args[2]
+
+	go modules.VerifySeedChecksum(phrase, currency, callback)
+
+	return nil
+}
+
+func signSighash(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeNumber, js.TypeString, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	phrase := args[0].String()
+	currency := args[1].String()
+	index := uint64(args[2].Int())
+	sighash := args[3].String()
+	accountOffset := uint64(args[4].Int())
+	callback := args[5]
+
+	go modules.SignSighash(phrase, currency, index, sighash, accountOffset, callback)
+
+	return nil
+}
+
+func counterpartyExposure(this js.Value, args []js.Value) interface{} {
+	var addresses []string
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	jsonAddresses := args[0].String()
+	currency := args[1].String()
+	callback := args[2]
+
+	if err := json.Unmarshal([]byte(jsonAddresses), &addresses); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding addresses: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.CounterpartyExposure(addresses, currency, callback)
+
+	return nil
+}
+
+func describeTransaction(this js.Value, args []js.Value) interface{} {
+	var txn apitypes.Transaction
+	var addresses []string
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	jsonTxn := args[0].String()
+	jsonAddresses := args[1].String()
+	currency := args[2].String()
+	callback := args[3]
+
+	if err := json.Unmarshal([]byte(jsonTxn), &txn); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding transaction: %s", err), js.Null())
+		return err.Error()
+	}
+
+	if err := json.Unmarshal([]byte(jsonAddresses), &addresses); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding addresses: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.DescribeTransaction(txn, addresses, currency, callback)
+
+	return nil
+}
+
+func verifyChange(this js.Value, args []js.Value) interface{} {
+	var txn siatypes.Transaction
+	var recipients []modules.SendRecipient
+
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeString, js.TypeNumber, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	phrase := args[0].String()
+	currency := args[1].String()
+	jsonTxn := args[2].String()
+	recipientsJSON := args[3].String()
+	maxIndex := uint64(args[4].Int())
+	accountOffset := uint64(args[5].Int())
+	callback := args[6]
+
+	if err := json.Unmarshal([]byte(jsonTxn), &txn); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding transaction: %s", err), js.Null())
+		return err.Error()
+	}
+
+	if err := json.Unmarshal([]byte(recipientsJSON), &recipients); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding recipients: %s", err), js.Null())
+		return err.Error()
+	}
+
+	go modules.VerifyChange(phrase, currency, txn, recipients, maxIndex, accountOffset, callback)
+
+	return nil
+}
+
+func recoverSiafundAddresses(this js.Value, args []js.Value) interface{} {
+	if err := checkArgs(args, js.TypeString, js.TypeString, js.TypeString, js.TypeNumber, js.TypeNumber, js.TypeNumber, js.TypeNumber, js.TypeNumber, js.TypeFunction); err != nil {
+		return err.Error()
+	}
+
+	seed := args[0].String()
+	currency := args[1].String()
+	scanID := args[2].String()
+	startIndex := uint64(args[3].Int())
+	addressCount := uint64(args[4].Int())
+	gapLimit := uint64(args[5].Int())
+	maxRetries := uint64(args[6].Int())
+	accountOffset := uint64(args[7].Int())
+	callback := args[8]
+
+	go modules.RecoverSiafundAddresses(seed, currency, scanID, startIndex, addressCount, gapLimit, maxRetries, accountOffset, callback)
 
 	return nil
 }
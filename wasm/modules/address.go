@@ -0,0 +1,82 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"syscall/js"
+
+	apitypes "github.com/siacentral/apisdkgo/types"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+type (
+	//addressDetails the balance, unspent outputs, and transactions of a single address
+	addressDetails struct {
+		Address                string                   `json:"address"`
+		SiacoinBalance         siatypes.Currency        `json:"siacoin_balance"`
+		ImmatureSiacoinBalance siatypes.Currency        `json:"immature_siacoin_balance"`
+		SiafundBalance         siatypes.Currency        `json:"siafund_balance"`
+		SiacoinOutputs         []apitypes.SiacoinOutput `json:"siacoin_outputs"`
+		ImmatureSiacoinOutputs []apitypes.SiacoinOutput `json:"immature_siacoin_outputs"`
+		SiafundOutputs         []apitypes.SiafundOutput `json:"siafund_outputs"`
+		TransactionIDs         []string                 `json:"transaction_ids"`
+		OutputConfirmations    map[string]uint64        `json:"output_confirmations"`
+	}
+)
+
+//computeAddressDetails summarizes the transactions of a single address. An address without any
+//activity has a zero balance and empty lists
+func computeAddressDetails(address string, resp transactionResp) addressDetails {
+	details := addressDetails{
+		Address:                address,
+		SiacoinBalance:         resp.ConfirmedSiacoinBalance,
+		ImmatureSiacoinBalance: resp.ImmatureSiacoinBalance,
+		SiafundBalance:         resp.ConfirmedSiafundBalance,
+		SiacoinOutputs:         []apitypes.SiacoinOutput{},
+		ImmatureSiacoinOutputs: []apitypes.SiacoinOutput{},
+		SiafundOutputs:         []apitypes.SiafundOutput{},
+		TransactionIDs:         []string{},
+		OutputConfirmations:    make(map[string]uint64),
+	}
+
+	details.SiacoinOutputs = append(details.SiacoinOutputs, resp.UnspentSiacoinOutputs...)
+	details.ImmatureSiacoinOutputs = append(details.ImmatureSiacoinOutputs, resp.ImmatureSiacoinOutputs...)
+	details.SiafundOutputs = append(details.SiafundOutputs, resp.UnspentSiafundOutputs...)
+
+	for id, confirmations := range resp.OutputConfirmations {
+		details.OutputConfirmations[id] = confirmations
+	}
+
+	for _, txn := range resp.Transactions {
+		details.TransactionIDs = append(details.TransactionIDs, txn.TransactionID)
+	}
+
+	return details
+}
+
+//GetAddressDetails returns the balance, unspent outputs, and the IDs of every transaction that
+//touched the address
+func GetAddressDetails(address, currency string, callback js.Value) {
+	var uh siatypes.UnlockHash
+
+	if err := uh.LoadString(address); err != nil {
+		callback.Invoke(fmt.Sprintf("invalid address %q: %s", address, err), js.Null())
+		return
+	}
+
+	resp, err := loadTransactions(context.Background(), []string{address}, currency)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(computeAddressDetails(address, resp))
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
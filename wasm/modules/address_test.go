@@ -0,0 +1,31 @@
+package modules
+
+import (
+	"testing"
+
+	apitypes "github.com/siacentral/apisdkgo/types"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+func TestComputeAddressDetails(t *testing.T) {
+	details := computeAddressDetails("addr", transactionResp{})
+	if !details.SiacoinBalance.IsZero() || !details.SiafundBalance.IsZero() {
+		t.Fatal("expected a zero balance for an address without activity")
+	} else if details.SiacoinOutputs == nil || details.SiafundOutputs == nil || details.TransactionIDs == nil {
+		t.Fatal("expected empty lists instead of nil for an address without activity")
+	}
+
+	details = computeAddressDetails("addr", transactionResp{
+		ConfirmedSiacoinBalance: siatypes.SiacoinPrecision.Mul64(10),
+		UnspentSiacoinOutputs:   []apitypes.SiacoinOutput{{OutputID: "a"}, {OutputID: "b"}},
+		Transactions:            []processedTransaction{{TransactionID: "t1"}, {TransactionID: "t2"}},
+	})
+
+	if !details.SiacoinBalance.Equals(siatypes.SiacoinPrecision.Mul64(10)) {
+		t.Fatalf("expected 10 SC balance, got %s", details.SiacoinBalance.HumanString())
+	} else if len(details.SiacoinOutputs) != 2 {
+		t.Fatalf("expected 2 outputs, got %d", len(details.SiacoinOutputs))
+	} else if len(details.TransactionIDs) != 2 || details.TransactionIDs[0] != "t1" || details.TransactionIDs[1] != "t2" {
+		t.Fatalf("expected transaction ids t1 and t2, got %v", details.TransactionIDs)
+	}
+}
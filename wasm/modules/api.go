@@ -0,0 +1,475 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/siacentral/apisdkgo"
+	apitypes "github.com/siacentral/apisdkgo/types"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+const (
+	//requestTimeout the maximum duration of a single API request
+	requestTimeout = 30 * time.Second
+	//apiVersion the version of the Sia Central API the response types are decoded as
+	apiVersion = "v2"
+	//apiVersionHeader the response header the API reports its version in, if any
+	apiVersionHeader = "X-Api-Version"
+)
+
+var (
+	httpClient = &http.Client{}
+
+	transportMu sync.RWMutex
+	transport   HTTPDoer = httpClient
+)
+
+type (
+	//HTTPDoer sends an HTTP request and returns its response. *http.Client satisfies the interface
+	HTTPDoer interface {
+		Do(*http.Request) (*http.Response, error)
+	}
+
+	//apiClient a context aware client for the Sia Central API. The apisdkgo client does not
+	//accept a context so in-flight requests could not be cancelled
+	apiClient struct {
+		BaseAddress string
+		Transport   HTTPDoer
+	}
+
+	latestBlockResp struct {
+		apisdkgo.APIResponse
+		Block apitypes.Block `json:"block"`
+	}
+
+	//unsupportedAPIVersionError returned when a response does not match the shape the wallet
+	//expects. Treating the missing fields as empty would silently return wrong results
+	unsupportedAPIVersionError struct {
+		Observed string
+		Expected string
+		Reason   string
+	}
+
+	//usedAddressesResp one page of the used addresses. Pages is the total number of pages, a
+	//response without it has every used address
+	usedAddressesResp struct {
+		apisdkgo.APIResponse
+		Addresses []apitypes.AddressUsage `json:"addresses"`
+		Pages     int                     `json:"pages,omitempty"`
+	}
+
+	transactionFeesResp struct {
+		apisdkgo.APIResponse
+		Minimum siatypes.Currency `json:"minimum"`
+		Maximum siatypes.Currency `json:"maximum"`
+	}
+
+	transactionByIDResp struct {
+		apisdkgo.APIResponse
+		Transaction apitypes.Transaction `json:"transaction"`
+	}
+)
+
+//SetTransport replaces the transport used for all API requests. Used to add proxies, headers, or
+//logging, or to serve canned responses in tests. A nil transport restores the default HTTP client
+func SetTransport(doer HTTPDoer) {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+
+	if doer == nil {
+		doer = httpClient
+	}
+
+	transport = doer
+}
+
+func currentTransport() HTTPDoer {
+	transportMu.RLock()
+	defer transportMu.RUnlock()
+
+	return transport
+}
+
+func siacentralAPIClient(currency string) *apiClient {
+	return &apiClient{
+		BaseAddress: getCurrencyParams(currency).APIAddress,
+		Transport:   currentTransport(),
+	}
+}
+
+func (e unsupportedAPIVersionError) Error() string {
+	return fmt.Sprintf("unsupported API version: observed %s, expected %s: %s", e.Observed, e.Expected, e.Reason)
+}
+
+//checkResponseShape checks that the response has the envelope every API response shares and, if it
+//was successful, each of the required fields
+func checkResponseShape(buf []byte, version string, required []string) error {
+	var fields map[string]json.RawMessage
+
+	if version == "" {
+		version = "unknown"
+	}
+
+	if err := json.Unmarshal(buf, &fields); err != nil {
+		return unsupportedAPIVersionError{version, apiVersion, "response is not an object"}
+	}
+
+	var respType string
+
+	if err := json.Unmarshal(fields["type"], &respType); err != nil || (respType != "success" && respType != "error") {
+		return unsupportedAPIVersionError{version, apiVersion, "response is missing its type"}
+	}
+
+	if respType != "success" {
+		return nil
+	}
+
+	for _, field := range required {
+		if _, exists := fields[field]; !exists {
+			return unsupportedAPIVersionError{version, apiVersion, fmt.Sprintf("response is missing %q", field)}
+		}
+	}
+
+	return nil
+}
+
+//retryable returns false for errors that will be returned again if the request is retried
+func retryable(err error) bool {
+	var versionErr unsupportedAPIVersionError
+
+	return !errors.As(err, &versionErr)
+}
+
+func drainAndClose(rc io.ReadCloser) {
+	io.Copy(ioutil.Discard, rc)
+	rc.Close()
+}
+
+//makeAPIRequest sends the request and decodes the response into value. A successful response must
+//contain each of the required fields, otherwise an unsupportedAPIVersionError is returned. Other
+//responses are decoded if they can be, or returned as an error with their status code and body
+func (a *apiClient) makeAPIRequest(ctx context.Context, method, url string, body interface{}, value interface{}, required ...string) (statusCode int, err error) {
+	var r io.Reader
+
+	if !strings.HasPrefix(url, "http") {
+		url = a.BaseAddress + url
+	}
+
+	if method != http.MethodGet && body != nil {
+		buf, err := json.Marshal(body)
+
+		if err != nil {
+			return 0, err
+		}
+
+		r = bytes.NewBuffer(buf)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+
+	if err != nil {
+		return
+	}
+
+	doer := a.Transport
+
+	if doer == nil {
+		doer = currentTransport()
+	}
+
+	resp, err := doer.Do(req)
+
+	if err != nil {
+		return
+	}
+
+	defer drainAndClose(resp.Body)
+
+	statusCode = resp.StatusCode
+	buf, err := ioutil.ReadAll(resp.Body)
+
+	if err != nil {
+		return
+	}
+
+	// only successful responses are checked against the API version. A gateway error or an
+	// overloaded server is not a version mismatch and should still be retried
+	if statusCode < 200 || statusCode >= 300 {
+		if json.Unmarshal(buf, value) != nil {
+			err = fmt.Errorf("unexpected status code %d: %s", statusCode, strings.TrimSpace(string(buf)))
+		}
+
+		return
+	}
+
+	if err = checkResponseShape(buf, resp.Header.Get(apiVersionHeader), required); err != nil {
+		return
+	}
+
+	err = json.Unmarshal(buf, value)
+
+	return
+}
+
+//FindAddressBalance gets all unspent outputs and the last n transactions for a list of addresses
+func (a *apiClient) FindAddressBalance(ctx context.Context, limit, page int, addresses []string) (resp apisdkgo.GetTransactionsResp, err error) {
+	if len(addresses) > 10000 {
+		err = errors.New("maximum of 10000 addresses")
+		return
+	}
+
+	code, err := a.makeAPIRequest(ctx, http.MethodPost, fmt.Sprintf("/wallet/addresses?limit=%d&page=%d", limit, page), map[string]interface{}{
+		"addresses": addresses,
+	}, &resp)
+
+	if err != nil {
+		return
+	}
+
+	if code < 200 || code >= 300 || resp.Type != "success" {
+		err = errors.New(resp.Message)
+		return
+	}
+
+	return
+}
+
+//dedupeOutputs removes the unspent outputs already returned by an earlier batch and subtracts
+//their value from the batch's balance. An output can be returned for more than one batch, like a
+//multisig output shared by addresses in different batches or an address listed twice, and would
+//otherwise be counted once per batch
+func dedupeOutputs(responses []apisdkgo.GetTransactionsResp) {
+	seen := make(map[string]bool)
+
+	for i := range responses {
+		resp := &responses[i]
+		siacoins := resp.UnspentSiacoinOutputs[:0]
+		siafunds := resp.UnspentSiafundOutputs[:0]
+
+		for _, output := range resp.UnspentSiacoinOutputs {
+			if seen[output.OutputID] {
+				if resp.UnspentSiacoins.Cmp(output.Value) >= 0 {
+					resp.UnspentSiacoins = resp.UnspentSiacoins.Sub(output.Value)
+				}
+
+				continue
+			}
+
+			seen[output.OutputID] = true
+			siacoins = append(siacoins, output)
+		}
+
+		for _, output := range resp.UnspentSiafundOutputs {
+			if seen[output.OutputID] {
+				if resp.UnspentSiafunds.Cmp(output.Value) >= 0 {
+					resp.UnspentSiafunds = resp.UnspentSiafunds.Sub(output.Value)
+				}
+
+				continue
+			}
+
+			seen[output.OutputID] = true
+			siafunds = append(siafunds, output)
+		}
+
+		resp.UnspentSiacoinOutputs = siacoins
+		resp.UnspentSiafundOutputs = siafunds
+	}
+}
+
+//findBalances gets the balance of the addresses in batches of 1000. The batches are requested by
+//a pool of workers owned by the call and returned in order. The first error cancels the remaining
+//batches. Outputs returned by more than one batch are only kept in the first
+func findBalances(ctx context.Context, currency string, limit int, addresses []string) ([]apisdkgo.GetTransactionsResp, error) {
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	count := len(addresses)
+	apiclient := siacentralAPIClient(currency)
+	responses := make([]apisdkgo.GetTransactionsResp, (count+999)/1000)
+	work := make(chan int)
+
+	wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for n := range work {
+				start, end := n*1e3, n*1e3+1e3
+
+				if end > count {
+					end = count
+				}
+
+				resp, err := apiclient.FindAddressBalance(ctx, limit, 0, addresses[start:end])
+
+				if err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					continue
+				}
+
+				responses[n] = resp
+			}
+		}()
+	}
+
+	for n := range responses {
+		select {
+		case work <- n:
+		case <-ctx.Done():
+		}
+	}
+
+	close(work)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	dedupeOutputs(responses)
+
+	return responses, nil
+}
+
+//FindUsedAddresses gets all addresses that have been seen in a transaction on the blockchain. If
+//the response is paginated every page is fetched and merged, a partial result would under-count
+//the used addresses and end a recovery scan early
+func (a *apiClient) FindUsedAddresses(ctx context.Context, addresses []string) (used []apitypes.AddressUsage, err error) {
+	if len(addresses) > 10000 {
+		err = errors.New("maximum of 10000 addresses")
+		return
+	}
+
+	resp, err := a.findUsedAddressesPage(ctx, addresses, 0)
+
+	if err != nil {
+		return
+	}
+
+	used = resp.Addresses
+
+	// the page count of the first response is used so the requests always end
+	for page := 1; page < resp.Pages; page++ {
+		next, err := a.findUsedAddressesPage(ctx, addresses, page)
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to get page %d of %d: %w", page+1, resp.Pages, err)
+		}
+
+		used = append(used, next.Addresses...)
+	}
+
+	return
+}
+
+//findUsedAddressesPage gets one page of the used addresses, the first page is 0
+func (a *apiClient) findUsedAddressesPage(ctx context.Context, addresses []string, page int) (resp usedAddressesResp, err error) {
+	url := "/wallet/addresses/used"
+
+	if page != 0 {
+		url += fmt.Sprintf("?page=%d", page)
+	}
+
+	code, err := a.makeAPIRequest(ctx, http.MethodPost, url, map[string]interface{}{
+		"addresses": addresses,
+	}, &resp, "addresses")
+
+	if err != nil {
+		return
+	}
+
+	if code < 200 || code >= 300 || resp.Type != "success" {
+		err = errors.New(resp.Message)
+		return
+	}
+
+	return
+}
+
+//GetLatestBlock returns the latest block in the Sia Central explorer
+func (a *apiClient) GetLatestBlock(ctx context.Context) (block apitypes.Block, err error) {
+	var resp latestBlockResp
+
+	code, err := a.makeAPIRequest(ctx, http.MethodGet, "/explorer/blocks", nil, &resp, "block")
+
+	if err != nil {
+		return
+	}
+
+	if code < 200 || code >= 300 || resp.Type != "success" {
+		err = errors.New(resp.Message)
+		return
+	}
+
+	block = resp.Block
+
+	return
+}
+
+//GetTransactionFees returns the current minimum and maximum fee per byte of the Sia network
+func (a *apiClient) GetTransactionFees(ctx context.Context) (min, max siatypes.Currency, err error) {
+	var resp transactionFeesResp
+
+	code, err := a.makeAPIRequest(ctx, http.MethodGet, "/wallet/fees", nil, &resp, "minimum", "maximum")
+
+	if err != nil {
+		return
+	}
+
+	if code < 200 || code >= 300 || resp.Type != "success" {
+		err = errors.New(resp.Message)
+		return
+	}
+
+	min = resp.Minimum
+	max = resp.Maximum
+
+	return
+}
+
+//GetTransaction returns the transaction with the id from the Sia Central explorer, confirmed or in
+//the transaction pool
+func (a *apiClient) GetTransaction(ctx context.Context, id string) (txn apitypes.Transaction, err error) {
+	var resp transactionByIDResp
+
+	code, err := a.makeAPIRequest(ctx, http.MethodGet, fmt.Sprintf("/explorer/transactions/%s", id), nil, &resp, "transaction")
+
+	if err != nil {
+		return
+	}
+
+	if code < 200 || code >= 300 || resp.Type != "success" {
+		err = errors.New(resp.Message)
+		return
+	}
+
+	txn = resp.Transaction
+
+	return
+}
@@ -0,0 +1,307 @@
+package modules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall/js"
+	"testing"
+	"time"
+)
+
+//cannedTransport serves fixed responses keyed by request path so tests can run offline. Handlers
+//build a response from the request for paths that need one, statuses override the status code
+type cannedTransport struct {
+	mu        sync.Mutex
+	responses map[string]string
+	handlers  map[string]func(*http.Request) string
+	statuses  map[string]int
+	requests  []*http.Request
+}
+
+func (c *cannedTransport) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.requests = append(c.requests, req)
+	c.mu.Unlock()
+
+	body, exists := c.responses[req.URL.Path]
+	status := http.StatusOK
+
+	if handler, ok := c.handlers[req.URL.Path]; ok {
+		body, exists = handler(req), true
+	}
+
+	if !exists {
+		body = `{"type":"error","message":"not found"}`
+		status = http.StatusNotFound
+	}
+
+	if code, ok := c.statuses[req.URL.Path]; ok {
+		status = code
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Header:     make(http.Header),
+		Request:    req,
+	}, nil
+}
+
+//invokeCallback passes a callback to fn and waits for it to be called with a result. Progress
+//events are ignored, the returned error message is empty if the callback resolved
+func invokeCallback(t *testing.T, fn func(callback js.Value)) (string, js.Value) {
+	type result struct {
+		err  string
+		data js.Value
+	}
+
+	done := make(chan result, 1)
+	callback := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if args[0].Type() == js.TypeString {
+			if args[0].String() != "progress" {
+				done <- result{err: args[0].String()}
+			}
+
+			return nil
+		}
+
+		done <- result{data: args[1]}
+		return nil
+	})
+	defer callback.Release()
+
+	go fn(callback.Value)
+
+	select {
+	case res := <-done:
+		return res.err, res.data
+	case <-time.After(10 * time.Second):
+		t.Fatal("callback was not called")
+	}
+
+	return "", js.Null()
+}
+
+func TestSetTransport(t *testing.T) {
+	canned := &cannedTransport{
+		responses: map[string]string{
+			"/v2/explorer/blocks": `{"type":"success","block":{"height":1234}}`,
+		},
+	}
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	block, err := siacentralAPIClient("sc").GetLatestBlock(context.Background())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if block.Height != 1234 {
+		t.Fatalf("expected height 1234, got %d", block.Height)
+	}
+
+	if len(canned.requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(canned.requests))
+	}
+
+	if _, err := siacentralAPIClient("sc").FindUsedAddresses(context.Background(), nil); err == nil || err.Error() != "not found" {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	SetTransport(nil)
+
+	if currentTransport() != HTTPDoer(httpClient) {
+		t.Fatal("expected nil transport to restore the default client")
+	}
+}
+
+func TestUnsupportedAPIVersion(t *testing.T) {
+	canned := &cannedTransport{
+		responses: map[string]string{
+			"/v2/explorer/blocks":       `{"status":"ok","data":{"height":1234}}`,
+			"/v2/wallet/addresses/used": `{"type":"success","used":[]}`,
+		},
+	}
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	var versionErr unsupportedAPIVersionError
+
+	_, err := siacentralAPIClient("sc").GetLatestBlock(context.Background())
+
+	if !errors.As(err, &versionErr) {
+		t.Fatalf("expected unsupported API version error, got %v", err)
+	} else if versionErr.Observed != "unknown" || versionErr.Expected != apiVersion {
+		t.Fatalf("unexpected versions %q and %q", versionErr.Observed, versionErr.Expected)
+	} else if retryable(err) {
+		t.Fatal("expected version errors to not be retried")
+	}
+
+	// a missing field is reported instead of being decoded as no used addresses
+	_, err = siacentralAPIClient("sc").FindUsedAddresses(context.Background(), []string{"addr1"})
+
+	if !errors.As(err, &versionErr) || !strings.Contains(err.Error(), `"addresses"`) {
+		t.Fatalf("expected missing addresses error, got %v", err)
+	}
+
+	// error responses do not need the success fields
+	if _, err = siacentralAPIClient("scp").FindUsedAddresses(context.Background(), nil); err == nil || err.Error() != "not found" {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	// a gateway error is not checked against the API version and can be retried
+	canned.responses["/v2/explorer/blocks"] = "<html>502 Bad Gateway</html>"
+	canned.statuses = map[string]int{"/v2/explorer/blocks": http.StatusBadGateway}
+
+	_, err = siacentralAPIClient("sc").GetLatestBlock(context.Background())
+
+	if err == nil || errors.As(err, &versionErr) || !strings.Contains(err.Error(), "502") {
+		t.Fatalf("expected status code error, got %v", err)
+	} else if !retryable(err) {
+		t.Fatal("expected gateway errors to be retried")
+	}
+}
+
+func TestFindUsedAddressesPages(t *testing.T) {
+	pages := map[string]string{
+		"":  `{"type":"success","pages":3,"addresses":[{"address":"addr1"}]}`,
+		"1": `{"type":"success","pages":3,"addresses":[{"address":"addr2"},{"address":"addr3"}]}`,
+		"2": `{"type":"success","pages":3,"addresses":[{"address":"addr4"}]}`,
+	}
+
+	canned := &cannedTransport{
+		handlers: map[string]func(*http.Request) string{
+			"/v2/wallet/addresses/used": func(req *http.Request) string {
+				if body, exists := pages[req.URL.Query().Get("page")]; exists {
+					return body
+				}
+
+				return `{"type":"error","message":"page not found"}`
+			},
+		},
+	}
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	used, err := siacentralAPIClient("sc").FindUsedAddresses(context.Background(), []string{"addr1", "addr2", "addr3", "addr4"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(used) != 4 || used[0].Address != "addr1" || used[3].Address != "addr4" {
+		t.Fatalf("expected the used addresses of every page, got %v", used)
+	} else if len(canned.requests) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(canned.requests))
+	}
+
+	// a failed page fails the request instead of returning a partial result
+	delete(pages, "2")
+
+	if _, err := siacentralAPIClient("sc").FindUsedAddresses(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "page 3 of 3") {
+		t.Fatalf("expected the missing page to fail, got %v", err)
+	}
+}
+
+func TestFindBalancesConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+
+	canned := &cannedTransport{
+		handlers: map[string]func(*http.Request) string{
+			"/v2/wallet/addresses": func(req *http.Request) string {
+				var body struct {
+					Addresses []string `json:"addresses"`
+				}
+
+				mu.Lock()
+				inFlight++
+				if inFlight > maxInFlight {
+					maxInFlight = inFlight
+				}
+				mu.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+
+				if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+					return `{"type":"error","message":"bad request"}`
+				}
+
+				// the first address of the batch identifies it in the response
+				return fmt.Sprintf(`{"type":"success","unspent_siacoins":"%s"}`, body.Addresses[0])
+			},
+		},
+	}
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	addresses := make([]string, 5500)
+	for i := range addresses {
+		addresses[i] = fmt.Sprint(i)
+	}
+
+	responses, err := findBalances(context.Background(), "sc", 1, addresses)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(responses) != 6 {
+		t.Fatalf("expected 6 batches, got %d", len(responses))
+	}
+
+	for i, resp := range responses {
+		if resp.UnspentSiacoins.Cmp64(uint64(i*1000)) != 0 {
+			t.Fatalf("expected batch %d to start at address %d, got %s", i, i*1000, resp.UnspentSiacoins)
+		}
+	}
+
+	if maxInFlight > workers {
+		t.Fatalf("expected at most %d requests in flight, got %d", workers, maxInFlight)
+	} else if maxInFlight < 2 {
+		t.Fatalf("expected the batches to be requested concurrently, got %d in flight", maxInFlight)
+	}
+
+	// each call has its own workers, a running call does not hold back another
+	var wg sync.WaitGroup
+
+	maxInFlight = 0
+	wg.Add(2)
+
+	for i := 0; i < 2; i++ {
+		go func() {
+			defer wg.Done()
+
+			if _, err := findBalances(context.Background(), "sc", 1, addresses); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if maxInFlight <= workers {
+		t.Fatalf("expected more than %d requests in flight across both calls, got %d", workers, maxInFlight)
+	}
+
+	// a failed batch fails the request instead of returning a partial result
+	delete(canned.handlers, "/v2/wallet/addresses")
+
+	if _, err := findBalances(context.Background(), "sc", 1, addresses); err == nil || err.Error() != "not found" {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
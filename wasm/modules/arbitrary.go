@@ -0,0 +1,207 @@
+package modules
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"syscall/js"
+	"unicode"
+	"unicode/utf8"
+
+	siacrypto "gitlab.com/NebulousLabs/Sia/crypto"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+//The prefixes are copied from the Sia code base, the modules package cannot be used because of the
+//wasm target
+var (
+	//https://gitlab.com/NebulousLabs/Sia/blob/v1.5.3/modules/negotiate.go#L184
+	prefixHostAnnouncement = siatypes.NewSpecifier("HostAnnouncement")
+	//https://gitlab.com/NebulousLabs/Sia/blob/v1.5.3/modules/negotiate.go#L189
+	prefixFileContractIdentifier = siatypes.NewSpecifier("FCIdentifier")
+	//https://gitlab.com/NebulousLabs/Sia/blob/v1.5.3/modules/transactionpool.go#L52
+	prefixNonSia = siatypes.NewSpecifier("NonSia")
+)
+
+type (
+	//decodedAnnouncement a host announcement. ValidSignature is false if the announcement was not
+	//signed by its public key, hosts ignore those announcements
+	decodedAnnouncement struct {
+		NetAddress     string `json:"net_address"`
+		PublicKey      string `json:"public_key"`
+		ValidSignature bool   `json:"valid_signature"`
+	}
+
+	//decodedArbitraryData one of a transaction's arbitrary data fields. Type is
+	//"host_announcement", "file_contract_identifier", "non_sia", or "unknown". Text is set if the
+	//data after a non-Sia prefix is printable
+	decodedArbitraryData struct {
+		Index        int                  `json:"index"`
+		Type         string               `json:"type"`
+		Prefix       string               `json:"prefix,omitempty"`
+		Size         int                  `json:"size"`
+		Hex          string               `json:"hex"`
+		Text         string               `json:"text,omitempty"`
+		Announcement *decodedAnnouncement `json:"announcement,omitempty"`
+		Error        string               `json:"error,omitempty"`
+	}
+)
+
+//readPrefixed reads a length prefixed field in the Sia encoding from the start of buf and returns
+//it and the rest of buf
+func readPrefixed(buf []byte) (field, rest []byte, err error) {
+	if len(buf) < 8 {
+		return nil, nil, errors.New("missing length prefix")
+	}
+
+	n := binary.LittleEndian.Uint64(buf[:8])
+	buf = buf[8:]
+
+	if n > uint64(len(buf)) {
+		return nil, nil, fmt.Errorf("length %d exceeds the remaining %d bytes", n, len(buf))
+	}
+
+	return buf[:n], buf[n:], nil
+}
+
+//decodeAnnouncement decodes a host announcement, the specifier, net address, and public key in
+//the Sia encoding followed by a signature of their hash
+func decodeAnnouncement(data []byte) (ann decodedAnnouncement, err error) {
+	var spk siatypes.SiaPublicKey
+	var sig siacrypto.Signature
+
+	buf := data[siatypes.SpecifierLen:]
+	addr, buf, err := readPrefixed(buf)
+
+	if err != nil {
+		return ann, fmt.Errorf("unable to decode net address: %w", err)
+	}
+
+	if len(buf) < siatypes.SpecifierLen {
+		return ann, errors.New("missing public key algorithm")
+	}
+
+	copy(spk.Algorithm[:], buf[:siatypes.SpecifierLen])
+
+	spk.Key, buf, err = readPrefixed(buf[siatypes.SpecifierLen:])
+
+	if err != nil {
+		return ann, fmt.Errorf("unable to decode public key: %w", err)
+	}
+
+	if len(buf) < siacrypto.SignatureSize {
+		return ann, errors.New("missing signature")
+	}
+
+	copy(sig[:], buf[:siacrypto.SignatureSize])
+
+	ann.NetAddress = string(addr)
+	ann.PublicKey = spk.String()
+
+	// only ed25519 announcements are accepted by hosts
+	if spk.Algorithm == siatypes.SignatureEd25519 && len(spk.Key) == siacrypto.PublicKeySize {
+		var pk siacrypto.PublicKey
+
+		copy(pk[:], spk.Key)
+		signed := data[:len(data)-len(buf)]
+		ann.ValidSignature = siacrypto.VerifyHash(siacrypto.HashBytes(signed), pk, sig) == nil
+	}
+
+	return
+}
+
+//printable returns true if the data is valid UTF-8 without control characters other than
+//whitespace
+func printable(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+//decodeArbitraryData interprets each of the arbitrary data fields by its prefix. Every field
+//includes its hex encoding, data that cannot be decoded falls back to it with the error set
+func decodeArbitraryData(arbitraryData [][]byte) []decodedArbitraryData {
+	decoded := make([]decodedArbitraryData, len(arbitraryData))
+
+	for i, data := range arbitraryData {
+		var prefix siatypes.Specifier
+
+		field := decodedArbitraryData{
+			Index: i,
+			Type:  "unknown",
+			Size:  len(data),
+			Hex:   hex.EncodeToString(data),
+		}
+
+		if len(data) >= siatypes.SpecifierLen {
+			copy(prefix[:], data[:siatypes.SpecifierLen])
+		}
+
+		switch prefix {
+		case prefixHostAnnouncement:
+			field.Type = "host_announcement"
+			field.Prefix = prefix.String()
+
+			ann, err := decodeAnnouncement(data)
+
+			if err != nil {
+				field.Error = err.Error()
+				break
+			}
+
+			field.Announcement = &ann
+		case prefixFileContractIdentifier:
+			// the identifier is encrypted by the renter, only the prefix can be read
+			field.Type = "file_contract_identifier"
+			field.Prefix = prefix.String()
+		case prefixNonSia:
+			field.Type = "non_sia"
+			field.Prefix = prefix.String()
+
+			if rest := data[siatypes.SpecifierLen:]; len(rest) != 0 && printable(rest) {
+				field.Text = string(rest)
+			}
+		}
+
+		decoded[i] = field
+	}
+
+	return decoded
+}
+
+//DecodeArbitraryData decodes the arbitrary data of a transaction for display. Known prefixes,
+//like host announcements, are returned structured, unknown data is only returned as hex
+func DecodeArbitraryData(txnJSON string, callback js.Value) {
+	var txn siatypes.Transaction
+
+	if err := json.Unmarshal([]byte(txnJSON), &txn); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding transaction: %s", err), js.Null())
+		return
+	}
+
+	decoded := decodeArbitraryData(txn.ArbitraryData)
+	resp := make([]interface{}, len(decoded))
+
+	for i, field := range decoded {
+		data, err := interfaceToJSON(field)
+
+		if err != nil {
+			callback.Invoke(err.Error(), js.Null())
+			return
+		}
+
+		resp[i] = data
+	}
+
+	callback.Invoke(js.Null(), resp)
+}
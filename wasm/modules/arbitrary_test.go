@@ -0,0 +1,71 @@
+package modules
+
+import (
+	"encoding/binary"
+	"testing"
+
+	siacrypto "gitlab.com/NebulousLabs/Sia/crypto"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+//appendPrefixed appends the data with its length prefix in the Sia encoding
+func appendPrefixed(buf, data []byte) []byte {
+	var prefix [8]byte
+
+	binary.LittleEndian.PutUint64(prefix[:], uint64(len(data)))
+	return append(append(buf, prefix[:]...), data...)
+}
+
+func TestDecodeArbitraryData(t *testing.T) {
+	sk, pk := siacrypto.GenerateKeyPair()
+	spk := siatypes.Ed25519PublicKey(pk)
+
+	ann := append([]byte(nil), prefixHostAnnouncement[:]...)
+	ann = appendPrefixed(ann, []byte("host.example.com:9982"))
+	ann = append(ann, spk.Algorithm[:]...)
+	ann = appendPrefixed(ann, spk.Key)
+	sig := siacrypto.SignHash(siacrypto.HashBytes(ann), sk)
+	signed := append(append([]byte(nil), ann...), sig[:]...)
+
+	forged := append([]byte(nil), signed...)
+	forged[len(forged)-1] ^= 1
+
+	decoded := decodeArbitraryData([][]byte{
+		signed,
+		forged,
+		ann,
+		append(append([]byte(nil), prefixNonSia[:]...), "hello sia"...),
+		append(append([]byte(nil), prefixNonSia[:]...), 0xff, 0x00),
+		{1, 2, 3},
+	})
+
+	if len(decoded) != 6 {
+		t.Fatalf("expected 6 fields, got %d", len(decoded))
+	}
+
+	if field := decoded[0]; field.Type != "host_announcement" || field.Announcement == nil || !field.Announcement.ValidSignature {
+		t.Fatalf("expected a valid host announcement, got %+v", field)
+	} else if field.Announcement.NetAddress != "host.example.com:9982" || field.Announcement.PublicKey != spk.String() {
+		t.Fatalf("expected the announced address and key, got %+v", field.Announcement)
+	}
+
+	if field := decoded[1]; field.Announcement == nil || field.Announcement.ValidSignature {
+		t.Fatalf("expected the forged announcement to have an invalid signature, got %+v", field)
+	}
+
+	if field := decoded[2]; field.Announcement != nil || field.Error == "" {
+		t.Fatalf("expected the unsigned announcement to fail to decode, got %+v", field)
+	}
+
+	if field := decoded[3]; field.Type != "non_sia" || field.Text != "hello sia" {
+		t.Fatalf("expected non-Sia text, got %+v", field)
+	}
+
+	if field := decoded[4]; field.Type != "non_sia" || field.Text != "" {
+		t.Fatalf("expected binary non-Sia data to not be returned as text, got %+v", field)
+	}
+
+	if field := decoded[5]; field.Type != "unknown" || field.Hex != "010203" || field.Size != 3 {
+		t.Fatalf("expected unknown data as hex, got %+v", field)
+	}
+}
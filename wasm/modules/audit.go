@@ -0,0 +1,191 @@
+package modules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"syscall/js"
+
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+type (
+	//auditReport the result of checking an address against the wallet and the blockchain
+	auditReport struct {
+		Address        string            `json:"address"`
+		Index          uint64            `json:"index"`
+		Derived        string            `json:"derived"`
+		Match          bool              `json:"match"`
+		Used           bool              `json:"used"`
+		UsageTypes     []string          `json:"usage_types"`
+		Label          string            `json:"label,omitempty"`
+		SiacoinBalance siatypes.Currency `json:"siacoin_balance"`
+		SiafundBalance siatypes.Currency `json:"siafund_balance"`
+		UnspentOutputs int               `json:"unspent_outputs"`
+	}
+
+	//addressSetDiff the addresses of one set missing from the other. Matched is the number of
+	//addresses in both
+	addressSetDiff struct {
+		Missing    []string `json:"missing"`
+		Unexpected []string `json:"unexpected"`
+		Matched    int      `json:"matched"`
+	}
+)
+
+//AuditAddress derives the address at the claimed index of the account and confirms it matches the
+//address. If it matches the address's usage and balance are queried from the API. A mismatched
+//index is reported instead of returning an error
+func AuditAddress(seed, currency, address string, index, accountOffset uint64, callback js.Value) {
+	ctx := context.Background()
+
+	w, err := recoverWallet(seed, currency, accountOffset)
+
+	if err != nil {
+		callback.Invoke(fmt.Errorf("unable to recover wallet: %w", err).Error(), js.Null())
+		return
+	}
+
+	if _, err := parseAddress(address); err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	report := auditReport{
+		Address:    address,
+		Index:      index,
+		Derived:    generateAddress(w, index).Address,
+		UsageTypes: []string{},
+		Label:      addressLabels.Get(address),
+	}
+
+	report.Match = report.Derived == address
+
+	if report.Match {
+		apiclient := siacentralAPIClient(currency)
+		used, err := apiclient.FindUsedAddresses(ctx, []string{address})
+
+		if err != nil {
+			callback.Invoke(fmt.Errorf("unable to get address usage: %w", err).Error(), js.Null())
+			return
+		}
+
+		for _, usage := range used {
+			if usage.Address != address {
+				continue
+			}
+
+			report.Used = true
+			report.UsageTypes = append(report.UsageTypes, usage.UsageType)
+		}
+
+		balance, err := apiclient.FindAddressBalance(ctx, 1, 0, []string{address})
+
+		if err != nil {
+			callback.Invoke(fmt.Errorf("unable to get address balance: %w", err).Error(), js.Null())
+			return
+		}
+
+		report.SiacoinBalance = balance.UnspentSiacoins
+		report.SiafundBalance = balance.UnspentSiafunds
+		report.UnspentOutputs = len(balance.UnspentSiacoinOutputs) + len(balance.UnspentSiafundOutputs)
+	}
+
+	data, err := interfaceToJSON(report)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//addressSet parses the addresses into a set of their normalized strings
+func addressSet(addresses []string) (map[string]bool, error) {
+	set := make(map[string]bool, len(addresses))
+
+	for _, address := range addresses {
+		uh, err := parseAddress(address)
+
+		if err != nil {
+			return nil, err
+		}
+
+		set[uh.String()] = true
+	}
+
+	return set, nil
+}
+
+//compareAddressSets returns the expected addresses that were not found and the found addresses
+//that were not expected, each sorted. Duplicates are only counted once
+func compareAddressSets(expected, found []string) (diff addressSetDiff, err error) {
+	expectedSet, err := addressSet(expected)
+
+	if err != nil {
+		return
+	}
+
+	foundSet, err := addressSet(found)
+
+	if err != nil {
+		return
+	}
+
+	diff.Missing = []string{}
+	diff.Unexpected = []string{}
+
+	for address := range expectedSet {
+		if foundSet[address] {
+			diff.Matched++
+		} else {
+			diff.Missing = append(diff.Missing, address)
+		}
+	}
+
+	for address := range foundSet {
+		if !expectedSet[address] {
+			diff.Unexpected = append(diff.Unexpected, address)
+		}
+	}
+
+	sort.Strings(diff.Missing)
+	sort.Strings(diff.Unexpected)
+
+	return
+}
+
+//CompareAddressSets compares the addresses a wallet is expected to have, like the addresses of the
+//wallet it is migrated from, to the addresses found by a recovery. Missing addresses may be past
+//the gap limit of the scan
+func CompareAddressSets(expectedJSON, foundJSON string, callback js.Value) {
+	var expected, found []string
+
+	if err := json.Unmarshal([]byte(expectedJSON), &expected); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding expected addresses: %s", err), js.Null())
+		return
+	}
+
+	if err := json.Unmarshal([]byte(foundJSON), &found); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding found addresses: %s", err), js.Null())
+		return
+	}
+
+	diff, err := compareAddressSets(expected, found)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(diff)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
@@ -0,0 +1,96 @@
+package modules
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+	"testing"
+)
+
+func TestCompareAddressSets(t *testing.T) {
+	w, err := recoverWallet(testPhrase, "sc", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addresses := make([]string, 4)
+	for i := range addresses {
+		addresses[i] = w.GetAddress(uint64(i)).UnlockConditions.UnlockHash().String()
+	}
+
+	// addresses are compared normalized and duplicates are counted once
+	expected := []string{addresses[0], strings.ToUpper(addresses[1]), addresses[2], addresses[2]}
+	found := []string{addresses[0], addresses[1], addresses[3]}
+
+	diff, err := compareAddressSets(expected, found)
+	if err != nil {
+		t.Fatal(err)
+	} else if diff.Matched != 2 {
+		t.Fatalf("expected 2 matched addresses, got %d", diff.Matched)
+	} else if len(diff.Missing) != 1 || diff.Missing[0] != addresses[2] {
+		t.Fatalf("expected address 2 to be missing, got %v", diff.Missing)
+	} else if len(diff.Unexpected) != 1 || diff.Unexpected[0] != addresses[3] {
+		t.Fatalf("expected address 3 to be unexpected, got %v", diff.Unexpected)
+	}
+
+	if _, err := compareAddressSets([]string{"not an address"}, nil); err == nil {
+		t.Fatal("expected an invalid address to fail")
+	}
+}
+
+func TestAuditAddress(t *testing.T) {
+	w, err := recoverWallet(testPhrase, "sc", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address := generateAddress(w, 3).Address
+
+	canned := usedAddressTransport(map[string]string{address: "received"})
+	canned.responses["/v2/wallet/addresses"] = fmt.Sprintf(`{"type":"success","unspent_siacoins":"100","unspent_siacoin_outputs":[{"output_id":"sc1","unlock_hash":%q,"value":"100"}]}`, address)
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	errMsg, report := invokeCallback(t, func(callback js.Value) {
+		AuditAddress(testPhrase, "sc", address, 3, 0, callback)
+	})
+	if errMsg != "" {
+		t.Fatal(errMsg)
+	} else if !report.Get("match").Bool() || !report.Get("used").Bool() {
+		t.Fatal("expected the address to match index 3 and be used")
+	} else if report.Get("siacoin_balance").String() != "100" || report.Get("unspent_outputs").Int() != 1 {
+		t.Fatalf("expected a balance of 100 in 1 output, got %s", report.Get("siacoin_balance").String())
+	}
+
+	// a wrong index is reported without querying the API
+	requests := len(canned.requests)
+
+	errMsg, report = invokeCallback(t, func(callback js.Value) {
+		AuditAddress(testPhrase, "sc", address, 4, 0, callback)
+	})
+	if errMsg != "" {
+		t.Fatal(errMsg)
+	} else if report.Get("match").Bool() || report.Get("used").Bool() {
+		t.Fatal("expected index 4 to not match")
+	} else if report.Get("derived").String() != generateAddress(w, 4).Address {
+		t.Fatalf("expected the address of index 4 to be derived, got %s", report.Get("derived").String())
+	} else if len(canned.requests) != requests {
+		t.Fatal("expected a mismatched address to not be queried")
+	}
+
+	// the index is relative to the account offset
+	offset, err := recoverWallet(testPhrase, "sc", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errMsg, report = invokeCallback(t, func(callback js.Value) {
+		AuditAddress(testPhrase, "sc", generateAddress(offset, 3).Address, 3, 1000, callback)
+	})
+	if errMsg != "" {
+		t.Fatal(errMsg)
+	} else if !report.Get("match").Bool() {
+		t.Fatal("expected the address to match index 3 of the account")
+	}
+}
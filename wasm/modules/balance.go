@@ -0,0 +1,415 @@
+package modules
+
+import (
+	"context"
+	"math/big"
+	"syscall/js"
+
+	apitypes "github.com/siacentral/apisdkgo/types"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+type (
+	balanceReconciliation struct {
+		Reported   siatypes.Currency `json:"reported"`
+		Outputs    siatypes.Currency `json:"outputs"`
+		Difference string            `json:"difference"`
+		Tolerance  siatypes.Currency `json:"tolerance"`
+		Match      bool              `json:"match"`
+	}
+
+	siafundClaim struct {
+		OutputID string            `json:"output_id"`
+		Value    siatypes.Currency `json:"value"`
+		Claim    siatypes.Currency `json:"claim"`
+	}
+
+	totalClaimsResp struct {
+		Siafunds siatypes.Currency `json:"siafunds"`
+		Total    siatypes.Currency `json:"total"`
+		Outputs  []siafundClaim    `json:"outputs"`
+	}
+
+	// KnownOutputs the unspent outputs from the previous scan, the same fields as a GetTransactions
+	// response so its result can be passed back unchanged
+	KnownOutputs struct {
+		SiacoinOutputs []apitypes.SiacoinOutput `json:"unspent_siacoin_outputs"`
+		SiafundOutputs []apitypes.SiafundOutput `json:"unspent_siafund_outputs"`
+	}
+
+	balanceDelta struct {
+		NewSiacoinOutputs     []apitypes.SiacoinOutput `json:"new_siacoin_outputs"`
+		ChangedSiacoinOutputs []apitypes.SiacoinOutput `json:"changed_siacoin_outputs"`
+		SpentSiacoinOutputs   []string                 `json:"spent_siacoin_outputs"`
+		NewSiafundOutputs     []apitypes.SiafundOutput `json:"new_siafund_outputs"`
+		ChangedSiafundOutputs []apitypes.SiafundOutput `json:"changed_siafund_outputs"`
+		SpentSiafundOutputs   []string                 `json:"spent_siafund_outputs"`
+		SiacoinDelta          string                   `json:"siacoin_delta"`
+		SiafundDelta          string                   `json:"siafund_delta"`
+	}
+
+	// SpendPolicy the rules an output must pass to be spent. Pending are the outputs spent by
+	// broadcast transactions that are not in the transaction pool yet. Timelocks are the timelocks of
+	// the unlock conditions of any timelocked addresses, the API does not report them. An empty
+	// fee per byte does not exclude dust
+	SpendPolicy struct {
+		MinConfirmations uint64            `json:"min_confirmations"`
+		FeePerByte       siatypes.Currency `json:"fee_per_byte"`
+		Pending          []string          `json:"pending"`
+		Timelocks        map[string]uint64 `json:"timelocks"`
+	}
+
+	//excludedOutput an unspent output that cannot be spent under the policy
+	excludedOutput struct {
+		OutputID string            `json:"output_id"`
+		Value    siatypes.Currency `json:"value"`
+		Reason   string            `json:"reason"`
+	}
+
+	//exclusionTotal the number and value of the outputs excluded for a reason
+	exclusionTotal struct {
+		Outputs int               `json:"outputs"`
+		Value   siatypes.Currency `json:"value"`
+	}
+
+	effectiveBalanceResp struct {
+		Spendable        siatypes.Currency         `json:"spendable"`
+		SpendableOutputs int                       `json:"spendable_outputs"`
+		Excluded         []excludedOutput          `json:"excluded"`
+		Exclusions       map[string]exclusionTotal `json:"exclusions"`
+	}
+
+	reconcileResp struct {
+		Siacoins balanceReconciliation `json:"siacoins"`
+		Siafunds balanceReconciliation `json:"siafunds"`
+		Match    bool                  `json:"match"`
+	}
+)
+
+//reconcile compares the reported balance to the sum of the outputs. The balances are considered
+//matching if the difference is within the tolerance
+func (r *balanceReconciliation) reconcile() {
+	diff := new(big.Int).Sub(r.Reported.Big(), r.Outputs.Big())
+
+	r.Difference = diff.String()
+	r.Match = diff.CmpAbs(r.Tolerance.Big()) <= 0
+}
+
+//ReconcileBalance sums the unspent outputs of the addresses and compares the total to the
+//balance reported by the API. Any unconfirmed value moving in or out of the wallet is used as the
+//tolerance since the API may or may not have applied it to both values yet
+func ReconcileBalance(addresses []string, currency string, callback js.Value) {
+	var resp reconcileResp
+
+	ctx := context.Background()
+	ownedAddresses := make(map[string]bool)
+
+	for _, addr := range addresses {
+		ownedAddresses[addr] = true
+	}
+
+	responses, err := findBalances(ctx, currency, 1, addresses)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	for _, callResp := range responses {
+		resp.Siacoins.Reported = resp.Siacoins.Reported.Add(callResp.UnspentSiacoins)
+		resp.Siafunds.Reported = resp.Siafunds.Reported.Add(callResp.UnspentSiafunds)
+
+		for _, output := range callResp.UnspentSiacoinOutputs {
+			resp.Siacoins.Outputs = resp.Siacoins.Outputs.Add(output.Value)
+		}
+
+		for _, output := range callResp.UnspentSiafundOutputs {
+			resp.Siafunds.Outputs = resp.Siafunds.Outputs.Add(output.Value)
+		}
+
+		for _, txn := range callResp.UnconfirmedTransactions {
+			for _, output := range txn.SiacoinOutputs {
+				if _, exists := ownedAddresses[output.UnlockHash]; exists {
+					resp.Siacoins.Tolerance = resp.Siacoins.Tolerance.Add(output.Value)
+				}
+			}
+
+			for _, input := range txn.SiacoinInputs {
+				if _, exists := ownedAddresses[input.UnlockHash]; exists {
+					resp.Siacoins.Tolerance = resp.Siacoins.Tolerance.Add(input.Value)
+				}
+			}
+
+			for _, output := range txn.SiafundOutputs {
+				if _, exists := ownedAddresses[output.UnlockHash]; exists {
+					resp.Siafunds.Tolerance = resp.Siafunds.Tolerance.Add(output.Value)
+				}
+			}
+
+			for _, input := range txn.SiafundInputs {
+				if _, exists := ownedAddresses[input.UnlockHash]; exists {
+					resp.Siafunds.Tolerance = resp.Siafunds.Tolerance.Add(input.Value)
+				}
+			}
+		}
+	}
+
+	resp.Siacoins.reconcile()
+	resp.Siafunds.reconcile()
+	resp.Match = resp.Siacoins.Match && resp.Siafunds.Match
+
+	data, err := interfaceToJSON(resp)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//sumClaims totals the siacoin claims of the siafund outputs. Each output's claim is computed by the
+//API from its own claim start, rounded down the same as consensus, so the claims are summed
+//instead of computing one claim from the total siafunds
+func sumClaims(outputs []apitypes.SiafundOutput) (resp totalClaimsResp) {
+	resp.Outputs = make([]siafundClaim, 0, len(outputs))
+
+	for _, output := range outputs {
+		resp.Siafunds = resp.Siafunds.Add(output.Value)
+		resp.Total = resp.Total.Add(output.SiacoinClaim)
+		resp.Outputs = append(resp.Outputs, siafundClaim{
+			OutputID: output.OutputID,
+			Value:    output.Value,
+			Claim:    output.SiacoinClaim,
+		})
+	}
+
+	return
+}
+
+//GetTotalClaims sums the unclaimed siacoin revenue of the wallet's siafund outputs. The claim is
+//paid out to the claim address when the output is spent
+func GetTotalClaims(addresses []string, currency string, callback js.Value) {
+	var outputs []apitypes.SiafundOutput
+
+	ctx := context.Background()
+	responses, err := findBalances(ctx, currency, 1, addresses)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	for _, callResp := range responses {
+		outputs = append(outputs, callResp.UnspentSiafundOutputs...)
+	}
+
+	data, err := interfaceToJSON(sumClaims(outputs))
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//computeBalanceDelta compares the current unspent outputs to the known outputs. Output IDs commit
+//to the output's value and address so a known output can only change if it was reorged into a
+//different block, or for siafunds, when its claim has grown. The deltas are the value of the new
+//outputs minus the value of the spent outputs
+func computeBalanceDelta(known, current KnownOutputs) (resp balanceDelta) {
+	siacoinDelta, siafundDelta := new(big.Int), new(big.Int)
+	knownSiacoins := make(map[string]apitypes.SiacoinOutput)
+	knownSiafunds := make(map[string]apitypes.SiafundOutput)
+
+	resp.NewSiacoinOutputs = []apitypes.SiacoinOutput{}
+	resp.ChangedSiacoinOutputs = []apitypes.SiacoinOutput{}
+	resp.SpentSiacoinOutputs = []string{}
+	resp.NewSiafundOutputs = []apitypes.SiafundOutput{}
+	resp.ChangedSiafundOutputs = []apitypes.SiafundOutput{}
+	resp.SpentSiafundOutputs = []string{}
+
+	for _, output := range known.SiacoinOutputs {
+		knownSiacoins[output.OutputID] = output
+	}
+
+	for _, output := range known.SiafundOutputs {
+		knownSiafunds[output.OutputID] = output
+	}
+
+	for _, output := range current.SiacoinOutputs {
+		prev, exists := knownSiacoins[output.OutputID]
+
+		if !exists {
+			resp.NewSiacoinOutputs = append(resp.NewSiacoinOutputs, output)
+			siacoinDelta.Add(siacoinDelta, output.Value.Big())
+		} else if prev.BlockHeight != output.BlockHeight || prev.MaturityHeight != output.MaturityHeight {
+			resp.ChangedSiacoinOutputs = append(resp.ChangedSiacoinOutputs, output)
+		}
+
+		delete(knownSiacoins, output.OutputID)
+	}
+
+	for _, output := range current.SiafundOutputs {
+		prev, exists := knownSiafunds[output.OutputID]
+
+		if !exists {
+			resp.NewSiafundOutputs = append(resp.NewSiafundOutputs, output)
+			siafundDelta.Add(siafundDelta, output.Value.Big())
+		} else if prev.BlockHeight != output.BlockHeight || !prev.SiacoinClaim.Equals(output.SiacoinClaim) {
+			resp.ChangedSiafundOutputs = append(resp.ChangedSiafundOutputs, output)
+		}
+
+		delete(knownSiafunds, output.OutputID)
+	}
+
+	// any known output that is no longer unspent has been spent, iterate the known slices to keep
+	// the order stable
+	for _, output := range known.SiacoinOutputs {
+		if _, exists := knownSiacoins[output.OutputID]; !exists {
+			continue
+		}
+
+		resp.SpentSiacoinOutputs = append(resp.SpentSiacoinOutputs, output.OutputID)
+		siacoinDelta.Sub(siacoinDelta, output.Value.Big())
+		delete(knownSiacoins, output.OutputID)
+	}
+
+	for _, output := range known.SiafundOutputs {
+		if _, exists := knownSiafunds[output.OutputID]; !exists {
+			continue
+		}
+
+		resp.SpentSiafundOutputs = append(resp.SpentSiafundOutputs, output.OutputID)
+		siafundDelta.Sub(siafundDelta, output.Value.Big())
+		delete(knownSiafunds, output.OutputID)
+	}
+
+	resp.SiacoinDelta = siacoinDelta.String()
+	resp.SiafundDelta = siafundDelta.String()
+
+	return
+}
+
+//GetBalanceDelta returns the outputs that are new, spent or changed since the known outputs were
+//scanned. The API has no way to request only the changes, so the unspent outputs are still
+//fetched, but the page only needs to apply the difference instead of replacing and re-summing
+//every output on each refresh
+func GetBalanceDelta(known KnownOutputs, addresses []string, currency string, callback js.Value) {
+	var current KnownOutputs
+
+	ctx := context.Background()
+	responses, err := findBalances(ctx, currency, 1, addresses)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	for _, callResp := range responses {
+		current.SiacoinOutputs = append(current.SiacoinOutputs, callResp.UnspentSiacoinOutputs...)
+		current.SiafundOutputs = append(current.SiafundOutputs, callResp.UnspentSiafundOutputs...)
+	}
+
+	data, err := interfaceToJSON(computeBalanceDelta(known, current))
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//effectiveBalance sums the siacoin outputs that can be spent right now under the policy. Each
+//excluded output is listed with the first reason it failed: "pending" if it is already being spent,
+//"immature" or "timelocked" if consensus does not allow it to be spent yet, "unconfirmed" if it
+//has fewer than the minimum confirmations, or "dust" if it is not worth the fee to spend
+func effectiveBalance(resp transactionResp, policy SpendPolicy, height uint64) (balance effectiveBalanceResp) {
+	locked := make(map[string]bool)
+
+	balance.Excluded = []excludedOutput{}
+	balance.Exclusions = make(map[string]exclusionTotal)
+
+	for _, id := range resp.SpentSiacoinOutputs {
+		locked[id] = true
+	}
+
+	for _, id := range policy.Pending {
+		locked[id] = true
+	}
+
+	exclude := func(output apitypes.SiacoinOutput, reason string) {
+		total := balance.Exclusions[reason]
+		total.Outputs++
+		total.Value = total.Value.Add(output.Value)
+
+		balance.Exclusions[reason] = total
+		balance.Excluded = append(balance.Excluded, excludedOutput{
+			OutputID: output.OutputID,
+			Value:    output.Value,
+			Reason:   reason,
+		})
+	}
+
+	for _, output := range resp.ImmatureSiacoinOutputs {
+		if locked[output.OutputID] {
+			exclude(output, "pending")
+			continue
+		}
+
+		exclude(output, "immature")
+	}
+
+	dust := dustThreshold(policy.FeePerByte)
+
+	for _, output := range resp.UnspentSiacoinOutputs {
+		switch {
+		case locked[output.OutputID]:
+			exclude(output, "pending")
+		case output.MaturityHeight > height:
+			exclude(output, "immature")
+		case policy.Timelocks[output.UnlockHash] > height:
+			exclude(output, "timelocked")
+		case outputConfirmations(height, output.BlockHeight) < policy.MinConfirmations:
+			exclude(output, "unconfirmed")
+		case !policy.FeePerByte.IsZero() && output.Value.Cmp(dust) <= 0:
+			exclude(output, "dust")
+		default:
+			balance.Spendable = balance.Spendable.Add(output.Value)
+			balance.SpendableOutputs++
+		}
+	}
+
+	return
+}
+
+//GetEffectiveBalance returns the siacoins the addresses can spend right now under the policy, and
+//why each of the other unspent outputs was excluded
+func GetEffectiveBalance(addresses []string, currency string, policy SpendPolicy, callback js.Value) {
+	ctx := context.Background()
+	resp, err := loadTransactions(ctx, addresses, currency)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	// the same cached height loadTransactions used
+	height, err := currentHeight(ctx, currency)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(effectiveBalance(resp, policy, height))
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
@@ -0,0 +1,183 @@
+package modules
+
+import (
+	"syscall/js"
+	"testing"
+
+	apitypes "github.com/siacentral/apisdkgo/types"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+func TestSumClaims(t *testing.T) {
+	// outputs created at different claim starts have different claims per siafund
+	outputs := []apitypes.SiafundOutput{
+		{OutputID: "sf1", Value: siatypes.NewCurrency64(100), SiacoinClaim: siatypes.SiacoinPrecision.Mul64(30)},
+		{OutputID: "sf2", Value: siatypes.NewCurrency64(100), SiacoinClaim: siatypes.SiacoinPrecision.Mul64(5)},
+		{OutputID: "sf3", Value: siatypes.NewCurrency64(50), SiacoinClaim: siatypes.ZeroCurrency},
+	}
+
+	resp := sumClaims(outputs)
+
+	if !resp.Total.Equals(siatypes.SiacoinPrecision.Mul64(35)) {
+		t.Fatalf("expected 35 SC, got %s", resp.Total.HumanString())
+	} else if !resp.Siafunds.Equals64(250) {
+		t.Fatalf("expected 250 SF, got %s", resp.Siafunds)
+	} else if len(resp.Outputs) != 3 || resp.Outputs[1].OutputID != "sf2" || !resp.Outputs[1].Claim.Equals(siatypes.SiacoinPrecision.Mul64(5)) {
+		t.Fatalf("unexpected outputs %v", resp.Outputs)
+	}
+
+	if resp := sumClaims(nil); resp.Outputs == nil || !resp.Total.IsZero() {
+		t.Fatal("expected an empty claim")
+	}
+}
+
+func TestComputeBalanceDelta(t *testing.T) {
+	known := KnownOutputs{
+		SiacoinOutputs: []apitypes.SiacoinOutput{
+			{OutputID: "sc1", Value: siatypes.NewCurrency64(10), BlockHeight: 100},
+			{OutputID: "sc2", Value: siatypes.NewCurrency64(20), BlockHeight: 101},
+			{OutputID: "sc3", Value: siatypes.NewCurrency64(30), BlockHeight: 102},
+		},
+		SiafundOutputs: []apitypes.SiafundOutput{
+			{OutputID: "sf1", Value: siatypes.NewCurrency64(5), SiacoinClaim: siatypes.NewCurrency64(1)},
+			{OutputID: "sf2", Value: siatypes.NewCurrency64(7)},
+		},
+	}
+
+	current := KnownOutputs{
+		SiacoinOutputs: []apitypes.SiacoinOutput{
+			{OutputID: "sc1", Value: siatypes.NewCurrency64(10), BlockHeight: 100},
+			// reorged into a later block
+			{OutputID: "sc3", Value: siatypes.NewCurrency64(30), BlockHeight: 105},
+			{OutputID: "sc4", Value: siatypes.NewCurrency64(5), BlockHeight: 106},
+		},
+		SiafundOutputs: []apitypes.SiafundOutput{
+			{OutputID: "sf1", Value: siatypes.NewCurrency64(5), SiacoinClaim: siatypes.NewCurrency64(3)},
+		},
+	}
+
+	resp := computeBalanceDelta(known, current)
+
+	if len(resp.NewSiacoinOutputs) != 1 || resp.NewSiacoinOutputs[0].OutputID != "sc4" {
+		t.Fatalf("expected sc4 to be new, got %v", resp.NewSiacoinOutputs)
+	} else if len(resp.ChangedSiacoinOutputs) != 1 || resp.ChangedSiacoinOutputs[0].OutputID != "sc3" {
+		t.Fatalf("expected sc3 to be changed, got %v", resp.ChangedSiacoinOutputs)
+	} else if len(resp.SpentSiacoinOutputs) != 1 || resp.SpentSiacoinOutputs[0] != "sc2" {
+		t.Fatalf("expected sc2 to be spent, got %v", resp.SpentSiacoinOutputs)
+	} else if resp.SiacoinDelta != "-15" {
+		t.Fatalf("expected siacoin delta -15, got %s", resp.SiacoinDelta)
+	}
+
+	if len(resp.NewSiafundOutputs) != 0 {
+		t.Fatalf("expected no new siafund outputs, got %v", resp.NewSiafundOutputs)
+	} else if len(resp.ChangedSiafundOutputs) != 1 || resp.ChangedSiafundOutputs[0].OutputID != "sf1" {
+		t.Fatalf("expected the grown claim to change sf1, got %v", resp.ChangedSiafundOutputs)
+	} else if len(resp.SpentSiafundOutputs) != 1 || resp.SpentSiafundOutputs[0] != "sf2" {
+		t.Fatalf("expected sf2 to be spent, got %v", resp.SpentSiafundOutputs)
+	} else if resp.SiafundDelta != "-7" {
+		t.Fatalf("expected siafund delta -7, got %s", resp.SiafundDelta)
+	}
+
+	// nothing known is the same as a full scan
+	resp = computeBalanceDelta(KnownOutputs{}, current)
+
+	if len(resp.NewSiacoinOutputs) != 3 || resp.SiacoinDelta != "45" || len(resp.SpentSiafundOutputs) != 0 {
+		t.Fatalf("unexpected delta from empty known outputs %v", resp)
+	}
+}
+
+func TestEffectiveBalance(t *testing.T) {
+	feePerByte := siatypes.SiacoinPrecision.Div64(1e6)
+	dust := dustThreshold(feePerByte)
+	sc := siatypes.SiacoinPrecision
+	resp := transactionResp{
+		UnspentSiacoinOutputs: []apitypes.SiacoinOutput{
+			{OutputID: "spendable", UnlockHash: "addr1", BlockHeight: 90, Value: sc.Mul64(1)},
+			{OutputID: "pool", UnlockHash: "addr1", BlockHeight: 90, Value: sc.Mul64(2)},
+			{OutputID: "broadcast", UnlockHash: "addr1", BlockHeight: 90, Value: sc.Mul64(4)},
+			{OutputID: "timelocked", UnlockHash: "locked", BlockHeight: 90, Value: sc.Mul64(8)},
+			{OutputID: "recent", UnlockHash: "addr1", BlockHeight: 99, Value: sc.Mul64(16)},
+			{OutputID: "unconfirmed", UnlockHash: "addr1", Value: sc.Mul64(32)},
+			{OutputID: "dust", UnlockHash: "addr1", BlockHeight: 90, Value: dust},
+		},
+		ImmatureSiacoinOutputs: []apitypes.SiacoinOutput{
+			{OutputID: "immature", UnlockHash: "addr1", BlockHeight: 90, MaturityHeight: 234, Value: sc.Mul64(64)},
+		},
+		SpentSiacoinOutputs: []string{"pool"},
+	}
+
+	balance := effectiveBalance(resp, SpendPolicy{
+		MinConfirmations: 3,
+		FeePerByte:       feePerByte,
+		Pending:          []string{"broadcast"},
+		Timelocks:        map[string]uint64{"locked": 150},
+	}, 100)
+
+	if !balance.Spendable.Equals(sc) || balance.SpendableOutputs != 1 {
+		t.Fatalf("expected only the 1 SC output to be spendable, got %s in %d outputs", balance.Spendable, balance.SpendableOutputs)
+	}
+
+	reasons := map[string]string{
+		"pool":        "pending",
+		"broadcast":   "pending",
+		"timelocked":  "timelocked",
+		"recent":      "unconfirmed",
+		"unconfirmed": "unconfirmed",
+		"dust":        "dust",
+		"immature":    "immature",
+	}
+
+	if len(balance.Excluded) != len(reasons) {
+		t.Fatalf("expected %d excluded outputs, got %d", len(reasons), len(balance.Excluded))
+	}
+
+	for _, excluded := range balance.Excluded {
+		if reasons[excluded.OutputID] != excluded.Reason {
+			t.Errorf("%s: expected reason %q, got %q", excluded.OutputID, reasons[excluded.OutputID], excluded.Reason)
+		}
+	}
+
+	if pending := balance.Exclusions["pending"]; pending.Outputs != 2 || !pending.Value.Equals(sc.Mul64(6)) {
+		t.Fatalf("unexpected pending total %+v", pending)
+	}
+
+	// without a policy only consensus and the transaction pool exclude outputs
+	balance = effectiveBalance(resp, SpendPolicy{}, 100)
+	if expected := sc.Mul64(1 + 4 + 8 + 16 + 32).Add(dust); !balance.Spendable.Equals(expected) {
+		t.Fatalf("expected %s spendable, got %s", expected, balance.Spendable)
+	}
+}
+
+func TestReconcileBalance(t *testing.T) {
+	canned := &cannedTransport{
+		responses: map[string]string{
+			"/v2/wallet/addresses": `{"type":"success","unspent_siacoins":"300","unspent_siafunds":"5","unspent_siacoin_outputs":[{"output_id":"sc1","unlock_hash":"addr","value":"100"},{"output_id":"sc2","unlock_hash":"addr","value":"200"}],"unspent_siafund_outputs":[{"output_id":"sf1","unlock_hash":"addr","value":"5"}]}`,
+		},
+	}
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	errMsg, resp := invokeCallback(t, func(callback js.Value) {
+		ReconcileBalance([]string{"addr"}, "sc", callback)
+	})
+	if errMsg != "" {
+		t.Fatal(errMsg)
+	} else if !resp.Get("match").Bool() || resp.Get("siacoins").Get("difference").String() != "0" {
+		t.Fatal("expected the balance to match the outputs")
+	}
+
+	// the reported balance includes an output the API did not return
+	canned.responses["/v2/wallet/addresses"] = `{"type":"success","unspent_siacoins":"350","unspent_siafunds":"5","unspent_siacoin_outputs":[{"output_id":"sc1","unlock_hash":"addr","value":"100"},{"output_id":"sc2","unlock_hash":"addr","value":"200"}],"unspent_siafund_outputs":[{"output_id":"sf1","unlock_hash":"addr","value":"5"}]}`
+
+	errMsg, resp = invokeCallback(t, func(callback js.Value) {
+		ReconcileBalance([]string{"addr"}, "sc", callback)
+	})
+	if errMsg != "" {
+		t.Fatal(errMsg)
+	} else if resp.Get("match").Bool() || resp.Get("siacoins").Get("match").Bool() || !resp.Get("siafunds").Get("match").Bool() {
+		t.Fatal("expected only the siacoin balance to mismatch")
+	} else if diff := resp.Get("siacoins").Get("difference").String(); diff != "50" {
+		t.Fatalf("expected a difference of 50, got %s", diff)
+	}
+}
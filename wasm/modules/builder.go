@@ -0,0 +1,430 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"syscall/js"
+
+	siacrypto "gitlab.com/NebulousLabs/Sia/crypto"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+const (
+	//maxInputsPerTxn the maximum number of inputs the wallet will add to a single transaction
+	maxInputsPerTxn = 90
+	//estimatedInputSize the estimated size in bytes an input and its signature add to a transaction
+	estimatedInputSize = 313
+)
+
+const (
+	//strategySmallestFirst spends the smallest outputs first. Consolidates dust as the wallet is used
+	//at the cost of more inputs and a higher fee per send
+	strategySmallestFirst selectionStrategy = "smallest-first"
+	//strategyLargestFirst spends the largest outputs first. Uses the fewest inputs for the lowest fee,
+	//but small outputs accumulate until the wallet is defragged
+	strategyLargestFirst selectionStrategy = "largest-first"
+)
+
+type (
+	//selectionStrategy the order outputs are selected in to fund a transaction
+	selectionStrategy string
+
+	//insufficientFundsError returned when the outputs cannot cover the amount and fee
+	insufficientFundsError struct {
+		Shortfall siatypes.Currency
+	}
+
+	//sweepEstimate the transactions needed to send every spendable output to one address. Amount is
+	//the total that arrives after the fees
+	sweepEstimate struct {
+		Transactions int               `json:"transactions"`
+		Inputs       int               `json:"inputs"`
+		Fees         siatypes.Currency `json:"fees"`
+		Amount       siatypes.Currency `json:"amount"`
+		Dust         int               `json:"dust"`
+		Unspendable  int               `json:"unspendable"`
+	}
+
+	defragResp struct {
+		Transactions []UnsignedTransaction `json:"transactions"`
+		Kept         []string              `json:"kept"`
+		Dust         []string              `json:"dust"`
+		Sent         siatypes.Currency     `json:"sent"`
+		Fees         siatypes.Currency     `json:"fees"`
+	}
+)
+
+func (e insufficientFundsError) Error() string {
+	return fmt.Sprintf("not enough funds to create transaction: short %s H", e.Shortfall)
+}
+
+//estimateTransactionFee estimates the miner fee of a transaction with the number of inputs and
+//outputs. Matches calculateFee in the frontend
+func estimateTransactionFee(feePerByte siatypes.Currency, inputs, outputs int) siatypes.Currency {
+	return feePerByte.Mul64(uint64(100 + ((inputs + 1) * estimatedInputSize) + (outputs * 50)))
+}
+
+//dustThreshold returns the fee to add one more input to a transaction. An output worth at most the
+//threshold costs as much or more in fees than its value to spend
+func dustThreshold(feePerByte siatypes.Currency) siatypes.Currency {
+	return feePerByte.Mul64(estimatedInputSize)
+}
+
+//DustThreshold returns the minimum value an output must have to be worth spending at the fee
+func DustThreshold(currency string, feePerByte siatypes.Currency, callback js.Value) {
+	threshold := dustThreshold(feePerByte)
+
+	callback.Invoke(js.Null(), map[string]interface{}{
+		"threshold":    threshold.String(),
+		"display":      siacoinString(threshold, currency),
+		"input_size":   estimatedInputSize,
+		"fee_per_byte": feePerByte.String(),
+	})
+}
+
+//sumOutputs returns the total value of the outputs
+func sumOutputs(outputs []SpendableOutput) (sum siatypes.Currency) {
+	for _, output := range outputs {
+		sum = sum.Add(output.Value)
+	}
+
+	return
+}
+
+//sortOutputsAsc sorts the outputs from smallest to largest value
+func sortOutputsAsc(outputs []SpendableOutput) {
+	sort.SliceStable(outputs, func(i, j int) bool {
+		return outputs[i].Value.Cmp(outputs[j].Value) == -1
+	})
+}
+
+//sortOutputsDesc sorts the outputs from largest to smallest value
+func sortOutputsDesc(outputs []SpendableOutput) {
+	sort.SliceStable(outputs, func(i, j int) bool {
+		return outputs[i].Value.Cmp(outputs[j].Value) == 1
+	})
+}
+
+//parseAddress parses and validates the checksum of an address string
+func parseAddress(address string) (uh siatypes.UnlockHash, err error) {
+	if err = uh.LoadString(address); err != nil {
+		err = fmt.Errorf("invalid address %q: %w", address, err)
+	}
+
+	return
+}
+
+//buildTransaction creates an unsigned transaction spending the inputs to the outputs with the
+//miner fee. A whole transaction signature is added for each input and the required signature
+//indices are set from the inputs
+func buildTransaction(inputs []SpendableOutput, outputs []siatypes.SiacoinOutput, minerFee siatypes.Currency) (unsigned UnsignedTransaction, err error) {
+	if len(inputs) == 0 {
+		err = errors.New("transaction has no inputs")
+		return
+	}
+
+	txn := siatypes.Transaction{
+		SiacoinOutputs: outputs,
+	}
+
+	if !minerFee.IsZero() {
+		txn.MinerFees = []siatypes.Currency{minerFee}
+	}
+
+	for _, input := range inputs {
+		var parentID siacrypto.Hash
+
+		if err = parentID.LoadString(input.OutputID); err != nil {
+			err = fmt.Errorf("unable to parse output id %q: %w", input.OutputID, err)
+			return
+		}
+
+		unlockConds, err := unmapUnlockConditions(input.UnlockConditions)
+
+		if err != nil {
+			return unsigned, err
+		}
+
+		if unlockConds.UnlockHash().String() != input.UnlockHash {
+			return unsigned, fmt.Errorf("unlock conditions do not match output %s", input.OutputID)
+		}
+
+		txn.SiacoinInputs = append(txn.SiacoinInputs, siatypes.SiacoinInput{
+			ParentID:         siatypes.SiacoinOutputID(parentID),
+			UnlockConditions: unlockConds,
+		})
+		txn.TransactionSignatures = append(txn.TransactionSignatures, siatypes.TransactionSignature{
+			ParentID:       parentID,
+			PublicKeyIndex: 0,
+			CoveredFields:  siatypes.CoveredFields{WholeTransaction: true},
+		})
+		unsigned.RequiredSigs = append(unsigned.RequiredSigs, input.Index)
+	}
+
+	unsigned.Transaction = txn
+
+	return
+}
+
+//spendableOutputs returns the outputs that can be spent in a block at height. Outputs with a
+//timelock in their unlock conditions cannot be spent until the height reaches the timelock and
+//block rewards and contract payouts cannot be spent until they mature
+func spendableOutputs(outputs []SpendableOutput, height uint64) (spendable []SpendableOutput) {
+	for _, output := range outputs {
+		if output.UnlockConditions.Timelock > height || output.MaturityHeight > height {
+			continue
+		}
+
+		spendable = append(spendable, output)
+	}
+
+	return
+}
+
+//parseSelectionStrategy returns the coin selection strategy named by str. An empty string selects
+//the default strategy
+func parseSelectionStrategy(str string) (selectionStrategy, error) {
+	switch strategy := selectionStrategy(str); strategy {
+	case "":
+		return strategySmallestFirst, nil
+	case strategySmallestFirst, strategyLargestFirst:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unsupported coin selection strategy %q", str)
+	}
+}
+
+//selectUTXOs selects inputs from the outputs spendable at height in the order of the strategy until
+//they cover the amount and the fee of a transaction with the selected inputs and outputCount
+//outputs. The default, smallest first, matches the input selection of the frontend
+func selectUTXOs(outputs []SpendableOutput, height uint64, amount, feePerByte siatypes.Currency, outputCount int, strategy selectionStrategy) (inputs []SpendableOutput, fee siatypes.Currency, err error) {
+	inputs, fee, err = fundUTXOs(outputs, height, amount, feePerByte, outputCount, strategy)
+
+	if err != nil {
+		return
+	}
+
+	if len(inputs) > maxInputsPerTxn {
+		return nil, fee, fmt.Errorf("transaction requires %d inputs, defrag the wallet first", len(inputs))
+	}
+
+	return
+}
+
+//fundUTXOs selects inputs the same as selectUTXOs without limiting the number of inputs
+func fundUTXOs(outputs []SpendableOutput, height uint64, amount, feePerByte siatypes.Currency, outputCount int, strategy selectionStrategy) (inputs []SpendableOutput, fee siatypes.Currency, err error) {
+	var added siatypes.Currency
+
+	sorted := spendableOutputs(outputs, height)
+
+	switch strategy {
+	case strategySmallestFirst:
+		sortOutputsAsc(sorted)
+	case strategyLargestFirst:
+		sortOutputsDesc(sorted)
+	default:
+		return nil, fee, fmt.Errorf("unsupported coin selection strategy %q", strategy)
+	}
+
+	for _, output := range sorted {
+		inputs = append(inputs, output)
+		added = added.Add(output.Value)
+		fee = estimateTransactionFee(feePerByte, len(inputs), outputCount)
+
+		if added.Cmp(amount.Add(fee)) >= 0 {
+			break
+		}
+	}
+
+	if added.Cmp(amount.Add(fee)) < 0 {
+		return nil, fee, insufficientFundsError{
+			Shortfall: amount.Add(fee).Sub(added),
+		}
+	}
+
+	return
+}
+
+//selectDefragOutputs splits the outputs into the outputs to consolidate, the keep largest outputs
+//to leave untouched, and the dust outputs that are not worth the fee to spend
+func selectDefragOutputs(outputs []SpendableOutput, keep int, dustThreshold siatypes.Currency) (spend, kept, dust []SpendableOutput) {
+	sorted := append([]SpendableOutput(nil), outputs...)
+
+	sortOutputsDesc(sorted)
+
+	for i, output := range sorted {
+		switch {
+		case i < keep:
+			kept = append(kept, output)
+		case output.Value.Cmp(dustThreshold) <= 0 && !dustThreshold.IsZero():
+			dust = append(dust, output)
+		default:
+			spend = append(spend, output)
+		}
+	}
+
+	return
+}
+
+//defragTransactions consolidates the outputs into as few outputs as possible sent to the
+//recipient. The outputs must be sorted largest to smallest. Each transaction gets one of the
+//largest outputs, then is filled from the smallest outputs so every transaction can cover its fee
+func defragTransactions(outputs []SpendableOutput, recipient siatypes.UnlockHash, feePerByte siatypes.Currency) (txns []UnsignedTransaction, sent, fees siatypes.Currency, err error) {
+	txnCount := (len(outputs) + maxInputsPerTxn - 1) / maxInputsPerTxn
+	groups := make([][]SpendableOutput, txnCount)
+
+	for i := 0; i < txnCount; i++ {
+		groups[i] = append(groups[i], outputs[i])
+	}
+
+	for i, j := len(outputs)-1, 0; i >= txnCount; i-- {
+		groups[j] = append(groups[j], outputs[i])
+
+		if len(groups[j]) >= maxInputsPerTxn {
+			j++
+		}
+	}
+
+	for _, inputs := range groups {
+		value := sumOutputs(inputs)
+		fee := estimateTransactionFee(feePerByte, len(inputs), 1)
+
+		if value.Cmp(fee) <= 0 {
+			err = errors.New("not enough siacoins to defrag")
+			return
+		}
+
+		txn, err := buildTransaction(inputs, []siatypes.SiacoinOutput{
+			{Value: value.Sub(fee), UnlockHash: recipient},
+		}, fee)
+
+		if err != nil {
+			return nil, sent, fees, err
+		}
+
+		txns = append(txns, txn)
+		sent = sent.Add(value.Sub(fee))
+		fees = fees.Add(fee)
+	}
+
+	return
+}
+
+//BuildDefragTransactions builds unsigned transactions consolidating the outputs into the
+//recipient address. The keep largest outputs are left unspent so the wallet retains some
+//spending flexibility, a keep of 0 consolidates every output. Outputs with a value at or below the
+//dust threshold are not spent. Timelocked and immature outputs are not spent until they are
+//spendable
+func BuildDefragTransactions(outputs []SpendableOutput, currency, recipient string, feePerByte siatypes.Currency, keep int, dustThreshold siatypes.Currency, callback js.Value) {
+	var resp defragResp
+
+	uh, err := parseAddress(recipient)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	height, err := currentHeight(context.Background(), currency)
+
+	if err != nil {
+		callback.Invoke(fmt.Errorf("unable to get block height: %w", err).Error(), js.Null())
+		return
+	}
+
+	spend, kept, dust := selectDefragOutputs(spendableOutputs(outputs, height), keep, dustThreshold)
+
+	if len(spend) < 2 {
+		callback.Invoke("not enough outputs to defrag", js.Null())
+		return
+	}
+
+	resp.Transactions, resp.Sent, resp.Fees, err = defragTransactions(spend, uh, feePerByte)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	for _, output := range kept {
+		resp.Kept = append(resp.Kept, output.OutputID)
+	}
+
+	for _, output := range dust {
+		resp.Dust = append(resp.Dust, output.OutputID)
+	}
+
+	data, err := interfaceToJSON(resp)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//estimateSweep groups the outputs spendable at height into transactions sending everything to the
+//recipient, the same as a defrag that keeps no outputs. Outputs at or below the dust threshold
+//cost more to spend than they are worth and are left out
+func estimateSweep(outputs []SpendableOutput, height uint64, recipient siatypes.UnlockHash, feePerByte siatypes.Currency) (estimate sweepEstimate, err error) {
+	spendable := spendableOutputs(outputs, height)
+	spend, _, dust := selectDefragOutputs(spendable, 0, dustThreshold(feePerByte))
+
+	estimate.Dust = len(dust)
+	estimate.Unspendable = len(outputs) - len(spendable)
+
+	if len(spend) == 0 {
+		return estimate, errors.New("no spendable outputs to sweep")
+	}
+
+	txns, sent, fees, err := defragTransactions(spend, recipient, feePerByte)
+
+	if err != nil {
+		return
+	}
+
+	estimate.Transactions = len(txns)
+	estimate.Inputs = len(spend)
+	estimate.Fees = fees
+	estimate.Amount = sent
+
+	return
+}
+
+//EstimateSweep returns how many transactions a sweep of the outputs to the destination needs
+//because of the input limit, their total fee, and the amount that will arrive. Lets the UI warn
+//before a fragmented wallet is swept
+func EstimateSweep(outputs []SpendableOutput, currency, destination string, feePerByte siatypes.Currency, callback js.Value) {
+	uh, err := parseAddress(destination)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	height, err := currentHeight(context.Background(), currency)
+
+	if err != nil {
+		callback.Invoke(fmt.Errorf("unable to get block height: %w", err).Error(), js.Null())
+		return
+	}
+
+	estimate, err := estimateSweep(outputs, height, uh, feePerByte)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(estimate)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
@@ -0,0 +1,640 @@
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	apitypes "github.com/siacentral/apisdkgo/types"
+	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+const testPhrase = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+//testOutputs creates a spendable output for each value owned by consecutive addresses of the test
+//wallet
+func testOutputs(t testing.TB, values ...uint64) []SpendableOutput {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := make([]SpendableOutput, len(values))
+
+	for i, v := range values {
+		addr := generateAddress(w, uint64(i))
+		outputs[i] = SpendableOutput{
+			SiacoinOutput: apitypes.SiacoinOutput{
+				OutputID:   fmt.Sprintf("%064x", i+1),
+				UnlockHash: addr.Address,
+				Value:      siatypes.SiacoinPrecision.Mul64(v),
+			},
+			Index:            addr.Index,
+			UnlockConditions: addr.UnlockConditions,
+		}
+	}
+
+	return outputs
+}
+
+func TestSelectDefragOutputs(t *testing.T) {
+	outputs := testOutputs(t, 5, 100, 1, 50, 2, 75, 3)
+	dust := siatypes.SiacoinPrecision.Mul64(2)
+
+	spend, kept, dusty := selectDefragOutputs(outputs, 2, dust)
+
+	if len(kept) != 2 || !kept[0].Value.Equals(siatypes.SiacoinPrecision.Mul64(100)) || !kept[1].Value.Equals(siatypes.SiacoinPrecision.Mul64(75)) {
+		t.Fatalf("expected the two largest outputs to be kept, got %v", kept)
+	}
+
+	if len(dusty) != 2 {
+		t.Fatalf("expected 2 dust outputs, got %d", len(dusty))
+	}
+
+	if len(spend) != 3 {
+		t.Fatalf("expected 3 outputs to spend, got %d", len(spend))
+	}
+
+	spend, kept, dusty = selectDefragOutputs(outputs, 0, siatypes.ZeroCurrency)
+
+	if len(spend) != len(outputs) || len(kept) != 0 || len(dusty) != 0 {
+		t.Fatalf("expected full consolidation to spend every output")
+	}
+}
+
+func TestEstimateSweep(t *testing.T) {
+	values := make([]uint64, 200)
+
+	for i := range values {
+		values[i] = uint64(i + 1)
+	}
+
+	outputs := testOutputs(t, values...)
+	// a timelocked output cannot be swept yet
+	outputs[10].UnlockConditions.Timelock = 1000
+
+	feePerByte := siatypes.SiacoinPrecision.Div64(1e6)
+	dust := testOutputs(t, 0)[0]
+	dust.OutputID = fmt.Sprintf("%064x", 1000)
+	dust.Value = dustThreshold(feePerByte)
+	outputs = append(outputs, dust)
+
+	recipient, err := parseAddress(outputs[0].UnlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	estimate, err := estimateSweep(outputs, 100, recipient, feePerByte)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if estimate.Transactions != 3 || estimate.Inputs != 199 {
+		t.Fatalf("expected 199 inputs in 3 transactions, got %d in %d", estimate.Inputs, estimate.Transactions)
+	} else if estimate.Dust != 1 || estimate.Unspendable != 1 {
+		t.Fatalf("expected 1 dust and 1 unspendable output, got %d and %d", estimate.Dust, estimate.Unspendable)
+	}
+
+	total := sumOutputs(outputs).Sub(outputs[10].Value).Sub(dust.Value)
+	if !estimate.Amount.Add(estimate.Fees).Equals(total) {
+		t.Fatalf("expected amount and fees to add up to %s, got %s + %s", total, estimate.Amount, estimate.Fees)
+	}
+
+	if _, err := estimateSweep([]SpendableOutput{dust}, 100, recipient, feePerByte); err == nil {
+		t.Fatal("expected sweeping only dust to fail")
+	}
+}
+
+func TestDefragTransactions(t *testing.T) {
+	values := make([]uint64, 200)
+
+	for i := range values {
+		values[i] = uint64(i + 1)
+	}
+
+	outputs := testOutputs(t, values...)
+	sortOutputsDesc(outputs)
+
+	recipient, err := parseAddress(outputs[0].UnlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feePerByte := siatypes.SiacoinPrecision.Div64(1e6)
+	txns, sent, fees, err := defragTransactions(outputs, recipient, feePerByte)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(txns) != 3 {
+		t.Fatalf("expected 3 transactions, got %d", len(txns))
+	}
+
+	var inputs int
+	for _, unsigned := range txns {
+		txn := unsigned.Transaction
+		inputs += len(txn.SiacoinInputs)
+
+		if len(txn.SiacoinInputs) > maxInputsPerTxn {
+			t.Fatalf("transaction has %d inputs", len(txn.SiacoinInputs))
+		}
+
+		if len(unsigned.RequiredSigs) != len(txn.SiacoinInputs) || len(txn.TransactionSignatures) != len(txn.SiacoinInputs) {
+			t.Fatal("expected one signature per input")
+		}
+	}
+
+	if inputs != len(outputs) {
+		t.Fatalf("expected %d inputs, got %d", len(outputs), inputs)
+	}
+
+	if !sent.Add(fees).Equals(sumOutputs(outputs)) {
+		t.Fatalf("sent plus fees %v does not equal inputs %v", sent.Add(fees), sumOutputs(outputs))
+	}
+}
+
+func TestSelectUTXOs(t *testing.T) {
+	outputs := testOutputs(t, 10, 1, 5, 2)
+	feePerByte := siatypes.SiacoinPrecision.Div64(1e6)
+
+	inputs, fee, err := selectUTXOs(outputs, 0, siatypes.SiacoinPrecision.Mul64(6), feePerByte, 2, strategySmallestFirst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// smallest first: 1 + 2 + 5 covers 6 SC plus the fee
+	if len(inputs) != 3 {
+		t.Fatalf("expected 3 inputs, got %d", len(inputs))
+	} else if !fee.Equals(estimateTransactionFee(feePerByte, 3, 2)) {
+		t.Fatalf("unexpected fee %v", fee)
+	}
+
+	if _, _, err := selectUTXOs(outputs, 0, siatypes.SiacoinPrecision.Mul64(18), feePerByte, 2, strategySmallestFirst); err == nil {
+		t.Fatal("expected error when outputs do not cover the fee")
+	}
+}
+
+func TestBuildTransactionSigns(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := testOutputs(t, 10, 20)
+	recipient, err := parseAddress(outputs[0].UnlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fee := siatypes.SiacoinPrecision
+	unsigned, err := buildTransaction(outputs, []siatypes.SiacoinOutput{
+		{Value: sumOutputs(outputs).Sub(fee), UnlockHash: recipient},
+	}, fee)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txn := unsigned.Transaction
+	if err := w.SignTransaction(&txn, unsigned.RequiredSigs); err != nil {
+		t.Fatal(err)
+	} else if err := txn.StandaloneValid(200000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildSendRecipients(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := testOutputs(t, 10, 20, 30)
+	feePerByte := siatypes.SiacoinPrecision.Div64(1e6)
+	recipients := []SendRecipient{
+		{Address: outputs[1].UnlockHash, Amount: siatypes.SiacoinPrecision.Mul64(15)},
+		{Address: outputs[2].UnlockHash, Amount: siatypes.SiacoinPrecision.Mul64(5)},
+	}
+
+	preview, err := buildSend(w, 0, recipients, feePerByte, outputs, strategySmallestFirst, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txn := preview.Transaction
+	if len(txn.SiacoinOutputs) != 3 {
+		t.Fatalf("expected 2 recipient outputs and change, got %d outputs", len(txn.SiacoinOutputs))
+	} else if !txn.SiacoinOutputSum().Equals(sumOutputs(preview.Inputs)) {
+		t.Fatal("outputs and fee do not equal inputs")
+	} else if err := txn.StandaloneValid(200000); err != nil {
+		t.Fatal(err)
+	}
+
+	recipients[0].Amount = siatypes.SiacoinPrecision.Mul64(60)
+	_, err = buildSend(w, 0, recipients, feePerByte, outputs, strategySmallestFirst, false)
+
+	shortErr, ok := err.(insufficientFundsError)
+	if !ok {
+		t.Fatalf("expected insufficient funds error, got %v", err)
+	}
+
+	expected := siatypes.SiacoinPrecision.Mul64(65).Add(estimateTransactionFee(feePerByte, 3, 3)).Sub(sumOutputs(outputs))
+	if !shortErr.Shortfall.Equals(expected) {
+		t.Fatalf("expected shortfall %v, got %v", expected, shortErr.Shortfall)
+	}
+
+	recipients[0].Address = "invalid"
+	if _, err := buildSend(w, 0, recipients, feePerByte, outputs, strategySmallestFirst, false); err == nil {
+		t.Fatal("expected error for invalid recipient")
+	}
+}
+
+func TestBuildUnsignedSend(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := testOutputs(t, 10, 20, 30)
+	feePerByte := siatypes.SiacoinPrecision.Div64(1e6)
+	recipients := []SendRecipient{
+		{Address: outputs[1].UnlockHash, Amount: siatypes.SiacoinPrecision.Mul64(25)},
+	}
+
+	unsigned, err := buildUnsignedSend(0, recipients, feePerByte, outputs, strategySmallestFirst, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, sig := range unsigned.Transaction.TransactionSignatures {
+		if len(sig.Signature) != 0 {
+			t.Fatalf("expected signature %d to be empty", i)
+		}
+	}
+
+	if len(unsigned.RequiredSigs) != len(unsigned.Inputs) {
+		t.Fatalf("expected %d required signatures, got %d", len(unsigned.Inputs), len(unsigned.RequiredSigs))
+	}
+
+	// signing separately must produce the same transaction as building and signing in one step
+	if err := w.SignTransaction(&unsigned.Transaction, unsigned.RequiredSigs); err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := buildSend(w, 0, recipients, feePerByte, outputs, strategySmallestFirst, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var unsignedBuf, signedBuf bytes.Buffer
+
+	unsigned.Transaction.MarshalSia(&unsignedBuf)
+	signed.Transaction.MarshalSia(&signedBuf)
+
+	if !bytes.Equal(unsignedBuf.Bytes(), signedBuf.Bytes()) {
+		t.Fatal("expected the same signed transaction")
+	} else if err := unsigned.Transaction.StandaloneValid(200000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSelectUTXOsTimelock(t *testing.T) {
+	outputs := testOutputs(t, 10, 20, 30)
+	outputs[0].UnlockConditions.Timelock = 500
+	outputs[2].MaturityHeight = 200
+	feePerByte := siatypes.SiacoinPrecision.Div64(1e6)
+
+	inputs, _, err := selectUTXOs(outputs, 100, siatypes.SiacoinPrecision.Mul64(15), feePerByte, 2, strategySmallestFirst)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(inputs) != 1 || inputs[0].OutputID != outputs[1].OutputID {
+		t.Fatalf("expected only the unlocked output to be selected, got %v", inputs)
+	}
+
+	if _, _, err := selectUTXOs(outputs, 100, siatypes.SiacoinPrecision.Mul64(25), feePerByte, 2, strategySmallestFirst); err == nil {
+		t.Fatal("expected locked outputs to be excluded before their timelock")
+	}
+
+	inputs, _, err = selectUTXOs(outputs, 500, siatypes.SiacoinPrecision.Mul64(25), feePerByte, 2, strategySmallestFirst)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(inputs) != 2 || inputs[0].OutputID != outputs[0].OutputID {
+		t.Fatalf("expected the timelocked output to be selected once eligible, got %v", inputs)
+	}
+}
+
+func TestCheckFeeHeadroom(t *testing.T) {
+	outputs := testOutputs(t, 10, 20, 30)
+	feePerByte := siatypes.SiacoinPrecision.Div64(1e6)
+	// one recipient and the change output buildSend reserves
+	fee := estimateTransactionFee(feePerByte, 3, 2)
+	max := siatypes.SiacoinPrecision.Mul64(60).Sub(fee)
+
+	if warning := checkFeeHeadroom(outputs, 0, siatypes.SiacoinPrecision.Mul64(50), feePerByte, 2); warning != nil {
+		t.Fatalf("expected no warning when the balance covers the fee, got %v", warning)
+	} else if warning := checkFeeHeadroom(outputs, 0, max, feePerByte, 2); warning != nil {
+		t.Fatalf("expected no warning when sending the maximum, got %v", warning)
+	}
+
+	// sending the entire balance leaves nothing for the fee
+	warning := checkFeeHeadroom(outputs, 0, siatypes.SiacoinPrecision.Mul64(60), feePerByte, 2)
+	if warning == nil {
+		t.Fatal("expected a warning when sending the entire balance")
+	} else if !warning.MaxSendable.Equals(max) {
+		t.Fatalf("expected max sendable %s, got %s", max, warning.MaxSendable)
+	} else if !warning.Fee.Equals(fee) || !warning.Balance.Equals(siatypes.SiacoinPrecision.Mul64(60)) {
+		t.Fatalf("unexpected fee %s or balance %s", warning.Fee, warning.Balance)
+	}
+
+	// the maximum must be sendable
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	preview, err := buildSend(w, 0, []SendRecipient{{Address: outputs[0].UnlockHash, Amount: warning.MaxSendable}}, feePerByte, outputs, strategySmallestFirst, false)
+	if err != nil {
+		t.Fatal(err)
+	} else if !preview.Change.IsZero() {
+		t.Fatalf("expected sending the maximum to leave no change, got %s", preview.Change)
+	}
+
+	// outputs that cannot cover the fee cannot send anything
+	if max, _ := maxSendable(testOutputs(t, 0), 0, siatypes.SiacoinPrecision, 1); !max.IsZero() {
+		t.Fatalf("expected nothing to be sendable, got %s", max)
+	}
+}
+
+func TestSelectUTXOsStrategies(t *testing.T) {
+	outputs := testOutputs(t, 1, 2, 3, 50, 100)
+	feePerByte := siatypes.SiacoinPrecision.Div64(1e6)
+	amount := siatypes.SiacoinPrecision.Mul64(40)
+
+	tests := []struct {
+		strategy selectionStrategy
+		values   []uint64
+	}{
+		{strategySmallestFirst, []uint64{1, 2, 3, 50}},
+		{strategyLargestFirst, []uint64{100}},
+	}
+
+	for _, tt := range tests {
+		inputs, fee, err := selectUTXOs(outputs, 0, amount, feePerByte, 2, tt.strategy)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.strategy, err)
+		} else if len(inputs) != len(tt.values) {
+			t.Fatalf("%s: expected %d inputs, got %d", tt.strategy, len(tt.values), len(inputs))
+		}
+
+		for i, v := range tt.values {
+			if !inputs[i].Value.Equals(siatypes.SiacoinPrecision.Mul64(v)) {
+				t.Fatalf("%s: expected input %d to be %d SC, got %s", tt.strategy, i, v, inputs[i].Value.HumanString())
+			}
+		}
+
+		if !fee.Equals(estimateTransactionFee(feePerByte, len(tt.values), 2)) {
+			t.Fatalf("%s: unexpected fee %s", tt.strategy, fee)
+		}
+	}
+
+	if strategy, err := parseSelectionStrategy(""); err != nil || strategy != strategySmallestFirst {
+		t.Fatalf("expected the default strategy to be smallest first, got %q %v", strategy, err)
+	} else if _, err := parseSelectionStrategy("random"); err == nil {
+		t.Fatal("expected an unknown strategy to be rejected")
+	}
+}
+
+func TestCountSendInputs(t *testing.T) {
+	feePerByte := siatypes.SiacoinPrecision.Div64(1e6)
+
+	resp, err := countSendInputs(testOutputs(t, 10, 1, 5, 2), 0, siatypes.SiacoinPrecision.Mul64(6), feePerByte, 1)
+	if err != nil {
+		t.Fatal(err)
+	} else if resp.Inputs != 3 || resp.RequiresDefrag {
+		t.Fatalf("expected 3 inputs without a defrag, got %d", resp.Inputs)
+	} else if !resp.Fee.Equals(estimateTransactionFee(feePerByte, 3, 2)) {
+		t.Fatalf("unexpected fee %v", resp.Fee)
+	}
+
+	// more small outputs than fit in one transaction are counted instead of failing
+	values := make([]uint64, maxInputsPerTxn+10)
+	for i := range values {
+		values[i] = 1
+	}
+
+	resp, err = countSendInputs(testOutputs(t, values...), 0, siatypes.SiacoinPrecision.Mul64(maxInputsPerTxn), siatypes.ZeroCurrency, 1)
+	if err != nil {
+		t.Fatal(err)
+	} else if resp.Inputs != maxInputsPerTxn || resp.RequiresDefrag {
+		t.Fatalf("expected %d inputs without a defrag, got %d", maxInputsPerTxn, resp.Inputs)
+	}
+
+	resp, err = countSendInputs(testOutputs(t, values...), 0, siatypes.SiacoinPrecision.Mul64(maxInputsPerTxn+5), siatypes.ZeroCurrency, 1)
+	if err != nil {
+		t.Fatal(err)
+	} else if resp.Inputs != maxInputsPerTxn+5 || !resp.RequiresDefrag {
+		t.Fatalf("expected %d inputs requiring a defrag, got %d", maxInputsPerTxn+5, resp.Inputs)
+	}
+
+	if _, err := countSendInputs(testOutputs(t, 1), 0, siatypes.SiacoinPrecision.Mul64(2), feePerByte, 1); err == nil {
+		t.Fatal("expected insufficient funds error")
+	}
+}
+
+func TestBuildSendFeeInclusive(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := testOutputs(t, 10, 20, 30)
+	feePerByte := siatypes.SiacoinPrecision.Div64(1e6)
+	amount := siatypes.SiacoinPrecision.Mul64(30)
+	recipients := []SendRecipient{
+		{Address: outputs[2].UnlockHash, Amount: amount},
+	}
+
+	// fee exclusive: the recipient receives exactly the amount and the fee needs a third input
+	exclusive, err := buildSend(w, 0, recipients, feePerByte, outputs, strategySmallestFirst, false)
+	if err != nil {
+		t.Fatal(err)
+	} else if !exclusive.Transaction.SiacoinOutputs[0].Value.Equals(amount) {
+		t.Fatalf("expected the recipient to receive %v, got %v", amount, exclusive.Transaction.SiacoinOutputs[0].Value)
+	} else if len(exclusive.Inputs) != 3 || !exclusive.Fee.Equals(estimateTransactionFee(feePerByte, 3, 2)) {
+		t.Fatalf("expected 3 inputs, got %d", len(exclusive.Inputs))
+	}
+
+	// fee inclusive: 10 + 20 covers the amount exactly, the fee is taken out of it with no change
+	inclusive, err := buildSend(w, 0, recipients, feePerByte, outputs, strategySmallestFirst, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txn := inclusive.Transaction
+	fee := estimateTransactionFee(feePerByte, 2, 2)
+	if len(inclusive.Inputs) != 2 || !inclusive.Fee.Equals(fee) {
+		t.Fatalf("expected 2 inputs and fee %v, got %d inputs and fee %v", fee, len(inclusive.Inputs), inclusive.Fee)
+	} else if !txn.SiacoinOutputs[0].Value.Equals(amount.Sub(fee)) || !inclusive.Amount.Equals(amount.Sub(fee)) {
+		t.Fatalf("expected the recipient to receive %v, got %v", amount.Sub(fee), txn.SiacoinOutputs[0].Value)
+	} else if len(txn.SiacoinOutputs) != 1 || !inclusive.Change.IsZero() {
+		t.Fatalf("expected no change, got %v", inclusive.Change)
+	} else if !txn.SiacoinOutputSum().Equals(amount) {
+		t.Fatal("expected the transaction to spend exactly the amount")
+	} else if err := txn.StandaloneValid(200000); err != nil {
+		t.Fatal(err)
+	}
+
+	// the whole balance can be sent when the fee is included
+	recipients[0].Amount = sumOutputs(outputs)
+	if _, err := buildSend(w, 0, recipients, feePerByte, outputs, strategySmallestFirst, true); err != nil {
+		t.Fatal(err)
+	} else if _, err := buildSend(w, 0, recipients, feePerByte, outputs, strategySmallestFirst, false); err == nil {
+		t.Fatal("expected the fee on top of the balance to fail")
+	}
+
+	// the first recipient must receive something after the fee
+	recipients[0].Amount = estimateTransactionFee(feePerByte, 1, 2)
+	if _, err := buildSend(w, 0, recipients, feePerByte, outputs, strategySmallestFirst, true); err == nil {
+		t.Fatal("expected an amount not covering the fee to fail")
+	}
+}
+
+func TestSpendOutput(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := testOutputs(t, 10, 20, 30)
+	destination := outputs[0].UnlockHash
+	feePerByte := siatypes.NewCurrency64(10)
+
+	// the whole output is sent less the fee
+	preview, err := spendOutput(w, 0, outputs[1].OutputID, destination, siatypes.ZeroCurrency, feePerByte, outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(preview.Inputs) != 1 || preview.Inputs[0].OutputID != outputs[1].OutputID {
+		t.Fatalf("expected only output %s to be spent, got %v", outputs[1].OutputID, preview.Inputs)
+	} else if !preview.Change.IsZero() || len(preview.Transaction.SiacoinOutputs) != 1 {
+		t.Fatalf("expected no change, got %s", preview.Change)
+	} else if !preview.Amount.Add(preview.Fee).Equals(outputs[1].Value) {
+		t.Fatalf("expected the amount and fee to equal the output, got %s and %s", preview.Amount, preview.Fee)
+	}
+
+	// a partial amount returns the change to the output's address
+	preview, err = spendOutput(w, 0, outputs[1].OutputID, destination, siatypes.SiacoinPrecision.Mul64(5), feePerByte, outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(preview.Inputs) != 1 || preview.Inputs[0].OutputID != outputs[1].OutputID {
+		t.Fatalf("expected only output %s to be spent, got %v", outputs[1].OutputID, preview.Inputs)
+	} else if preview.ChangeAddress != outputs[1].UnlockHash {
+		t.Fatalf("expected change to return to %s, got %s", outputs[1].UnlockHash, preview.ChangeAddress)
+	} else if len(preview.Transaction.TransactionSignatures[0].Signature) == 0 {
+		t.Fatal("expected the transaction to be signed")
+	}
+
+	// the other outputs are never added to cover a larger amount
+	if _, err := spendOutput(w, 0, outputs[1].OutputID, destination, siatypes.SiacoinPrecision.Mul64(25), feePerByte, outputs); err == nil {
+		t.Fatal("expected an amount larger than the output to fail")
+	}
+
+	if _, err := spendOutput(w, 0, fmt.Sprintf("%064x", 100), destination, siatypes.ZeroCurrency, feePerByte, outputs); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	outputs[2].MaturityHeight = 100
+	if _, err := spendOutput(w, 0, outputs[2].OutputID, destination, siatypes.ZeroCurrency, feePerByte, outputs); err == nil || !strings.Contains(err.Error(), "not spendable") {
+		t.Fatalf("expected not spendable error, got %v", err)
+	}
+}
+
+func TestVerifyChange(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := testOutputs(t, 10, 20, 30)
+	recipients := []SendRecipient{{Address: outputs[0].UnlockHash, Amount: siatypes.SiacoinPrecision.Mul64(5)}}
+
+	preview, err := buildUnsignedSend(0, recipients, siatypes.NewCurrency64(10), outputs[2:], strategySmallestFirst, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verified, err := verifyChange(w, preview.Transaction, recipients, 2)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(verified) != 1 || !verified[0].Owned || verified[0].Index != 2 {
+		t.Fatalf("expected the change address at index 2, got %+v", verified)
+	}
+
+	// the address is beyond the known range
+	if _, err := verifyChange(w, preview.Transaction, recipients, 1); err == nil || !strings.Contains(err.Error(), "not one of the wallet's addresses") {
+		t.Fatalf("expected an unowned change address error, got %v", err)
+	}
+
+	// change redirected to an address outside the range
+	foreign := generateAddress(w, 10000)
+	external := foreign.Address
+	changed := preview.Transaction
+	changed.SiacoinOutputs = append([]siatypes.SiacoinOutput(nil), preview.Transaction.SiacoinOutputs...)
+	if err := changed.SiacoinOutputs[1].UnlockHash.LoadString(external); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := verifyChange(w, changed, recipients, 2); err == nil || !strings.Contains(err.Error(), "not one of the wallet's addresses") {
+		t.Fatalf("expected change to an address outside the range to fail, got %v", err)
+	}
+
+	// an extra output is change even if the caller does not expect any
+	extra := preview.Transaction
+	extra.SiacoinOutputs = append([]siatypes.SiacoinOutput{preview.Transaction.SiacoinOutputs[0]}, changed.SiacoinOutputs[1])
+	if _, err := verifyChange(w, extra, recipients, 2); err == nil || !strings.Contains(err.Error(), "not one of the wallet's addresses") {
+		t.Fatalf("expected an extra output to a foreign address to fail, got %v", err)
+	}
+
+	// a recipient that is not paid or paid more than its amount
+	overpaid := []SendRecipient{{Address: outputs[0].UnlockHash, Amount: siatypes.SiacoinPrecision.Mul64(4)}}
+	if _, err := verifyChange(w, preview.Transaction, overpaid, 2); err == nil || !strings.Contains(err.Error(), "no output paying recipient 0") {
+		t.Fatalf("expected a missing recipient output error, got %v", err)
+	} else if _, err := verifyChange(w, preview.Transaction, []SendRecipient{{Address: "not an address"}}, 2); err == nil {
+		t.Fatal("expected a malformed recipient address to fail")
+	}
+
+	// a send without change passes
+	exact := preview.Transaction
+	exact.SiacoinOutputs = preview.Transaction.SiacoinOutputs[:1]
+	if verified, err := verifyChange(w, exact, recipients, 2); err != nil || len(verified) != 0 {
+		t.Fatalf("expected a send without change to pass, got %v %v", verified, err)
+	} else if _, err := verifyChange(w, preview.Transaction, recipients, maxDerivedIndex+1); err == nil || !strings.Contains(err.Error(), "above the maximum") {
+		t.Fatalf("expected the max index to be refused, got %v", err)
+	}
+
+	// a fee inclusive send pays the first recipient less than its amount
+	inclusive, err := buildUnsignedSend(0, recipients, siatypes.NewCurrency64(10), outputs[2:], strategySmallestFirst, true)
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := verifyChange(w, inclusive.Transaction, recipients, 2); err != nil {
+		t.Fatalf("expected the fee inclusive send to pass, got %v", err)
+	}
+
+	// an output from outside the known range would receive the change, so the send is not signed
+	outputs[2].UnlockHash = external
+	outputs[2].UnlockConditions = foreign.UnlockConditions
+	if _, err := buildSend(w, 0, recipients, siatypes.NewCurrency64(10), outputs[2:], strategySmallestFirst, false); err == nil || !strings.Contains(err.Error(), "refusing to sign") {
+		t.Fatalf("expected the send to be refused, got %v", err)
+	}
+
+	// change back to the address of the first input is signed at any index
+	outputs[2].UnlockHash = foreign.Address
+	outputs[2].Index = foreign.Index
+	if _, err := buildSend(w, 0, recipients, siatypes.NewCurrency64(10), outputs[2:], strategySmallestFirst, false); err != nil {
+		t.Fatal(err)
+	}
+}
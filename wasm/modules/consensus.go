@@ -0,0 +1,232 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall/js"
+	"time"
+)
+
+const (
+	//syncThreshold the maximum age of the latest block before the API is considered out of sync
+	syncThreshold = 3 * time.Hour
+	//tipCacheDuration how long the latest block is reused before it is requested again
+	tipCacheDuration = 30 * time.Second
+)
+
+type (
+	//chainTip the height and timestamp of the latest block
+	chainTip struct {
+		Height    uint64    `json:"height"`
+		Timestamp time.Time `json:"timestamp"`
+	}
+
+	cachedTip struct {
+		tip     chainTip
+		fetched time.Time
+	}
+
+	//tipFetch a request for the latest block shared by every caller waiting on the same currency.
+	//done is closed once tip and err are set
+	tipFetch struct {
+		done chan struct{}
+		tip  chainTip
+		err  error
+	}
+
+	//tipCache caches the latest block of each currency so confirmations, maturity, and timelocks
+	//are computed from the same height without each function requesting it
+	tipCache struct {
+		mu      sync.Mutex
+		tips    map[string]cachedTip
+		fetches map[string]*tipFetch
+	}
+
+	pingResp struct {
+		Reachable      bool      `json:"reachable"`
+		Synced         bool      `json:"synced"`
+		Height         uint64    `json:"height"`
+		BlockTimestamp time.Time `json:"block_timestamp"`
+		Latency        int64     `json:"latency"`
+		Error          string    `json:"error,omitempty"`
+	}
+)
+
+var (
+	chainTips = &tipCache{
+		tips:    make(map[string]cachedTip),
+		fetches: make(map[string]*tipFetch),
+	}
+)
+
+//Get returns the latest block of the currency, requesting it from the API if the cached block is
+//older than tipCacheDuration. The lock is not held during the request, callers of the same
+//currency share a single request and other currencies are not blocked by it
+func (c *tipCache) Get(ctx context.Context, currency string) (chainTip, error) {
+	for {
+		c.mu.Lock()
+
+		if cached, exists := c.tips[currency]; exists && time.Since(cached.fetched) < tipCacheDuration {
+			c.mu.Unlock()
+			return cached.tip, nil
+		}
+
+		if f, exists := c.fetches[currency]; exists {
+			c.mu.Unlock()
+
+			select {
+			case <-ctx.Done():
+				return chainTip{}, ctx.Err()
+			case <-f.done:
+			}
+
+			// the request was aborted by the context of the caller that made it, not this one
+			if errors.Is(f.err, context.Canceled) || errors.Is(f.err, context.DeadlineExceeded) {
+				continue
+			}
+
+			return f.tip, f.err
+		}
+
+		f := &tipFetch{
+			done: make(chan struct{}),
+		}
+		c.fetches[currency] = f
+		c.mu.Unlock()
+
+		block, err := siacentralAPIClient(currency).GetLatestBlock(ctx)
+
+		c.mu.Lock()
+		delete(c.fetches, currency)
+
+		if err == nil {
+			f.tip = chainTip{
+				Height:    block.Height,
+				Timestamp: block.Timestamp,
+			}
+
+			c.tips[currency] = cachedTip{
+				tip:     f.tip,
+				fetched: time.Now(),
+			}
+		}
+
+		f.err = err
+		c.mu.Unlock()
+		close(f.done)
+
+		return f.tip, f.err
+	}
+}
+
+//Clear removes all cached blocks
+func (c *tipCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tips = make(map[string]cachedTip)
+}
+
+//currentHeight returns the height of the latest block known to the API
+func currentHeight(ctx context.Context, currency string) (uint64, error) {
+	tip, err := chainTips.Get(ctx, currency)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return tip.Height, nil
+}
+
+//snapshotHeight returns the height an outputs snapshot was fetched at, or the current height from
+//the API if the height is not known. A snapshot with its height lets a device without network
+//access build transactions
+func snapshotHeight(ctx context.Context, currency string, height uint64) (uint64, error) {
+	if height != 0 {
+		return height, nil
+	}
+
+	return currentHeight(ctx, currency)
+}
+
+//confirmations returns the number of confirmations of a block at blockHeight
+func confirmations(height, blockHeight uint64) uint64 {
+	if blockHeight > height {
+		return 0
+	}
+
+	return height - blockHeight + 1
+}
+
+//outputConfirmations returns the number of confirmations of an output created at blockHeight. The
+//API reports unconfirmed outputs at height 0, they have no confirmations
+func outputConfirmations(height, blockHeight uint64) uint64 {
+	if blockHeight == 0 {
+		return 0
+	}
+
+	return confirmations(height, blockHeight)
+}
+
+//Confirmations returns the number of confirmations of an output created at outputBlockHeight. An
+//output height of 0 is an unconfirmed output with 0 confirmations
+func Confirmations(outputBlockHeight uint64, currency string, callback js.Value) {
+	height, err := currentHeight(context.Background(), currency)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), outputConfirmations(height, outputBlockHeight))
+}
+
+//GetBlockHeight returns the current height and timestamp of the latest block
+func GetBlockHeight(currency string, callback js.Value) {
+	tip, err := chainTips.Get(context.Background(), currency)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(tip)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//PingAPI makes a cheap request to the API for the latest block to check that it is reachable and
+//synced before starting a long running operation. An unreachable API is not treated as an error
+func PingAPI(currency string, callback js.Value) {
+	var resp pingResp
+
+	start := time.Now()
+	apiclient := siacentralAPIClient(currency)
+	block, err := apiclient.GetLatestBlock(context.Background())
+
+	resp.Latency = time.Since(start).Milliseconds()
+
+	if err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.Reachable = true
+		resp.Height = block.Height
+		resp.BlockTimestamp = block.Timestamp
+		resp.Synced = time.Since(block.Timestamp) < syncThreshold
+	}
+
+	data, err := interfaceToJSON(resp)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
@@ -0,0 +1,202 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"syscall/js"
+	"testing"
+	"time"
+)
+
+func TestTipCache(t *testing.T) {
+	canned := &cannedTransport{
+		responses: map[string]string{
+			"/v2/explorer/blocks":         `{"type":"success","block":{"height":1234}}`,
+			"/v2/scprime/explorer/blocks": `{"type":"success","block":{"height":5678}}`,
+		},
+	}
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	chainTips.Clear()
+	defer chainTips.Clear()
+
+	for i := 0; i < 3; i++ {
+		height, err := currentHeight(context.Background(), "sc")
+		if err != nil {
+			t.Fatal(err)
+		} else if height != 1234 {
+			t.Fatalf("expected height 1234, got %d", height)
+		}
+	}
+
+	if len(canned.requests) != 1 {
+		t.Fatalf("expected the height to be cached, got %d requests", len(canned.requests))
+	}
+
+	height, err := currentHeight(context.Background(), "scp")
+	if err != nil {
+		t.Fatal(err)
+	} else if height != 5678 {
+		t.Fatalf("expected height 5678, got %d", height)
+	} else if len(canned.requests) != 2 {
+		t.Fatalf("expected each currency to be cached separately, got %d requests", len(canned.requests))
+	}
+}
+
+func TestTipCacheConcurrent(t *testing.T) {
+	release := make(chan struct{})
+	canned := &cannedTransport{
+		responses: map[string]string{
+			"/v2/scprime/explorer/blocks": `{"type":"success","block":{"height":5678}}`,
+		},
+		handlers: map[string]func(*http.Request) string{
+			"/v2/explorer/blocks": func(*http.Request) string {
+				<-release
+				return `{"type":"success","block":{"height":1234}}`
+			},
+		},
+	}
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	chainTips.Clear()
+	defer chainTips.Clear()
+
+	var wg sync.WaitGroup
+	heights := make([]uint64, 3)
+	errs := make([]error, 3)
+
+	for i := range heights {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			heights[i], errs[i] = currentHeight(context.Background(), "sc")
+		}(i)
+	}
+
+	// a slow request for one currency does not block the others
+	done := make(chan error, 1)
+	go func() {
+		_, err := currentHeight(context.Background(), "scp")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the scp height to not wait on the sc request")
+	}
+
+	close(release)
+	wg.Wait()
+
+	for i := range heights {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		} else if heights[i] != 1234 {
+			t.Fatalf("expected height 1234, got %d", heights[i])
+		}
+	}
+
+	var scRequests int
+
+	for _, req := range canned.requests {
+		if req.URL.Path == "/v2/explorer/blocks" {
+			scRequests++
+		}
+	}
+
+	if scRequests != 1 {
+		t.Fatalf("expected the concurrent callers to share 1 request, got %d", scRequests)
+	}
+}
+
+func TestConfirmations(t *testing.T) {
+	tests := []struct {
+		height, blockHeight, confirmations uint64
+	}{
+		{100, 100, 1},
+		{100, 91, 10},
+		{100, 101, 0},
+	}
+
+	for _, test := range tests {
+		if c := confirmations(test.height, test.blockHeight); c != test.confirmations {
+			t.Errorf("expected %d confirmations at height %d for block %d, got %d", test.confirmations, test.height, test.blockHeight, c)
+		}
+	}
+
+	// unconfirmed outputs are reported at height 0
+	if c := outputConfirmations(100, 0); c != 0 {
+		t.Errorf("expected unconfirmed output to have 0 confirmations, got %d", c)
+	} else if c := outputConfirmations(100, 98); c != 3 {
+		t.Errorf("expected 3 confirmations, got %d", c)
+	}
+}
+
+func TestSnapshotHeight(t *testing.T) {
+	canned := &cannedTransport{
+		responses: map[string]string{
+			"/v2/explorer/blocks": `{"type":"success","block":{"height":1234}}`,
+		},
+	}
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	chainTips.Clear()
+	defer chainTips.Clear()
+
+	// a snapshot's height is used without asking the API
+	if height, err := snapshotHeight(context.Background(), "sc", 1000); err != nil {
+		t.Fatal(err)
+	} else if height != 1000 || len(canned.requests) != 0 {
+		t.Fatalf("expected the snapshot height without a request, got %d with %d requests", height, len(canned.requests))
+	}
+
+	if height, err := snapshotHeight(context.Background(), "sc", 0); err != nil {
+		t.Fatal(err)
+	} else if height != 1234 || len(canned.requests) != 1 {
+		t.Fatalf("expected the current height from the API, got %d with %d requests", height, len(canned.requests))
+	}
+}
+
+func TestPingAPI(t *testing.T) {
+	canned := &cannedTransport{
+		responses: map[string]string{
+			"/v2/explorer/blocks": fmt.Sprintf(`{"type":"success","block":{"height":1234,"timestamp":%q}}`, time.Now().Add(-time.Minute).Format(time.RFC3339)),
+		},
+	}
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	errMsg, resp := invokeCallback(t, func(callback js.Value) {
+		PingAPI("sc", callback)
+	})
+	if errMsg != "" {
+		t.Fatal(errMsg)
+	} else if !resp.Get("reachable").Bool() || !resp.Get("synced").Bool() || resp.Get("height").Int() != 1234 {
+		t.Fatal("expected a reachable and synced API at height 1234")
+	}
+
+	// a failed request is reported in the response, not as an error
+	delete(canned.responses, "/v2/explorer/blocks")
+
+	errMsg, resp = invokeCallback(t, func(callback js.Value) {
+		PingAPI("sc", callback)
+	})
+	if errMsg != "" {
+		t.Fatal(errMsg)
+	} else if resp.Get("reachable").Bool() || resp.Get("error").String() != "not found" {
+		t.Fatalf("expected an unreachable API, got error %q", resp.Get("error").String())
+	}
+}
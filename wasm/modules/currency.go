@@ -0,0 +1,161 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"syscall/js"
+
+	"github.com/shopspring/decimal"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+type (
+	//currencyParams the parameters of a network supported by the wallet
+	currencyParams struct {
+		ID           string `json:"id"`
+		Name         string `json:"name"`
+		Symbol       string `json:"symbol"`
+		FundSymbol   string `json:"fund_symbol"`
+		Decimals     int    `json:"decimals"`
+		FundDecimals int    `json:"fund_decimals"`
+		HasSiafunds  bool   `json:"has_siafunds"`
+		APIAddress   string `json:"-"`
+	}
+)
+
+//supportedCurrencies the networks supported by the wallet. The first currency is the default
+var supportedCurrencies = []currencyParams{
+	{
+		ID:           "sc",
+		Name:         "Siacoin",
+		Symbol:       "SC",
+		FundSymbol:   "SF",
+		Decimals:     24,
+		FundDecimals: 0,
+		HasSiafunds:  true,
+		APIAddress:   "https://api.siacentral.com/v2",
+	},
+	{
+		ID:           "scp",
+		Name:         "ScPrime",
+		Symbol:       "SCP",
+		FundSymbol:   "SCPF",
+		Decimals:     27,
+		FundDecimals: 0,
+		HasSiafunds:  true,
+		APIAddress:   "https://api.siacentral.com/v2/scprime",
+	},
+}
+
+//getCurrencyParams returns the parameters of the currency. Unknown currencies use the default
+func getCurrencyParams(currency string) currencyParams {
+	for _, params := range supportedCurrencies {
+		if params.ID == currency {
+			return params
+		}
+	}
+
+	return supportedCurrencies[0]
+}
+
+//assetDecimals returns the number of decimal places of the currency's siacoin or siafund asset.
+//Siacoins are divisible into hastings, siafunds are indivisible
+func assetDecimals(currency, asset string) (int32, error) {
+	params := getCurrencyParams(currency)
+
+	switch asset {
+	case "siacoin":
+		return int32(params.Decimals), nil
+	case "siafund":
+		return int32(params.FundDecimals), nil
+	}
+
+	return 0, fmt.Errorf("unknown asset %q", asset)
+}
+
+//formatAmount formats the base units of the asset as a decimal string without losing precision
+func formatAmount(c siatypes.Currency, currency, asset string) (string, error) {
+	decimals, err := assetDecimals(currency, asset)
+
+	if err != nil {
+		return "", err
+	}
+
+	return decimal.NewFromBigInt(c.Big(), -decimals).String(), nil
+}
+
+//parseAmount parses a decimal string of the asset into its base units. Amounts with more precision
+//than the asset's smallest unit are rejected instead of being rounded
+func parseAmount(str, currency, asset string) (siatypes.Currency, error) {
+	decimals, err := assetDecimals(currency, asset)
+
+	if err != nil {
+		return siatypes.ZeroCurrency, err
+	}
+
+	d, err := decimal.NewFromString(strings.TrimSpace(str))
+
+	if err != nil {
+		return siatypes.ZeroCurrency, fmt.Errorf("unable to parse amount %q", str)
+	} else if d.Sign() < 0 {
+		return siatypes.ZeroCurrency, errors.New("amount cannot be negative")
+	}
+
+	units := d.Shift(decimals)
+
+	if !units.Equal(units.Truncate(0)) {
+		if decimals == 0 {
+			return siatypes.ZeroCurrency, fmt.Errorf("%ss are indivisible", asset)
+		}
+
+		return siatypes.ZeroCurrency, fmt.Errorf("amount has more than %d decimal places", decimals)
+	}
+
+	return siatypes.NewCurrency(new(big.Int).Set(units.BigInt())), nil
+}
+
+//FormatCurrency formats the base units of the currency's siacoin or siafund asset as a decimal
+//string
+func FormatCurrency(value siatypes.Currency, currency, asset string, callback js.Value) {
+	str, err := formatAmount(value, currency, asset)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), str)
+}
+
+//ParseCurrency parses a decimal string of the currency's siacoin or siafund asset, returning the
+//base units as a string
+func ParseCurrency(str, currency, asset string, callback js.Value) {
+	value, err := parseAmount(str, currency, asset)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), value.String())
+}
+
+//GetSupportedCurrencies returns the currencies supported by the wallet and their parameters
+func GetSupportedCurrencies(callback js.Value) {
+	currencies := make([]interface{}, len(supportedCurrencies))
+
+	for i, params := range supportedCurrencies {
+		data, err := interfaceToJSON(params)
+
+		if err != nil {
+			callback.Invoke(err.Error(), js.Null())
+			return
+		}
+
+		currencies[i] = data
+	}
+
+	callback.Invoke(js.Null(), currencies)
+}
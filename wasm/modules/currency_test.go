@@ -0,0 +1,84 @@
+package modules
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+func TestFormatAmount(t *testing.T) {
+	// siacoins have no max supply, one trillion is far more than will ever be in circulation. Siafunds
+	// have a fixed supply of 10,000
+	maxSupply, _ := new(big.Int).SetString("1000000000000000000000000000000000000", 10)
+
+	tests := []struct {
+		value    siatypes.Currency
+		currency string
+		asset    string
+		expected string
+	}{
+		{siatypes.NewCurrency64(1), "sc", "siacoin", "0.000000000000000000000001"},
+		{siatypes.SiacoinPrecision, "sc", "siacoin", "1"},
+		{siatypes.NewCurrency(maxSupply), "sc", "siacoin", "1000000000000"},
+		{siatypes.NewCurrency64(1), "scp", "siacoin", "0.000000000000000000000000001"},
+		{siatypes.ZeroCurrency, "sc", "siacoin", "0"},
+		{siatypes.NewCurrency64(1), "sc", "siafund", "1"},
+		{siatypes.NewCurrency64(10000), "sc", "siafund", "10000"},
+	}
+
+	for _, tt := range tests {
+		str, err := formatAmount(tt.value, tt.currency, tt.asset)
+		if err != nil {
+			t.Fatal(err)
+		} else if str != tt.expected {
+			t.Fatalf("expected %s %s to format as %s, got %s", tt.value, tt.asset, tt.expected, str)
+		}
+
+		// every formatted amount must parse back to the same value
+		value, err := parseAmount(str, tt.currency, tt.asset)
+		if err != nil {
+			t.Fatal(err)
+		} else if !value.Equals(tt.value) {
+			t.Fatalf("expected %s to parse as %s, got %s", str, tt.value, value)
+		}
+	}
+
+	if _, err := formatAmount(siatypes.ZeroCurrency, "sc", "bitcoin"); err == nil {
+		t.Fatal("expected unknown asset to fail")
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	value, err := parseAmount(" 1.5 ", "sc", "siacoin")
+	if err != nil {
+		t.Fatal(err)
+	} else if !value.Equals(siatypes.SiacoinPrecision.Mul64(3).Div64(2)) {
+		t.Fatalf("expected 1.5 SC, got %s", value)
+	}
+
+	tests := []struct {
+		str      string
+		currency string
+		asset    string
+		err      string
+	}{
+		{"1.5", "sc", "siafund", "siafunds are indivisible"},
+		{"0.0000000000000000000000001", "sc", "siacoin", "more than 24 decimal places"},
+		{"-1", "sc", "siacoin", "negative"},
+		{"abc", "sc", "siacoin", "unable to parse"},
+		{"", "sc", "siafund", "unable to parse"},
+	}
+
+	for _, tt := range tests {
+		if _, err := parseAmount(tt.str, tt.currency, tt.asset); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Fatalf("expected %q to fail with %q, got %v", tt.str, tt.err, err)
+		}
+	}
+
+	// ScPrime has more decimal places than Siacoin
+	if _, err := parseAmount("0.0000000000000000000000001", "scp", "siacoin"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build debug
+// +build debug
+
+package modules
+
+//debugAssertions enables internal consistency checks that are too expensive for release builds.
+//Build with -tags debug to enable
+const debugAssertions = true
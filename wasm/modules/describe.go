@@ -0,0 +1,187 @@
+package modules
+
+import (
+	"fmt"
+	"syscall/js"
+
+	apitypes "github.com/siacentral/apisdkgo/types"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+type (
+	//transactionDescription a short sentence describing a transaction from the wallet's point of
+	//view, for notifications. Type is "sent", "received", "payout", "defrag", "contract",
+	//"host_announcement", or "none" if the wallet's balance did not change. Amount is in the asset
+	//of the sentence, siacoins unless a siafund was moved
+	transactionDescription struct {
+		TransactionID string            `json:"transaction_id"`
+		Type          string            `json:"type"`
+		Asset         string            `json:"asset"`
+		Amount        siatypes.Currency `json:"amount"`
+		Fee           siatypes.Currency `json:"fee"`
+		Recipients    []string          `json:"recipients"`
+		Description   string            `json:"description"`
+	}
+)
+
+//shortAddress returns the first characters of the address for display
+func shortAddress(address string) string {
+	if len(address) <= 8 {
+		return address
+	}
+
+	return address[:8] + "…"
+}
+
+//describeRecipients returns the first recipient, and how many others there are, for a sentence
+func describeRecipients(recipients []string) string {
+	switch len(recipients) {
+	case 0:
+		return ""
+	case 1:
+		return " to " + shortAddress(recipients[0])
+	case 2:
+		return fmt.Sprintf(" to %s and 1 other", shortAddress(recipients[0]))
+	default:
+		return fmt.Sprintf(" to %s and %d others", shortAddress(recipients[0]), len(recipients)-1)
+	}
+}
+
+//describeTransaction classifies the transaction from the point of view of the owned addresses and
+//describes it in a sentence. The fee is only included if the wallet funded every siacoin input,
+//otherwise another party paid it
+func describeTransaction(txn apitypes.Transaction, ownedAddresses map[string]bool, currency string) (desc transactionDescription) {
+	var ownedInputs int
+	var sent siatypes.Currency
+	var recipients []string
+
+	params := getCurrencyParams(currency)
+	processed, ok := processTransaction(txn, ownedAddresses, 0)
+
+	desc = transactionDescription{
+		TransactionID: txn.ID,
+		Type:          "none",
+		Asset:         "siacoin",
+		Recipients:    []string{},
+	}
+
+	seen := make(map[string]bool)
+
+	for _, input := range processed.SiacoinInputs {
+		if input.Owned {
+			ownedInputs++
+		}
+	}
+
+	if ownedInputs != 0 && ownedInputs == len(processed.SiacoinInputs) {
+		desc.Fee = txn.Fees
+	}
+
+	// the recipients of a siafund send are the siafund outputs, otherwise the siacoin outputs
+	sendsSiafunds := !processed.SiafundValue.Value.IsZero() && processed.SiafundValue.Direction == "sent"
+
+	for _, output := range processed.SiacoinOutputs {
+		if output.Owned || sendsSiafunds {
+			continue
+		}
+
+		sent = sent.Add(output.Value)
+
+		if !seen[output.UnlockHash] {
+			seen[output.UnlockHash] = true
+			recipients = append(recipients, output.UnlockHash)
+		}
+	}
+
+	for _, output := range processed.SiafundOutputs {
+		if output.Owned || !sendsSiafunds || seen[output.UnlockHash] {
+			continue
+		}
+
+		seen[output.UnlockHash] = true
+		recipients = append(recipients, output.UnlockHash)
+	}
+
+	fee := ""
+
+	if !desc.Fee.IsZero() {
+		fee = fmt.Sprintf(" (fee %s %s)", siacoinString(desc.Fee, currency), params.Symbol)
+	}
+
+	hasTag := func(tag string) bool {
+		for _, t := range processed.Tags {
+			if t == tag {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	switch {
+	case !ok:
+		desc.Description = "Transaction did not change the wallet's balance"
+	case len(txn.SiacoinInputs) == 0 && len(txn.SiacoinOutputs) != 0:
+		desc.Type = "payout"
+		desc.Amount = processed.SiacoinValue.Value
+		desc.Description = fmt.Sprintf("Received %s %s (%s)", siacoinString(desc.Amount, currency), params.Symbol, transactionType(txn, currency))
+	case hasTag("defrag"):
+		desc.Type = "defrag"
+		desc.Description = "Defragmented the wallet" + fee
+	case !processed.SiafundValue.Value.IsZero():
+		amount, _ := formatAmount(processed.SiafundValue.Value, currency, "siafund")
+
+		desc.Type = processed.SiafundValue.Direction
+		desc.Asset = "siafund"
+		desc.Amount = processed.SiafundValue.Value
+
+		if desc.Type == "received" {
+			desc.Description = fmt.Sprintf("Received %s %s", amount, params.FundSymbol)
+		} else {
+			desc.Recipients = recipients
+			desc.Description = fmt.Sprintf("Sent %s %s%s%s", amount, params.FundSymbol, describeRecipients(recipients), fee)
+		}
+	case processed.SiacoinValue.Direction == "received":
+		desc.Type = "received"
+		desc.Amount = processed.SiacoinValue.Value
+		desc.Description = fmt.Sprintf("Received %s %s", siacoinString(desc.Amount, currency), params.Symbol)
+	case len(txn.StorageContracts) != 0:
+		// the payout of the contract is not an output, it is the value leaving the wallet
+		desc.Type = "contract"
+
+		if processed.SiacoinValue.Value.Cmp(desc.Fee) > 0 {
+			desc.Amount = processed.SiacoinValue.Value.Sub(desc.Fee)
+		}
+
+		desc.Description = fmt.Sprintf("Formed a storage contract for %s %s%s", siacoinString(desc.Amount, currency), params.Symbol, fee)
+	case len(txn.HostAnnouncements) != 0 && sent.IsZero():
+		desc.Type = "host_announcement"
+		desc.Description = "Announced the host" + fee
+	default:
+		desc.Type = "sent"
+		desc.Amount = sent
+		desc.Recipients = recipients
+		desc.Description = fmt.Sprintf("Sent %s %s%s%s", siacoinString(sent, currency), params.Symbol, describeRecipients(recipients), fee)
+	}
+
+	return
+}
+
+//DescribeTransaction returns a short sentence describing the transaction from the point of view of
+//the wallet's addresses, like "Sent 50 SC to 5c1c2c50… (fee 0.01 SC)" or "Received 100 SC"
+func DescribeTransaction(txn apitypes.Transaction, addresses []string, currency string, callback js.Value) {
+	ownedAddresses := make(map[string]bool)
+
+	for _, addr := range addresses {
+		ownedAddresses[addr] = true
+	}
+
+	data, err := interfaceToJSON(describeTransaction(txn, ownedAddresses, currency))
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
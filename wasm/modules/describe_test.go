@@ -0,0 +1,81 @@
+package modules
+
+import (
+	"testing"
+
+	apitypes "github.com/siacentral/apisdkgo/types"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+func TestDescribeTransaction(t *testing.T) {
+	sc := siatypes.SiacoinPrecision
+	owned := map[string]bool{"ownedaddress1": true, "ownedaddress2": true}
+
+	send := apitypes.Transaction{
+		ID:   "send",
+		Fees: sc.Div64(100),
+		SiacoinInputs: []apitypes.SiacoinInput{
+			{SiacoinOutput: apitypes.SiacoinOutput{UnlockHash: "ownedaddress1", Value: sc.Mul64(100)}},
+		},
+		SiacoinOutputs: []apitypes.SiacoinOutput{
+			{UnlockHash: "recipientaddress", Value: sc.Mul64(50)},
+			{UnlockHash: "ownedaddress2", Value: sc.Mul64(50).Sub(sc.Div64(100))},
+		},
+	}
+
+	desc := describeTransaction(send, owned, "sc")
+	if desc.Type != "sent" || desc.Amount.Cmp(sc.Mul64(50)) != 0 {
+		t.Fatalf("expected a send of 50 SC, got %+v", desc)
+	} else if desc.Description != "Sent 50 SC to recipien… (fee 0.01 SC)" {
+		t.Fatalf("unexpected description %q", desc.Description)
+	}
+
+	// another wallet funded the transaction, so the fee is not the wallet's
+	receive := send
+	receive.ID = "receive"
+	receive.SiacoinInputs = []apitypes.SiacoinInput{
+		{SiacoinOutput: apitypes.SiacoinOutput{UnlockHash: "sender", Value: sc.Mul64(101)}},
+	}
+	receive.SiacoinOutputs = []apitypes.SiacoinOutput{
+		{UnlockHash: "ownedaddress1", Value: sc.Mul64(100)},
+		{UnlockHash: "sender", Value: sc.Sub(sc.Div64(100))},
+	}
+
+	desc = describeTransaction(receive, owned, "sc")
+	if desc.Type != "received" || !desc.Fee.IsZero() || len(desc.Recipients) != 0 {
+		t.Fatalf("expected a receive without a fee, got %+v", desc)
+	} else if desc.Description != "Received 100 SC" {
+		t.Fatalf("unexpected description %q", desc.Description)
+	}
+
+	payout := apitypes.Transaction{
+		ID: "payout",
+		SiacoinOutputs: []apitypes.SiacoinOutput{
+			{UnlockHash: "ownedaddress1", Value: sc.Mul64(300), Source: "block_reward"},
+		},
+	}
+
+	if desc = describeTransaction(payout, owned, "sc"); desc.Type != "payout" || desc.Description != "Received 300 SC (Block Reward)" {
+		t.Fatalf("expected a block reward payout, got %+v", desc)
+	}
+
+	split := send
+	split.SiacoinOutputs = []apitypes.SiacoinOutput{
+		{UnlockHash: "recipientaddress", Value: sc.Mul64(20)},
+		{UnlockHash: "otheraddress", Value: sc.Mul64(20)},
+		{UnlockHash: "thirdaddress", Value: sc.Mul64(20)},
+		{UnlockHash: "ownedaddress2", Value: sc.Mul64(40).Sub(sc.Div64(100))},
+	}
+
+	if desc = describeTransaction(split, owned, "sc"); desc.Description != "Sent 60 SC to recipien… and 2 others (fee 0.01 SC)" || len(desc.Recipients) != 3 {
+		t.Fatalf("expected a send to 3 recipients, got %+v", desc)
+	}
+
+	external := send
+	external.SiacoinInputs = receive.SiacoinInputs
+	external.SiacoinOutputs = []apitypes.SiacoinOutput{{UnlockHash: "recipientaddress", Value: sc.Mul64(100)}}
+
+	if desc = describeTransaction(external, owned, "sc"); desc.Type != "none" {
+		t.Fatalf("expected a transaction not touching the wallet to be none, got %+v", desc)
+	}
+}
@@ -0,0 +1,281 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"syscall/js"
+
+	apitypes "github.com/siacentral/apisdkgo/types"
+)
+
+const (
+	//detectScanDepth the number of addresses scanned to detect the wallet type
+	detectScanDepth = 1000
+	//detectTransactionLimit the number of transactions inspected to detect the wallet type
+	detectTransactionLimit = 100
+	//mixedThreshold the minimum share of the less common asset for a wallet to be mixed
+	mixedThreshold = 0.25
+	//currencyProbeDepth the number of addresses checked on each network to detect the currency
+	currencyProbeDepth = 10
+)
+
+type (
+	walletTypeResp struct {
+		Type                string  `json:"type"`
+		Confidence          float64 `json:"confidence"`
+		UsedAddresses       int     `json:"used_addresses"`
+		SiacoinTransactions int     `json:"siacoin_transactions"`
+		SiafundTransactions int     `json:"siafund_transactions"`
+	}
+
+	currencyDetectResp struct {
+		Currency      string         `json:"currency"`
+		UsedAddresses map[string]int `json:"used_addresses"`
+	}
+
+	//probeProgress the part of a shallow probe that is being checked. Stage is "addresses" when a
+	//range of addresses finished scanning, "transactions" while the used addresses' transactions
+	//are inspected, and "network" when a network is about to be checked
+	probeProgress struct {
+		Stage    string `json:"stage"`
+		Currency string `json:"currency"`
+		Start    uint64 `json:"start"`
+		End      uint64 `json:"end"`
+		Found    int    `json:"found"`
+	}
+)
+
+//transactionAssets reports whether the transaction moved siacoins or siafunds belonging to the
+//owned addresses
+func transactionAssets(txn apitypes.Transaction, owned map[string]bool) (siacoins, siafunds bool) {
+	for _, input := range txn.SiacoinInputs {
+		siacoins = siacoins || owned[input.UnlockHash]
+	}
+
+	for _, output := range txn.SiacoinOutputs {
+		siacoins = siacoins || owned[output.UnlockHash]
+	}
+
+	for _, input := range txn.SiafundInputs {
+		siafunds = siafunds || owned[input.UnlockHash]
+	}
+
+	for _, output := range txn.SiafundOutputs {
+		siafunds = siafunds || owned[output.UnlockHash]
+	}
+
+	return
+}
+
+//classifyWallet returns the dominant type of a wallet from the number of transactions moving each
+//asset and the confidence of the classification between 0 and 1. A wallet is mixed if the less
+//common asset is in at least mixedThreshold of the transactions
+func classifyWallet(siacoinTxns, siafundTxns int) (string, float64) {
+	total := siacoinTxns + siafundTxns
+
+	if total == 0 {
+		return "unused", 1
+	}
+
+	siacoinShare := float64(siacoinTxns) / float64(total)
+	siafundShare := float64(siafundTxns) / float64(total)
+
+	switch {
+	case siafundShare >= mixedThreshold && siacoinShare >= mixedThreshold:
+		// most confident when the assets are evenly split
+		diff := siacoinShare - siafundShare
+
+		if diff < 0 {
+			diff = -diff
+		}
+
+		return "mixed", 1 - diff
+	case siafundShare > siacoinShare:
+		return "siafund", siafundShare
+	default:
+		return "siacoin", siacoinShare
+	}
+}
+
+//DetectWalletType does a shallow scan of the wallet's first addresses and inspects their recent
+//transactions to determine whether it is primarily a siacoin wallet, a siafund wallet, or mixed.
+//Progress is sent as each range of addresses is scanned and before the transactions are inspected
+func DetectWalletType(seed, currency string, callback js.Value) {
+	var resp walletTypeResp
+	var used []string
+
+	w, err := recoverWallet(seed, currency, 0)
+
+	if err != nil {
+		callback.Invoke(fmt.Errorf("unable to recover wallet: %w", err).Error(), js.Null())
+		return
+	}
+
+	err = scanAddresses(context.Background(), w, currency, 0, detectScanDepth, 2, detectScanDepth/4, 0, 0, nil, func(res recoveryResults) error {
+		for _, addr := range res.Addresses {
+			used = append(used, addr.Address)
+		}
+
+		sendProbeProgress(callback, probeProgress{
+			Stage:    "addresses",
+			Currency: currency,
+			Start:    res.Start,
+			End:      res.End,
+			Found:    len(used),
+		})
+
+		return nil
+	})
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	resp.UsedAddresses = len(used)
+
+	if len(used) != 0 {
+		owned := make(map[string]bool)
+
+		for _, addr := range used {
+			owned[addr] = true
+		}
+
+		sendProbeProgress(callback, probeProgress{
+			Stage:    "transactions",
+			Currency: currency,
+			End:      detectScanDepth,
+			Found:    len(used),
+		})
+
+		balance, err := siacentralAPIClient(currency).FindAddressBalance(context.Background(), detectTransactionLimit, 0, used)
+
+		if err != nil {
+			callback.Invoke(fmt.Errorf("unable to get transactions: %w", err).Error(), js.Null())
+			return
+		}
+
+		for _, txn := range balance.Transactions {
+			siacoins, siafunds := transactionAssets(txn, owned)
+
+			if siacoins {
+				resp.SiacoinTransactions++
+			}
+
+			if siafunds {
+				resp.SiafundTransactions++
+			}
+		}
+	}
+
+	resp.Type, resp.Confidence = classifyWallet(resp.SiacoinTransactions, resp.SiafundTransactions)
+
+	data, err := interfaceToJSON(resp)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//sendProbeProgress sends the progress of a shallow probe to the callback. Progress is best-effort,
+//a payload that cannot be encoded is dropped instead of failing the probe
+func sendProbeProgress(callback js.Value, progress probeProgress) {
+	data, err := interfaceToJSON(progress)
+
+	if err != nil {
+		return
+	}
+
+	callback.Invoke("progress", data)
+}
+
+//probeCurrencies counts how many of the addresses have been used on each supported network. The
+//addresses of a seed are the same on every network so they only need to be derived once. A network
+//that fails to respond is left out of the counts and its error is returned with the results. If
+//onProbe is not nil it is called before each network is checked
+func probeCurrencies(ctx context.Context, addresses []string, onProbe func(currencyParams)) (map[string]int, error) {
+	var probeErr error
+
+	counts := make(map[string]int)
+
+	for _, params := range supportedCurrencies {
+		if onProbe != nil {
+			onProbe(params)
+		}
+
+		used, err := siacentralAPIClient(params.ID).FindUsedAddresses(ctx, addresses)
+
+		if err != nil {
+			probeErr = fmt.Errorf("unable to probe %s: %w", params.Name, err)
+			continue
+		}
+
+		counts[params.ID] = len(used)
+	}
+
+	return counts, probeErr
+}
+
+//detectCurrency returns the network with the most used addresses or "unknown" if none of the
+//networks have activity
+func detectCurrency(counts map[string]int) string {
+	detected, most := "unknown", 0
+
+	// iterate the supported currencies so ties always resolve to the same network
+	for _, params := range supportedCurrencies {
+		if counts[params.ID] > most {
+			detected, most = params.ID, counts[params.ID]
+		}
+	}
+
+	return detected
+}
+
+//AutoDetectCurrency does a best-effort check of the seed's first few addresses against each
+//supported network to find the one the seed was used on. Returns "unknown" instead of guessing
+//when no network shows activity. Only fails if no activity was found and a network could not be
+//checked, since the activity may be on that network. Progress is sent before each network is
+//checked
+func AutoDetectCurrency(seed string, callback js.Value) {
+	w, err := recoverWallet(seed, supportedCurrencies[0].ID, 0)
+
+	if err != nil {
+		callback.Invoke(fmt.Errorf("unable to recover wallet: %w", err).Error(), js.Null())
+		return
+	}
+
+	addresses := make([]string, currencyProbeDepth)
+
+	for i := range addresses {
+		addresses[i] = w.GetAddress(uint64(i)).UnlockConditions.UnlockHash().String()
+	}
+
+	counts, err := probeCurrencies(context.Background(), addresses, func(params currencyParams) {
+		sendProbeProgress(callback, probeProgress{
+			Stage:    "network",
+			Currency: params.ID,
+			End:      currencyProbeDepth,
+		})
+	})
+	resp := currencyDetectResp{
+		Currency:      detectCurrency(counts),
+		UsedAddresses: counts,
+	}
+
+	if err != nil && resp.Currency == "unknown" {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(resp)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
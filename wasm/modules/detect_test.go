@@ -0,0 +1,81 @@
+package modules
+
+import (
+	"context"
+	"testing"
+)
+
+func TestClassifyWallet(t *testing.T) {
+	tests := []struct {
+		siacoins, siafunds int
+		walletType         string
+		confidence         float64
+	}{
+		{0, 0, "unused", 1},
+		{10, 0, "siacoin", 1},
+		{9, 1, "siacoin", 0.9},
+		{1, 4, "siafund", 0.8},
+		{5, 5, "mixed", 1},
+		{6, 4, "mixed", 0.8},
+	}
+
+	for _, test := range tests {
+		walletType, confidence := classifyWallet(test.siacoins, test.siafunds)
+
+		if walletType != test.walletType {
+			t.Errorf("%d siacoin %d siafund: expected %s, got %s", test.siacoins, test.siafunds, test.walletType, walletType)
+		} else if diff := confidence - test.confidence; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("%d siacoin %d siafund: expected confidence %f, got %f", test.siacoins, test.siafunds, test.confidence, confidence)
+		}
+	}
+}
+
+func TestProbeCurrencies(t *testing.T) {
+	transport := usedAddressTransport(map[string]string{
+		"addr1": "sender",
+		"addr2": "receiver",
+	})
+	// only ScPrime shows activity, Sia responds with no used addresses
+	transport.handlers["/v2/scprime/wallet/addresses/used"] = transport.handlers["/v2/wallet/addresses/used"]
+	transport.responses["/v2/wallet/addresses/used"] = `{"type":"success","addresses":[]}`
+	delete(transport.handlers, "/v2/wallet/addresses/used")
+
+	SetTransport(transport)
+	defer SetTransport(nil)
+
+	var probed []string
+	counts, err := probeCurrencies(context.Background(), []string{"addr1", "addr2", "addr3"}, func(params currencyParams) {
+		probed = append(probed, params.ID)
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	} else if counts["sc"] != 0 || counts["scp"] != 2 {
+		t.Fatalf("unexpected counts %v", counts)
+	} else if currency := detectCurrency(counts); currency != "scp" {
+		t.Fatalf("expected scp, got %s", currency)
+	} else if len(probed) != len(supportedCurrencies) || probed[0] != supportedCurrencies[0].ID {
+		t.Fatalf("expected progress for each network in order, got %v", probed)
+	}
+
+	// a network that cannot be checked is left out and reported
+	delete(transport.handlers, "/v2/scprime/wallet/addresses/used")
+
+	counts, err = probeCurrencies(context.Background(), []string{"addr1"}, nil)
+
+	if err == nil {
+		t.Fatal("expected probe error")
+	} else if _, exists := counts["scp"]; exists {
+		t.Fatal("expected failed network to be left out")
+	} else if currency := detectCurrency(counts); currency != "unknown" {
+		t.Fatalf("expected unknown, got %s", currency)
+	}
+}
+
+func TestDetectCurrencyTie(t *testing.T) {
+	if currency := detectCurrency(map[string]int{"sc": 3, "scp": 3}); currency != "sc" {
+		t.Fatalf("expected ties to resolve to sc, got %s", currency)
+	} else if currency := detectCurrency(nil); currency != "unknown" {
+		t.Fatalf("expected unknown, got %s", currency)
+	}
+}
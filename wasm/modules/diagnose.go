@@ -0,0 +1,122 @@
+package modules
+
+import (
+	"context"
+	"syscall/js"
+	"time"
+
+	apitypes "github.com/siacentral/apisdkgo/types"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+const (
+	//stuckThreshold the duration a transaction can stay in the transaction pool, about six blocks,
+	//before it is considered stuck
+	stuckThreshold = time.Hour
+)
+
+type (
+	//transactionDiagnosis whether a transaction is stuck in the transaction pool and if paying a
+	//higher fee would help it confirm. Pending is in seconds
+	transactionDiagnosis struct {
+		TransactionID      string             `json:"transaction_id"`
+		Confirmations      uint64             `json:"confirmations"`
+		Pending            int64              `json:"pending"`
+		Stuck              bool               `json:"stuck"`
+		Fees               siatypes.Currency  `json:"fees"`
+		Size               uint64             `json:"size,omitempty"`
+		FeeRate            *siatypes.Currency `json:"fee_rate,omitempty"`
+		Market             feeMarket          `json:"market"`
+		RecommendBump      bool               `json:"recommend_bump"`
+		RecommendedFeeRate *siatypes.Currency `json:"recommended_fee_rate,omitempty"`
+		AdditionalFee      *siatypes.Currency `json:"additional_fee,omitempty"`
+		Reason             string             `json:"reason"`
+	}
+)
+
+//diagnoseTransaction compares the fee rate of the transaction to the fee market. A bump is only
+//recommended for a transaction that has been pending longer than the stuck threshold with a fee
+//rate below the maximum of the market, a transaction already paying the market rate is waiting on
+//something other than its fee
+func diagnoseTransaction(txn apitypes.Transaction, market feeMarket, now time.Time) (diagnosis transactionDiagnosis) {
+	diagnosis = transactionDiagnosis{
+		TransactionID: txn.ID,
+		Confirmations: txn.Confirmations,
+		Fees:          txn.Fees,
+		Market:        market,
+	}
+
+	if txn.Confirmations != 0 {
+		diagnosis.Reason = "transaction is confirmed"
+		return
+	}
+
+	if !txn.Timestamp.IsZero() && now.After(txn.Timestamp) {
+		pending := now.Sub(txn.Timestamp)
+
+		diagnosis.Pending = int64(pending / time.Second)
+		diagnosis.Stuck = pending >= stuckThreshold
+	}
+
+	size, ok := transactionSize(txn)
+
+	if !ok {
+		diagnosis.Reason = "fee rate of the transaction cannot be computed"
+		return
+	}
+
+	rate := txn.Fees.Div64(size)
+
+	diagnosis.Size = size
+	diagnosis.FeeRate = &rate
+
+	switch {
+	case !diagnosis.Stuck:
+		diagnosis.Reason = "transaction has not been pending long enough to be stuck"
+	case rate.Cmp(market.Maximum) >= 0:
+		diagnosis.Reason = "fee rate is at or above the current market, a fee bump is unlikely to help"
+	default:
+		recommended := market.Maximum
+		additional := recommended.Mul64(size).Sub(txn.Fees)
+
+		diagnosis.RecommendBump = true
+		diagnosis.RecommendedFeeRate = &recommended
+		diagnosis.AdditionalFee = &additional
+
+		if rate.Cmp(market.Minimum) < 0 {
+			diagnosis.Reason = "fee rate is below the current minimum"
+		} else {
+			diagnosis.Reason = "fee rate is below the current maximum"
+		}
+	}
+
+	return
+}
+
+//DiagnoseTransaction checks whether a pending transaction is stuck and if a fee bump would help it
+//confirm. Returns the current fee market and the transaction's effective fee rate
+func DiagnoseTransaction(id, currency string, callback js.Value) {
+	ctx := context.Background()
+	txn, err := siacentralAPIClient(currency).GetTransaction(ctx, id)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	market, err := currentFeeMarket(ctx, currency)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(diagnoseTransaction(txn, market, time.Now()))
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
@@ -0,0 +1,53 @@
+package modules
+
+import (
+	"testing"
+	"time"
+
+	apitypes "github.com/siacentral/apisdkgo/types"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+func TestDiagnoseTransaction(t *testing.T) {
+	now := time.Now()
+	market := feeMarket{
+		Minimum: siatypes.NewCurrency64(10),
+		Maximum: siatypes.NewCurrency64(30),
+	}
+
+	// an empty transaction is 80 bytes
+	txn := apitypes.Transaction{
+		ID:        "txn",
+		Timestamp: now.Add(-2 * stuckThreshold),
+		Fees:      siatypes.NewCurrency64(800),
+	}
+
+	diagnosis := diagnoseTransaction(txn, market, now)
+	if !diagnosis.Stuck || !diagnosis.RecommendBump {
+		t.Fatalf("expected a stuck transaction to recommend a bump, got %+v", diagnosis)
+	} else if diagnosis.FeeRate.Cmp64(10) != 0 || diagnosis.Size != 80 {
+		t.Fatalf("expected fee rate 10 for 80 bytes, got %s for %d", diagnosis.FeeRate, diagnosis.Size)
+	} else if diagnosis.RecommendedFeeRate.Cmp64(30) != 0 || diagnosis.AdditionalFee.Cmp64(1600) != 0 {
+		t.Fatalf("expected an additional fee of 1600 at rate 30, got %s at %s", diagnosis.AdditionalFee, diagnosis.RecommendedFeeRate)
+	} else if diagnosis.Pending != int64(2*stuckThreshold/time.Second) {
+		t.Fatalf("expected pending %d, got %d", int64(2*stuckThreshold/time.Second), diagnosis.Pending)
+	}
+
+	// a transaction already paying the market rate will not confirm faster with a bump
+	txn.Fees = siatypes.NewCurrency64(2400)
+	if diagnosis := diagnoseTransaction(txn, market, now); !diagnosis.Stuck || diagnosis.RecommendBump {
+		t.Fatalf("expected no bump at the market rate, got %+v", diagnosis)
+	}
+
+	txn.Fees = siatypes.NewCurrency64(800)
+	txn.Timestamp = now.Add(-time.Minute)
+	if diagnosis := diagnoseTransaction(txn, market, now); diagnosis.Stuck || diagnosis.RecommendBump {
+		t.Fatalf("expected a recent transaction to not be stuck, got %+v", diagnosis)
+	}
+
+	txn.Confirmations = 1
+	txn.Timestamp = now.Add(-2 * stuckThreshold)
+	if diagnosis := diagnoseTransaction(txn, market, now); diagnosis.Stuck || diagnosis.RecommendBump || diagnosis.FeeRate != nil {
+		t.Fatalf("expected a confirmed transaction to not be diagnosed, got %+v", diagnosis)
+	}
+}
@@ -0,0 +1,138 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"syscall/js"
+	"time"
+
+	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
+)
+
+type (
+	//recoveryEstimate the expected duration of a recovery scan in milliseconds
+	recoveryEstimate struct {
+		Rounds     uint64 `json:"rounds"`
+		Derivation int64  `json:"derivation"`
+		Latency    int64  `json:"latency"`
+		Best       int64  `json:"best"`
+		Worst      int64  `json:"worst"`
+	}
+
+	//derivationBenchmark the time to derive count addresses. Duration is in milliseconds
+	derivationBenchmark struct {
+		Count              uint64  `json:"count"`
+		Duration           int64   `json:"duration"`
+		AddressesPerSecond float64 `json:"addresses_per_second"`
+	}
+)
+
+//estimateScanDuration extrapolates the duration of a scan of rounds rounds from the time to derive
+//and query a single round. Derivation is CPU bound and cannot run in parallel, but the API requests
+//of the workers overlap. The best case assumes every request overlaps, the worst case assumes none
+//do
+func estimateScanDuration(rounds uint64, derivation, latency time.Duration) (best, worst time.Duration) {
+	batches := (rounds + workers - 1) / workers
+
+	best = time.Duration(rounds)*derivation + time.Duration(batches)*latency
+	worst = time.Duration(rounds) * (derivation + latency)
+
+	return
+}
+
+//EstimateRecoveryTime measures the time to derive and query a single round of addressCount
+//addresses and extrapolates the duration of a scan stopping after maxEmptyRounds empty rounds. The
+//estimate is for a wallet without used addresses, each round containing used addresses extends the
+//scan by another maxEmptyRounds rounds. The round size is floored the same way as a recovery scan
+func EstimateRecoveryTime(currency string, addressCount, maxEmptyRounds uint64, callback js.Value) {
+	addressCount, maxEmptyRounds = effectiveRoundSize(addressCount, maxEmptyRounds, defaultMinRoundSize)
+
+	phrase, err := wallet.NewBIP39RecoveryPhrase()
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	w, err := wallet.RecoverBIP39Seed(phrase, currency)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	start := time.Now()
+	addresses := make([]string, addressCount)
+
+	for i := range addresses {
+		addresses[i] = generateAddress(w, uint64(i)).Address
+	}
+
+	derivation := time.Since(start)
+	start = time.Now()
+
+	if _, err := siacentralAPIClient(currency).FindUsedAddresses(context.Background(), addresses); err != nil {
+		callback.Invoke(fmt.Errorf("unable to get used addresses: %w", err).Error(), js.Null())
+		return
+	}
+
+	latency := time.Since(start)
+	best, worst := estimateScanDuration(maxEmptyRounds, derivation, latency)
+
+	data, err := interfaceToJSON(recoveryEstimate{
+		Rounds:     maxEmptyRounds,
+		Derivation: derivation.Milliseconds(),
+		Latency:    latency.Milliseconds(),
+		Best:       best.Milliseconds(),
+		Worst:      worst.Milliseconds(),
+	})
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//derivationRate returns the number of addresses derived per second. A duration too short to
+//measure returns 0 instead of infinity
+func derivationRate(count uint64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+
+	return float64(count) / d.Seconds()
+}
+
+//BenchmarkDerivation derives count addresses of the seed the same way a recovery scan does and
+//returns the derivation throughput of the device. The API is not queried
+func BenchmarkDerivation(seed, currency string, count uint64, callback js.Value) {
+	w, err := recoverWallet(seed, currency, 0)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	start := time.Now()
+
+	for i := uint64(0); i < count; i++ {
+		generateAddress(w, i)
+	}
+
+	duration := time.Since(start)
+
+	data, err := interfaceToJSON(derivationBenchmark{
+		Count:              count,
+		Duration:           duration.Milliseconds(),
+		AddressesPerSecond: derivationRate(count, duration),
+	})
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
@@ -2,10 +2,14 @@ package modules
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
 	"fmt"
 	"math"
+	"math/big"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"syscall/js"
@@ -96,29 +100,31 @@ func feesPaid(txn types.Transaction, ownedInput, unownedInput siatypes.Currency,
 }
 
 func siacoinString(c siatypes.Currency, currency string) string {
-	var precision int32 = -24
+	// the asset is always known so formatting cannot fail
+	str, _ := formatAmount(c, currency, "siacoin")
 
-	if currency == "scp" {
-		precision = -27
-	}
+	return str
+}
 
-	d := decimal.NewFromBigInt(c.Big(), precision)
+//signedSiacoinString formats a signed amount of hastings without losing precision
+func signedSiacoinString(v *big.Int, currency string) string {
+	precision := -int32(getCurrencyParams(currency).Decimals)
 
-	return d.String()
+	return decimal.NewFromBigInt(v, precision).String()
 }
 
 func siafundString(c siatypes.Currency) string {
 	return c.String()
 }
 
-func exportAddressWorker(ownedAddresses map[string]bool, currency string, work <-chan []string, results chan<- apiResults, errors chan<- error) {
+func exportAddressWorker(ctx context.Context, ownedAddresses map[string]bool, currency string, work <-chan []string, results chan<- apiResults, errors chan<- error) {
 	for addresses := range work {
 		for j := 0; j < 1e4; j++ {
 			var transactions []exportTransaction
 
 			apiclient := siacentralAPIClient(currency)
 
-			balanceResp, err := apiclient.FindAddressBalance(2000, j, addresses)
+			balanceResp, err := apiclient.FindAddressBalance(ctx, 2000, j, addresses)
 			if err != nil {
 				errors <- fmt.Errorf("unable to get wallet transactions: %s", err)
 				return
@@ -191,19 +197,13 @@ func exportAddressWorker(ownedAddresses map[string]bool, currency string, work <
 
 //ExportTransactions gets all transactions belonging to the addresses
 func ExportTransactions(addresses []string, currency string, min, max time.Time, callback js.Value) {
-	var currencyLabel, fundLabel string
-
 	var buf []byte
 	var transactions []exportTransaction
 	var matching uint64
 
-	if currency == "scp" {
-		currencyLabel = "SCP"
-		fundLabel = "SCPF"
-	} else {
-		currencyLabel = "SC"
-		fundLabel = "SF"
-	}
+	params := getCurrencyParams(currency)
+	currencyLabel := params.Symbol
+	fundLabel := params.FundSymbol
 
 	ownedAddresses := make(map[string]bool)
 	count := len(addresses)
@@ -212,13 +212,17 @@ func ExportTransactions(addresses []string, currency string, min, max time.Time,
 		ownedAddresses[addr] = true
 	}
 
+	// cancelling the context on return stops any workers still waiting on the API
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	rounds := int(math.Ceil(float64(len(addresses)) / 1000))
 	work := make(chan []string, workers)
 	results := make(chan apiResults)
 	errors := make(chan error, 1)
 
 	for i := 0; i < workers; i++ {
-		go exportAddressWorker(ownedAddresses, currency, work, results, errors)
+		go exportAddressWorker(ctx, ownedAddresses, currency, work, results, errors)
 	}
 
 	go func() {
@@ -339,3 +343,106 @@ func ExportTransactions(addresses []string, currency string, min, max time.Time,
 	cw.Flush()
 	callback.Invoke(js.Null(), string(out.Bytes()))
 }
+
+//historyFee returns the fee paid by the wallet. Fees are only counted when the wallet spent inputs
+//and sent value out, otherwise the fee was paid by another party
+func historyFee(txn processedTransaction) siatypes.Currency {
+	if txn.SiacoinValue.Direction != "sent" {
+		return siatypes.ZeroCurrency
+	}
+
+	for _, input := range txn.SiacoinInputs {
+		if input.Owned {
+			return txn.Fees
+		}
+	}
+
+	return siatypes.ZeroCurrency
+}
+
+//historyCSV encodes the transaction history as CSV, oldest first. The amount is the net siacoin
+//change of each transaction and the balance is the running total of the exported transactions.
+//Amounts are summed as big.Int so no precision is lost
+func historyCSV(transactions []processedTransaction, currency string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	symbol := getCurrencyParams(currency).Symbol
+	sorted := make([]processedTransaction, len(transactions))
+	copy(sorted, transactions)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	cw := csv.NewWriter(&buf)
+	balance := new(big.Int)
+
+	cw.Write([]string{
+		"Date",
+		"Height",
+		"Transaction ID",
+		"Type",
+		fmt.Sprintf("Amount (%s)", symbol),
+		fmt.Sprintf("Fee (%s)", symbol),
+		fmt.Sprintf("Balance (%s)", symbol),
+	})
+
+	for _, txn := range sorted {
+		var height string
+
+		amount := new(big.Int).Set(txn.SiacoinValue.Value.Big())
+
+		if txn.SiacoinValue.Direction == "sent" {
+			amount.Neg(amount)
+		}
+
+		balance.Add(balance, amount)
+
+		// unconfirmed transactions do not have a height yet
+		if txn.Confirmations != 0 {
+			height = strconv.FormatUint(txn.BlockHeight, 10)
+		}
+
+		err := cw.Write([]string{
+			txn.Timestamp.UTC().Format(time.RFC3339),
+			height,
+			txn.TransactionID,
+			strings.Join(txn.Tags, ";"),
+			signedSiacoinString(amount, currency),
+			siacoinString(historyFee(txn), currency),
+			signedSiacoinString(balance, currency),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cw.Flush()
+
+	if err := cw.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+//ExportHistoryCSV exports the transaction history of the addresses as CSV for accounting. The
+//history is the same returned by GetTransactions, only the last 500 transactions of each address
+//are included
+func ExportHistoryCSV(addresses []string, currency string, callback js.Value) {
+	resp, err := loadTransactions(context.Background(), addresses, currency)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	out, err := historyCSV(resp.Transactions, currency)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), string(out))
+}
@@ -0,0 +1,65 @@
+package modules
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+func TestHistoryCSV(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	// the extra hasting is lost if the amount passes through a float64
+	large := siatypes.SiacoinPrecision.Mul64(1e6).Add64(1)
+
+	transactions := []processedTransaction{
+		{
+			TransactionID: "sent",
+			BlockHeight:   110,
+			Confirmations: 5,
+			Fees:          siatypes.SiacoinPrecision,
+			Timestamp:     start.Add(time.Hour),
+			Tags:          []string{"siacoin_transaction"},
+			SiacoinValue:  processedTxnValue{Value: siatypes.SiacoinPrecision.Mul64(11), Direction: "sent"},
+			SiacoinInputs: []processedSiacoinInput{{Owned: true}},
+		},
+		{
+			TransactionID: "received",
+			BlockHeight:   100,
+			Confirmations: 15,
+			Fees:          siatypes.SiacoinPrecision,
+			Timestamp:     start,
+			Tags:          []string{"siacoin_transaction"},
+			SiacoinValue:  processedTxnValue{Value: large, Direction: "received"},
+			SiacoinInputs: []processedSiacoinInput{{Owned: false}},
+		},
+		{
+			TransactionID: "pending",
+			Timestamp:     start.Add(2 * time.Hour),
+			Tags:          []string{"siacoin_transaction", "defrag"},
+			SiacoinValue:  processedTxnValue{Value: siatypes.ZeroCurrency, Direction: "received"},
+		},
+	}
+
+	out, err := historyCSV(transactions, "sc")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Join([]string{
+		"Date,Height,Transaction ID,Type,Amount (SC),Fee (SC),Balance (SC)",
+		"2021-01-01T00:00:00Z,100,received,siacoin_transaction,1000000.000000000000000000000001,0,1000000.000000000000000000000001",
+		"2021-01-01T01:00:00Z,110,sent,siacoin_transaction,-11,1,999989.000000000000000000000001",
+		"2021-01-01T02:00:00Z,,pending,siacoin_transaction;defrag,0,0,999989.000000000000000000000001",
+	}, "\n") + "\n"
+
+	if string(out) != expected {
+		t.Fatalf("unexpected csv:\n%s\nexpected:\n%s", out, expected)
+	}
+
+	if transactions[0].TransactionID != "sent" {
+		t.Fatal("expected the input order to be unchanged")
+	}
+}
@@ -0,0 +1,396 @@
+package modules
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
+	siacrypto "gitlab.com/NebulousLabs/Sia/crypto"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+type (
+	//signatureRequest the sig hash of one of a transaction's signatures sent to an external signer,
+	//like a hardware wallet, with the public key expected to sign it
+	signatureRequest struct {
+		Index     int    `json:"index"`
+		ParentID  string `json:"parent_id"`
+		PublicKey string `json:"public_key"`
+		SigHash   string `json:"sig_hash"`
+	}
+
+	//externalSigner returns the signature of the request's sig hash
+	externalSigner func(signatureRequest) ([]byte, error)
+
+	//inputKey a public key of an input's unlock conditions the wallet derives at Index
+	inputKey struct {
+		PublicKeyIndex uint64 `json:"public_key_index"`
+		PublicKey      string `json:"public_key"`
+		Index          uint64 `json:"index"`
+	}
+
+	//inputKeys the wallet's keys in the unlock conditions of one of a transaction's inputs. Signable
+	//is set if the wallet controls enough of the keys to sign the input alone
+	inputKeys struct {
+		Input              int        `json:"input"`
+		Type               string     `json:"type"`
+		ParentID           string     `json:"parent_id"`
+		SignaturesRequired uint64     `json:"signatures_required"`
+		Keys               []inputKey `json:"keys"`
+		Signable           bool       `json:"signable"`
+	}
+
+	requiredKeysResp struct {
+		Inputs  []inputKeys `json:"inputs"`
+		Indices []uint64    `json:"indices"`
+	}
+
+	//rawSignature an ed25519 signature of a sig hash by the key at Index, for a transaction built
+	//outside of the wallet
+	rawSignature struct {
+		Index      uint64 `json:"index"`
+		PublicKey  string `json:"public_key"`
+		UnlockHash string `json:"unlock_hash"`
+		SigHash    string `json:"sig_hash"`
+		Signature  string `json:"signature"`
+	}
+
+	promiseResult struct {
+		Value js.Value
+		Err   error
+	}
+)
+
+//unlockConditionsKey returns the public key at the index of the unlock conditions
+func unlockConditionsKey(uc siatypes.UnlockConditions, index uint64) (siatypes.SiaPublicKey, error) {
+	if index >= uint64(len(uc.PublicKeys)) {
+		return siatypes.SiaPublicKey{}, fmt.Errorf("public key index %d out of range", index)
+	}
+
+	return uc.PublicKeys[index], nil
+}
+
+//signerPublicKey returns the public key that must sign the transaction signature, from the siacoin
+//or siafund input it signs
+func signerPublicKey(txn siatypes.Transaction, sig siatypes.TransactionSignature) (siatypes.SiaPublicKey, error) {
+	for _, input := range txn.SiacoinInputs {
+		if siacrypto.Hash(input.ParentID) == sig.ParentID {
+			return unlockConditionsKey(input.UnlockConditions, sig.PublicKeyIndex)
+		}
+	}
+
+	for _, input := range txn.SiafundInputs {
+		if siacrypto.Hash(input.ParentID) == sig.ParentID {
+			return unlockConditionsKey(input.UnlockConditions, sig.PublicKeyIndex)
+		}
+	}
+
+	return siatypes.SiaPublicKey{}, errors.New("signature does not match a siacoin or siafund input")
+}
+
+//signExternal signs each of the transaction's signatures with the external signer instead of a
+//key derived from the seed. The sig hashes are computed here so the signer only needs to sign the
+//hash it is sent. Each returned signature is verified before it is added to the transaction. If
+//coverage is not nil it replaces the covered fields of every signature
+func signExternal(txn *siatypes.Transaction, coverage *siatypes.CoveredFields, height siatypes.BlockHeight, sign externalSigner) error {
+	if coverage != nil {
+		for i := range txn.TransactionSignatures {
+			txn.TransactionSignatures[i].CoveredFields = *coverage
+		}
+	}
+
+	// sigHashes only checks the consensus rules, signing also requires every input to be covered
+	for i, sig := range txn.TransactionSignatures {
+		if err := wallet.ValidCoverage(txn, sig.CoveredFields); err != nil {
+			return fmt.Errorf("signature %d: %w", i, err)
+		}
+	}
+
+	hashes, err := sigHashes(*txn, nil, height)
+
+	if err != nil {
+		return err
+	}
+
+	for i, sig := range txn.TransactionSignatures {
+		pk, err := signerPublicKey(*txn, sig)
+
+		if err != nil {
+			return fmt.Errorf("signature %d: %w", i, err)
+		}
+
+		if pk.Algorithm != siatypes.SignatureEd25519 || len(pk.Key) != siacrypto.PublicKeySize {
+			return fmt.Errorf("signature %d: only ed25519 keys can be signed externally", i)
+		}
+
+		encoded, err := sign(signatureRequest{
+			Index:     i,
+			ParentID:  sig.ParentID.String(),
+			PublicKey: pk.String(),
+			SigHash:   hashes[i].String(),
+		})
+
+		if err != nil {
+			return fmt.Errorf("signature %d: unable to sign: %w", i, err)
+		}
+
+		var edPK siacrypto.PublicKey
+		var edSig siacrypto.Signature
+
+		if len(encoded) != len(edSig) {
+			return fmt.Errorf("signature %d: expected %d bytes, got %d", i, len(edSig), len(encoded))
+		}
+
+		copy(edPK[:], pk.Key)
+		copy(edSig[:], encoded)
+
+		// a device signing the wrong hash or with the wrong key would create an invalid transaction
+		if err := siacrypto.VerifyHash(hashes[i], edPK, edSig); err != nil {
+			return fmt.Errorf("signature %d is not valid: %w", i, err)
+		}
+
+		txn.TransactionSignatures[i].Signature = encoded
+	}
+
+	return nil
+}
+
+//awaitPromise blocks until the promise settles. Must not be called from the JS event loop, the
+//promise cannot settle while it is blocked
+func awaitPromise(promise js.Value) (js.Value, error) {
+	// buffered so the handlers never block the event loop
+	done := make(chan promiseResult, 1)
+
+	onResolve := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		done <- promiseResult{Value: args[0]}
+		return nil
+	})
+	defer onResolve.Release()
+
+	onReject := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		done <- promiseResult{Err: errors.New(args[0].Call("toString").String())}
+		return nil
+	})
+	defer onReject.Release()
+
+	// resolving normalizes signers that return the signature instead of a promise
+	js.Global().Get("Promise").Call("resolve", promise).Call("then", onResolve, onReject)
+
+	res := <-done
+
+	return res.Value, res.Err
+}
+
+//jsSigner wraps a JS function as an external signer. The function is called with the request and
+//returns the hex encoded signature or a promise resolving to it
+func jsSigner(signer js.Value) externalSigner {
+	return func(req signatureRequest) ([]byte, error) {
+		data, err := interfaceToJSON(req)
+
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := awaitPromise(signer.Invoke(data))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if value.Type() != js.TypeString {
+			return nil, errors.New("signer must return a hex encoded signature")
+		}
+
+		return hex.DecodeString(value.String())
+	}
+}
+
+//SignTransactionExternal signs the transaction by calling signer with the sig hash of each
+//signature, so the seed never enters WASM. Used for hardware wallets, the signer passes the hash to
+//the device and returns its signature. If coverage is not nil the signatures only commit to the
+//covered fields, see SeedWallet.SignTransactionCoverage for when that is safe
+func SignTransactionExternal(txn siatypes.Transaction, coverage *siatypes.CoveredFields, currency string, signer js.Value, callback js.Value) {
+	if err := signExternal(&txn, coverage, wallet.ASICHardForkHeight(currency), jsSigner(signer)); err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(txn)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//requiredKeys finds the wallet's keys in the unlock conditions of each of the transaction's siacoin
+//and siafund inputs by deriving every key up to and including maxIndex. A maxIndex above
+//maxDerivedIndex is an error. Keys are matched by public key instead of address so the wallet's
+//keys in a multisig input are found. Derivation stops as soon as every ed25519 key of the inputs
+//is found
+func requiredKeys(txn siatypes.Transaction, w *wallet.SeedWallet, maxIndex uint64) (resp requiredKeysResp, err error) {
+	if err = checkDerivedIndex(maxIndex); err != nil {
+		return
+	}
+
+	resp.Inputs = []inputKeys{}
+	resp.Indices = []uint64{}
+
+	for i, input := range txn.SiacoinInputs {
+		resp.Inputs = append(resp.Inputs, inputKeys{
+			Input:              i,
+			Type:               "siacoin",
+			ParentID:           input.ParentID.String(),
+			SignaturesRequired: input.UnlockConditions.SignaturesRequired,
+		})
+	}
+
+	for i, input := range txn.SiafundInputs {
+		resp.Inputs = append(resp.Inputs, inputKeys{
+			Input:              i,
+			Type:               "siafund",
+			ParentID:           input.ParentID.String(),
+			SignaturesRequired: input.UnlockConditions.SignaturesRequired,
+		})
+	}
+
+	conditions := make([]siatypes.UnlockConditions, 0, len(resp.Inputs))
+
+	for _, input := range txn.SiacoinInputs {
+		conditions = append(conditions, input.UnlockConditions)
+	}
+
+	for _, input := range txn.SiafundInputs {
+		conditions = append(conditions, input.UnlockConditions)
+	}
+
+	wanted := make(map[string]bool)
+
+	for _, uc := range conditions {
+		for _, pk := range uc.PublicKeys {
+			if pk.Algorithm == siatypes.SignatureEd25519 {
+				wanted[pk.String()] = true
+			}
+		}
+	}
+
+	found := make(map[string]uint64)
+	remaining := len(wanted)
+
+	for index := uint64(0); remaining > 0 && index <= maxIndex; index++ {
+		key := w.GetAddress(index).UnlockConditions.PublicKeys[0].String()
+
+		if wanted[key] {
+			found[key] = index
+			delete(wanted, key)
+			remaining--
+			resp.Indices = append(resp.Indices, index)
+		}
+	}
+
+	for i, uc := range conditions {
+		input := &resp.Inputs[i]
+		input.Keys = []inputKey{}
+
+		for j, pk := range uc.PublicKeys {
+			index, exists := found[pk.String()]
+
+			if !exists {
+				continue
+			}
+
+			input.Keys = append(input.Keys, inputKey{
+				PublicKeyIndex: uint64(j),
+				PublicKey:      pk.String(),
+				Index:          index,
+			})
+		}
+
+		input.Signable = uint64(len(input.Keys)) >= uc.SignaturesRequired
+	}
+
+	return
+}
+
+//RequiredKeys returns which of the wallet's keys, up to index maxIndex, are needed to sign each
+//input of the transaction. Inputs the wallet cannot sign alone, like a multisig input missing
+//cosigners or an input of another wallet, are returned with signable unset
+func RequiredKeys(txn siatypes.Transaction, seed, currency string, maxIndex, accountOffset uint64, callback js.Value) {
+	w, err := recoverWallet(seed, currency, accountOffset)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	resp, err := requiredKeys(txn, w, maxIndex)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(resp)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//signSighash signs the hex encoded sig hash with the wallet's key at the index
+func signSighash(w *wallet.SeedWallet, index uint64, sighashHex string) (sig rawSignature, err error) {
+	var h siacrypto.Hash
+
+	if err = h.LoadString(sighashHex); err != nil {
+		err = fmt.Errorf("unable to parse sig hash: %w", err)
+		return
+	}
+
+	key := w.GetAddress(index)
+	signature := w.SignHash(index, h)
+
+	sig = rawSignature{
+		Index:      index,
+		PublicKey:  key.UnlockConditions.PublicKeys[0].String(),
+		UnlockHash: key.UnlockConditions.UnlockHash().String(),
+		SigHash:    h.String(),
+		Signature:  hex.EncodeToString(signature[:]),
+	}
+
+	return
+}
+
+//SignSighash signs a sig hash computed elsewhere with the key at the index and returns the raw
+//signature and the key's public key. The wallet never sees the transaction, so the caller is
+//responsible for the sig hash being the one the user agreed to sign
+func SignSighash(phrase, currency string, index uint64, sighashHex string, accountOffset uint64, callback js.Value) {
+	w, err := recoverWallet(phrase, currency, accountOffset)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	sig, err := signSighash(w, index, sighashHex)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(sig)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
@@ -0,0 +1,282 @@
+package modules
+
+import (
+	"encoding/hex"
+	"strings"
+	"syscall/js"
+	"testing"
+
+	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
+	siacrypto "gitlab.com/NebulousLabs/Sia/crypto"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+//copySignatures returns the transaction with its own copy of the signatures so signing does not
+//modify the original
+func copySignatures(txn siatypes.Transaction) siatypes.Transaction {
+	sigs := make([]siatypes.TransactionSignature, len(txn.TransactionSignatures))
+	copy(sigs, txn.TransactionSignatures)
+	txn.TransactionSignatures = sigs
+
+	return txn
+}
+
+func TestSignExternal(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := testOutputs(t, 10, 20)
+	recipient, err := parseAddress(outputs[0].UnlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unsigned, err := buildTransaction(outputs, []siatypes.SiacoinOutput{
+		{Value: sumOutputs(outputs), UnlockHash: recipient},
+	}, siatypes.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the device only knows its keys, it never sees the seed
+	device := make(map[string]siacrypto.SecretKey)
+	for _, index := range unsigned.RequiredSigs {
+		key := w.GetAddress(index)
+		device[key.UnlockConditions.PublicKeys[0].String()] = key.SecretKeys[0]
+	}
+
+	sign := func(req signatureRequest) ([]byte, error) {
+		var h siacrypto.Hash
+
+		if err := h.LoadString(req.SigHash); err != nil {
+			return nil, err
+		}
+
+		sig := siacrypto.SignHash(h, device[req.PublicKey])
+		return sig[:], nil
+	}
+
+	height := wallet.ASICHardForkHeight("sc")
+
+	expected := copySignatures(unsigned.Transaction)
+	if err := w.SignTransaction(&expected, unsigned.RequiredSigs); err != nil {
+		t.Fatal(err)
+	}
+
+	txn := copySignatures(unsigned.Transaction)
+	if err := signExternal(&txn, nil, height, sign); err != nil {
+		t.Fatal(err)
+	}
+
+	// ed25519 signatures are deterministic so the device must match the seed
+	for i, sig := range txn.TransactionSignatures {
+		if hex.EncodeToString(sig.Signature) != hex.EncodeToString(expected.TransactionSignatures[i].Signature) {
+			t.Fatalf("signature %d does not match the seed's signature", i)
+		}
+	}
+
+	// a device signing with the wrong key must not create an invalid transaction
+	wrongKey := func(req signatureRequest) ([]byte, error) {
+		var h siacrypto.Hash
+
+		if err := h.LoadString(req.SigHash); err != nil {
+			return nil, err
+		}
+
+		sig := siacrypto.SignHash(h, w.GetAddress(1000).SecretKeys[0])
+		return sig[:], nil
+	}
+
+	txn = copySignatures(unsigned.Transaction)
+	if err := signExternal(&txn, nil, height, wrongKey); err == nil || !strings.Contains(err.Error(), "is not valid") {
+		t.Fatalf("expected invalid signature error, got %v", err)
+	} else if len(txn.TransactionSignatures[0].Signature) != 0 {
+		t.Fatal("expected the invalid signature to not be added")
+	}
+
+	txn = copySignatures(unsigned.Transaction)
+	short := func(req signatureRequest) ([]byte, error) { return make([]byte, 32), nil }
+	if err := signExternal(&txn, nil, height, short); err == nil {
+		t.Fatal("expected short signature to fail")
+	}
+}
+
+func TestSignerPublicKey(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	siacoinKey, siafundKey := w.GetAddress(1), w.GetAddress(2)
+	txn := siatypes.Transaction{
+		SiacoinInputs: []siatypes.SiacoinInput{{ParentID: siatypes.SiacoinOutputID{1}, UnlockConditions: siacoinKey.UnlockConditions}},
+		SiafundInputs: []siatypes.SiafundInput{{ParentID: siatypes.SiafundOutputID{2}, UnlockConditions: siafundKey.UnlockConditions}},
+	}
+
+	pk, err := signerPublicKey(txn, siatypes.TransactionSignature{ParentID: siacrypto.Hash{1}})
+	if err != nil {
+		t.Fatal(err)
+	} else if !pk.Equals(siacoinKey.UnlockConditions.PublicKeys[0]) {
+		t.Fatal("expected the siacoin input's key")
+	}
+
+	pk, err = signerPublicKey(txn, siatypes.TransactionSignature{ParentID: siacrypto.Hash{2}})
+	if err != nil {
+		t.Fatal(err)
+	} else if !pk.Equals(siafundKey.UnlockConditions.PublicKeys[0]) {
+		t.Fatal("expected the siafund input's key")
+	}
+
+	if _, err := signerPublicKey(txn, siatypes.TransactionSignature{ParentID: siacrypto.Hash{2}, PublicKeyIndex: 1}); err == nil || !strings.Contains(err.Error(), "out of range") {
+		t.Fatalf("expected out of range error, got %v", err)
+	}
+
+	if _, err := signerPublicKey(txn, siatypes.TransactionSignature{ParentID: siacrypto.Hash{3}}); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("expected unmatched signature error, got %v", err)
+	}
+}
+
+func TestJSSigner(t *testing.T) {
+	var received js.Value
+
+	sig := strings.Repeat("ab", 64)
+	resolves := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		received = args[0]
+		return sig
+	})
+	defer resolves.Release()
+
+	buf, err := jsSigner(resolves.Value)(signatureRequest{Index: 1, SigHash: "hash"})
+	if err != nil {
+		t.Fatal(err)
+	} else if hex.EncodeToString(buf) != sig {
+		t.Fatalf("expected signature %s, got %x", sig, buf)
+	} else if received.Get("index").Int() != 1 || received.Get("sig_hash").String() != "hash" {
+		t.Fatal("expected the request to be passed to the signer")
+	}
+
+	rejects := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		return js.Global().Get("Promise").Call("reject", js.Global().Get("Error").New("rejected on device"))
+	})
+	defer rejects.Release()
+
+	if _, err := jsSigner(rejects.Value)(signatureRequest{}); err == nil || !strings.Contains(err.Error(), "rejected on device") {
+		t.Fatalf("expected rejected error, got %v", err)
+	}
+}
+
+func TestRequiredKeys(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	phrase, err := wallet.NewBIP39RecoveryPhrase()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := wallet.RecoverBIP39Seed(phrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ours := w.GetAddress(3).UnlockConditions
+	theirs := other.GetAddress(0).UnlockConditions
+	// a 2-of-2 with one of the wallet's keys and one of the cosigner's
+	multisig := siatypes.UnlockConditions{
+		PublicKeys:         []siatypes.SiaPublicKey{theirs.PublicKeys[0], w.GetAddress(7).UnlockConditions.PublicKeys[0]},
+		SignaturesRequired: 2,
+	}
+
+	txn := siatypes.Transaction{
+		SiacoinInputs: []siatypes.SiacoinInput{
+			{ParentID: siatypes.SiacoinOutputID{1}, UnlockConditions: ours},
+			{ParentID: siatypes.SiacoinOutputID{2}, UnlockConditions: multisig},
+			{ParentID: siatypes.SiacoinOutputID{3}, UnlockConditions: theirs},
+		},
+		SiafundInputs: []siatypes.SiafundInput{
+			{ParentID: siatypes.SiafundOutputID{4}, UnlockConditions: w.GetAddress(12).UnlockConditions},
+		},
+	}
+
+	resp, err := requiredKeys(txn, w, 20)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(resp.Inputs) != 4 {
+		t.Fatalf("expected 4 inputs, got %d", len(resp.Inputs))
+	} else if len(resp.Indices) != 3 || resp.Indices[0] != 3 || resp.Indices[1] != 7 || resp.Indices[2] != 12 {
+		t.Fatalf("expected indices [3 7 12], got %v", resp.Indices)
+	}
+
+	if input := resp.Inputs[0]; !input.Signable || len(input.Keys) != 1 || input.Keys[0].Index != 3 {
+		t.Fatalf("expected the wallet's input to be signable with key 3, got %+v", input)
+	}
+
+	// the wallet holds the second key of the multisig but cannot sign alone
+	if input := resp.Inputs[1]; input.Signable || len(input.Keys) != 1 || input.Keys[0].Index != 7 || input.Keys[0].PublicKeyIndex != 1 {
+		t.Fatalf("expected the multisig input to need a cosigner, got %+v", input)
+	}
+
+	if input := resp.Inputs[2]; input.Signable || len(input.Keys) != 0 {
+		t.Fatalf("expected another wallet's input to not be signable, got %+v", input)
+	}
+
+	if input := resp.Inputs[3]; input.Type != "siafund" || !input.Signable || input.Keys[0].Index != 12 {
+		t.Fatalf("expected the siafund input to be signable with key 12, got %+v", input)
+	}
+
+	// keys past the max index are not found
+	if resp, err := requiredKeys(txn, w, 10); err != nil || resp.Inputs[3].Signable || len(resp.Indices) != 2 {
+		t.Fatalf("expected key 12 to be past the max index, got %v %v", resp.Indices, err)
+	}
+
+	// a max index past the derived index limit is refused instead of silently capped
+	if _, err := requiredKeys(txn, w, maxDerivedIndex+1); err == nil || !strings.Contains(err.Error(), "above the maximum") {
+		t.Fatalf("expected the max index to be refused, got %v", err)
+	}
+}
+
+func TestSignSighash(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := siacrypto.HashBytes([]byte("external transaction"))
+	sig, err := signSighash(w, 5, h.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := w.GetAddress(5)
+	if sig.PublicKey != key.UnlockConditions.PublicKeys[0].String() || sig.UnlockHash != key.UnlockConditions.UnlockHash().String() {
+		t.Fatalf("expected the key at index 5, got %s", sig.PublicKey)
+	}
+
+	var pk siacrypto.PublicKey
+	var signature siacrypto.Signature
+
+	buf, err := hex.DecodeString(sig.Signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	copy(pk[:], key.UnlockConditions.PublicKeys[0].Key)
+	copy(signature[:], buf)
+
+	if err := siacrypto.VerifyHash(h, pk, signature); err != nil {
+		t.Fatalf("expected the signature to verify, got %s", err)
+	}
+
+	// the signature must match the one the wallet adds when signing a transaction
+	if expected := siacrypto.SignHash(h, key.SecretKeys[0]); hex.EncodeToString(expected[:]) != sig.Signature {
+		t.Fatal("expected the signature to match the transaction signature")
+	}
+
+	if _, err := signSighash(w, 5, "not a hash"); err == nil {
+		t.Fatal("expected an invalid sig hash to fail")
+	}
+}
@@ -0,0 +1,83 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"syscall/js"
+
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+const (
+	//slowFeeTarget the confirmation target in blocks the minimum fee of the market is expected to
+	//confirm within. Sia blocks are mined about every 10 minutes
+	slowFeeTarget = 6
+)
+
+type (
+	//feeMarket the current fee per byte range of the network
+	feeMarket struct {
+		Minimum siatypes.Currency `json:"minimum"`
+		Maximum siatypes.Currency `json:"maximum"`
+	}
+
+	//feeEstimate the fee per byte expected to confirm a transaction within the target number of
+	//blocks
+	feeEstimate struct {
+		TargetBlocks uint64            `json:"target_blocks"`
+		FeePerByte   siatypes.Currency `json:"fee_per_byte"`
+		Market       feeMarket         `json:"market"`
+	}
+)
+
+//currentFeeMarket returns the current fee per byte range of the network from the API
+func currentFeeMarket(ctx context.Context, currency string) (market feeMarket, err error) {
+	market.Minimum, market.Maximum, err = siacentralAPIClient(currency).GetTransactionFees(ctx)
+
+	return
+}
+
+//feeForTarget returns the fee per byte for a transaction to confirm within targetBlocks. The API
+//only reports the range of the market, the maximum is used for the next block and the minimum
+//for the slow target or later. Targets in between are interpolated linearly
+func feeForTarget(market feeMarket, targetBlocks uint64) siatypes.Currency {
+	if market.Maximum.Cmp(market.Minimum) <= 0 || targetBlocks <= 1 {
+		return market.Maximum
+	} else if targetBlocks >= slowFeeTarget {
+		return market.Minimum
+	}
+
+	spread := market.Maximum.Sub(market.Minimum)
+	step := spread.Mul64(targetBlocks - 1).Div64(slowFeeTarget - 1)
+
+	return market.Maximum.Sub(step)
+}
+
+//EstimateFeeForTarget returns the fee per byte likely to confirm a transaction within targetBlocks
+//blocks, so the UI can offer fast, normal, and slow fees mapped to block targets
+func EstimateFeeForTarget(currency string, targetBlocks uint64, callback js.Value) {
+	if targetBlocks == 0 {
+		callback.Invoke("target must be at least 1 block", js.Null())
+		return
+	}
+
+	market, err := currentFeeMarket(context.Background(), currency)
+
+	if err != nil {
+		callback.Invoke(fmt.Sprintf("unable to get transaction fees: %s", err), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(feeEstimate{
+		TargetBlocks: targetBlocks,
+		FeePerByte:   feeForTarget(market, targetBlocks),
+		Market:       market,
+	})
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
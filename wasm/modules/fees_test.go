@@ -0,0 +1,38 @@
+package modules
+
+import (
+	"testing"
+
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+func TestFeeForTarget(t *testing.T) {
+	market := feeMarket{
+		Minimum: siatypes.NewCurrency64(100),
+		Maximum: siatypes.NewCurrency64(600),
+	}
+
+	tests := []struct {
+		target uint64
+		fee    uint64
+	}{
+		{0, 600},
+		{1, 600},
+		{2, 500},
+		{4, 300},
+		{slowFeeTarget, 100},
+		{144, 100},
+	}
+
+	for _, test := range tests {
+		if fee := feeForTarget(market, test.target); fee.Cmp64(test.fee) != 0 {
+			t.Errorf("expected fee %d for target %d, got %s", test.fee, test.target, fee)
+		}
+	}
+
+	// a market without a spread has one fee for every target
+	flat := feeMarket{Minimum: siatypes.NewCurrency64(100), Maximum: siatypes.NewCurrency64(100)}
+	if fee := feeForTarget(flat, 3); fee.Cmp64(100) != 0 {
+		t.Errorf("expected fee 100 for a flat market, got %s", fee)
+	}
+}
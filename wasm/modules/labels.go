@@ -0,0 +1,176 @@
+package modules
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"syscall/js"
+
+	siacrypto "gitlab.com/NebulousLabs/Sia/crypto"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+const (
+	//labelNonceSize the nonce size of the secretbox the frontend encrypts labels with
+	labelNonceSize = 24
+)
+
+type (
+	//labelStore an in-memory mapping of addresses to user defined labels. The store is only
+	//populated when labels are set or imported, flows that never touch labels carry no state
+	labelStore struct {
+		mu     sync.RWMutex
+		labels map[string]string
+	}
+)
+
+var (
+	addressLabels = &labelStore{
+		labels: make(map[string]string),
+	}
+
+	labelNonceSpecifier = siatypes.NewSpecifier("label nonce")
+)
+
+//Set sets the label for an address. An empty label removes the address from the store
+func (s *labelStore) Set(address, label string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	label = strings.TrimSpace(label)
+
+	if len(label) == 0 {
+		delete(s.labels, address)
+		return
+	}
+
+	s.labels[address] = label
+}
+
+//Get returns the label of an address or an empty string if the address has no label
+func (s *labelStore) Get(address string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.labels[address]
+}
+
+//Len returns the number of labeled addresses
+func (s *labelStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.labels)
+}
+
+//Lookup returns the labels of any of the addresses that have one
+func (s *labelStore) Lookup(addresses []string) map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	found := make(map[string]string)
+
+	for _, addr := range addresses {
+		if label, exists := s.labels[addr]; exists {
+			found[addr] = label
+		}
+	}
+
+	return found
+}
+
+//MarshalJSON implements json.Marshaler
+func (s *labelStore) MarshalJSON() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return json.Marshal(s.labels)
+}
+
+//UnmarshalJSON implements json.Unmarshaler, merging the labels into the store
+func (s *labelStore) UnmarshalJSON(buf []byte) error {
+	var labels map[string]string
+
+	if err := json.Unmarshal(buf, &labels); err != nil {
+		return err
+	}
+
+	for addr, label := range labels {
+		s.Set(addr, label)
+	}
+
+	return nil
+}
+
+//SetAddressLabel sets the label of an address, an empty label removes it
+func SetAddressLabel(address, label string) {
+	addressLabels.Set(address, label)
+}
+
+//GetAddressLabels returns the labels of the addresses that have one
+func GetAddressLabels(addresses []string, callback js.Value) {
+	labels := make(map[string]interface{})
+
+	for addr, label := range addressLabels.Lookup(addresses) {
+		labels[addr] = label
+	}
+
+	callback.Invoke(js.Null(), labels)
+}
+
+//ExportAddressLabels serializes all labels in the store to JSON
+func ExportAddressLabels(callback js.Value) {
+	buf, err := json.Marshal(addressLabels)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), string(buf))
+}
+
+//ImportAddressLabels merges previously exported labels into the store
+func ImportAddressLabels(labelsJSON string, callback js.Value) {
+	if err := json.Unmarshal([]byte(labelsJSON), addressLabels); err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), addressLabels.Len())
+}
+
+//labelNonce derives the nonce for encrypting a version of the label of the address with the label
+//key. The address and version are hashed with the key so every address, and every edit of its
+//label, has a different nonce under the same key
+func labelNonce(key [32]byte, address siatypes.UnlockHash, version uint64) (nonce [labelNonceSize]byte) {
+	h := siacrypto.HashAll(labelNonceSpecifier, key, address, version)
+	copy(nonce[:], h[:])
+
+	return
+}
+
+//DeriveLabelNonce returns the hex encoded nonce for encrypting the version of the address's label
+//with the wallet's label key, so only the version needs to be stored with the label. A secretbox
+//nonce must never encrypt two different labels, the version must be incremented every time the
+//label changes
+func DeriveLabelNonce(phrase, currency, address string, version uint64, callback js.Value) {
+	w, err := recoverWallet(phrase, currency, 0)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	uh, err := parseAddress(address)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	nonce := labelNonce(w.LabelKey(), uh, version)
+
+	callback.Invoke(js.Null(), hex.EncodeToString(nonce[:]))
+}
@@ -0,0 +1,31 @@
+package modules
+
+import "testing"
+
+func TestLabelNonce(t *testing.T) {
+	w, err := recoverWallet(testPhrase, "sc", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := w.LabelKey()
+	first := w.GetAddress(0).UnlockConditions.UnlockHash()
+	second := w.GetAddress(1).UnlockConditions.UnlockHash()
+
+	if labelNonce(key, first, 0) != labelNonce(key, first, 0) {
+		t.Fatal("expected the nonce to be deterministic")
+	} else if labelNonce(key, first, 0) == labelNonce(key, second, 0) {
+		t.Fatal("expected each address to have a different nonce")
+	} else if labelNonce(key, first, 0) == labelNonce(key, first, 1) {
+		t.Fatal("expected each version of a label to have a different nonce")
+	}
+
+	scp, err := recoverWallet(testPhrase, "scp", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if labelNonce(key, first, 0) == labelNonce(scp.LabelKey(), first, 0) {
+		t.Fatal("expected the nonce to depend on the label key")
+	}
+}
@@ -0,0 +1,134 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"syscall/js"
+
+	apitypes "github.com/siacentral/apisdkgo/types"
+)
+
+const (
+	//outputPageSize the number of transactions requested per page when collecting outputs
+	outputPageSize = 2000
+)
+
+type (
+	//outputsInRange the owned outputs created within a range of block heights
+	outputsInRange struct {
+		SiacoinOutputs []apitypes.SiacoinOutput `json:"siacoin_outputs"`
+		SiafundOutputs []apitypes.SiafundOutput `json:"siafund_outputs"`
+
+		seen map[string]bool
+	}
+)
+
+func newOutputsInRange() *outputsInRange {
+	return &outputsInRange{
+		SiacoinOutputs: []apitypes.SiacoinOutput{},
+		SiafundOutputs: []apitypes.SiafundOutput{},
+		seen:           make(map[string]bool),
+	}
+}
+
+//Add adds the owned outputs of the transactions confirmed between from and to inclusive. Outputs
+//are only added once even if they are returned for more than one batch of addresses
+func (o *outputsInRange) Add(txns []apitypes.Transaction, owned map[string]bool, from, to uint64) {
+	for _, txn := range txns {
+		if txn.Confirmations == 0 || txn.BlockHeight < from || txn.BlockHeight > to {
+			continue
+		}
+
+		for _, output := range txn.SiacoinOutputs {
+			if !owned[output.UnlockHash] || o.seen[output.OutputID] {
+				continue
+			}
+
+			if output.BlockHeight == 0 {
+				output.BlockHeight = txn.BlockHeight
+			}
+
+			o.seen[output.OutputID] = true
+			o.SiacoinOutputs = append(o.SiacoinOutputs, output)
+		}
+
+		for _, output := range txn.SiafundOutputs {
+			if !owned[output.UnlockHash] || o.seen[output.OutputID] {
+				continue
+			}
+
+			if output.BlockHeight == 0 {
+				output.BlockHeight = txn.BlockHeight
+			}
+
+			o.seen[output.OutputID] = true
+			o.SiafundOutputs = append(o.SiafundOutputs, output)
+		}
+	}
+}
+
+//Sort sorts the outputs from oldest to newest
+func (o *outputsInRange) Sort() {
+	sort.SliceStable(o.SiacoinOutputs, func(i, j int) bool {
+		return o.SiacoinOutputs[i].BlockHeight < o.SiacoinOutputs[j].BlockHeight
+	})
+
+	sort.SliceStable(o.SiafundOutputs, func(i, j int) bool {
+		return o.SiafundOutputs[i].BlockHeight < o.SiafundOutputs[j].BlockHeight
+	})
+}
+
+//GetOutputsInRange returns the outputs belonging to the addresses that were created between the
+//from and to block heights inclusive. Used for accounting exports of a specific period
+func GetOutputsInRange(addresses []string, currency string, fromHeight, toHeight uint64, callback js.Value) {
+	ctx := context.Background()
+	owned := make(map[string]bool)
+	results := newOutputsInRange()
+	count := len(addresses)
+
+	if fromHeight > toHeight {
+		callback.Invoke(fmt.Sprintf("invalid height range %d-%d", fromHeight, toHeight), js.Null())
+		return
+	}
+
+	for _, addr := range addresses {
+		owned[addr] = true
+	}
+
+	for i := 0; i < count; i += 1e3 {
+		end := i + 1e3
+
+		if end > count {
+			end = count
+		}
+
+		apiclient := siacentralAPIClient(currency)
+
+		for page := 0; page < 1e4; page++ {
+			resp, err := apiclient.FindAddressBalance(ctx, outputPageSize, page, addresses[i:end])
+
+			if err != nil {
+				callback.Invoke(fmt.Errorf("unable to get wallet transactions: %w", err).Error(), js.Null())
+				return
+			}
+
+			results.Add(resp.Transactions, owned, fromHeight, toHeight)
+
+			if len(resp.Transactions) < outputPageSize {
+				break
+			}
+		}
+	}
+
+	results.Sort()
+
+	data, err := interfaceToJSON(results)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
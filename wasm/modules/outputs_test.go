@@ -0,0 +1,33 @@
+package modules
+
+import (
+	"testing"
+
+	apitypes "github.com/siacentral/apisdkgo/types"
+)
+
+func TestOutputsInRange(t *testing.T) {
+	owned := map[string]bool{"a": true, "b": true}
+	txns := []apitypes.Transaction{
+		{BlockHeight: 50, Confirmations: 100, SiacoinOutputs: []apitypes.SiacoinOutput{{OutputID: "early", UnlockHash: "a"}}},
+		{BlockHeight: 120, Confirmations: 30, SiacoinOutputs: []apitypes.SiacoinOutput{{OutputID: "2", UnlockHash: "b"}, {OutputID: "external", UnlockHash: "c"}}},
+		{BlockHeight: 100, Confirmations: 50, SiacoinOutputs: []apitypes.SiacoinOutput{{OutputID: "1", UnlockHash: "a"}}, SiafundOutputs: []apitypes.SiafundOutput{{OutputID: "sf", UnlockHash: "b"}}},
+		{BlockHeight: 0, Confirmations: 0, SiacoinOutputs: []apitypes.SiacoinOutput{{OutputID: "unconfirmed", UnlockHash: "a"}}},
+	}
+
+	results := newOutputsInRange()
+	results.Add(txns, owned, 100, 150)
+	// a second batch of addresses returns the same transactions
+	results.Add(txns, owned, 100, 150)
+	results.Sort()
+
+	if len(results.SiacoinOutputs) != 2 {
+		t.Fatalf("expected 2 siacoin outputs, got %v", results.SiacoinOutputs)
+	} else if results.SiacoinOutputs[0].OutputID != "1" || results.SiacoinOutputs[0].BlockHeight != 100 {
+		t.Fatalf("expected output 1 at height 100 first, got %v", results.SiacoinOutputs[0])
+	} else if results.SiacoinOutputs[1].OutputID != "2" {
+		t.Fatalf("expected output 2 second, got %v", results.SiacoinOutputs[1])
+	} else if len(results.SiafundOutputs) != 1 || results.SiafundOutputs[0].OutputID != "sf" {
+		t.Fatalf("expected 1 siafund output, got %v", results.SiafundOutputs)
+	}
+}
@@ -0,0 +1,199 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"syscall/js"
+
+	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+const (
+	//paymentURIScheme the scheme of payment request URIs
+	paymentURIScheme = "sia"
+)
+
+type (
+	//paymentRequest the address, amount in hastings, and label of a payment request
+	paymentRequest struct {
+		Address string            `json:"address"`
+		Amount  siatypes.Currency `json:"amount"`
+		Label   string            `json:"label,omitempty"`
+	}
+
+	//receiveRequest a payment request for one of the wallet's addresses and its URI
+	receiveRequest struct {
+		paymentRequest
+		Index            uint64                    `json:"index"`
+		UnlockConditions siatypes.UnlockConditions `json:"unlock_conditions"`
+		URI              string                    `json:"uri"`
+	}
+)
+
+//buildPaymentURI encodes a payment request as a sia:<address>?amount=<hastings>&label=<label> URI.
+//A zero amount and an empty label are left out
+func buildPaymentURI(req paymentRequest) (string, error) {
+	if _, err := parseAddress(req.Address); err != nil {
+		return "", err
+	}
+
+	query := make(url.Values)
+
+	if !req.Amount.IsZero() {
+		query.Set("amount", req.Amount.String())
+	}
+
+	if len(req.Label) != 0 {
+		query.Set("label", req.Label)
+	}
+
+	uri := url.URL{
+		Scheme:   paymentURIScheme,
+		Opaque:   req.Address,
+		RawQuery: query.Encode(),
+	}
+
+	return uri.String(), nil
+}
+
+//parsePaymentURI decodes a payment request URI. A bare address is accepted as a request without an
+//amount or label
+func parsePaymentURI(str string) (req paymentRequest, err error) {
+	str = strings.TrimSpace(str)
+
+	if !strings.Contains(str, ":") {
+		str = paymentURIScheme + ":" + str
+	}
+
+	uri, err := url.Parse(str)
+
+	if err != nil {
+		err = fmt.Errorf("invalid payment uri: %w", err)
+		return
+	}
+
+	if !strings.EqualFold(uri.Scheme, paymentURIScheme) {
+		err = fmt.Errorf("unsupported payment uri scheme %q", uri.Scheme)
+		return
+	}
+
+	// tolerate sia://<address> as well as sia:<address>
+	req.Address = uri.Opaque
+
+	if len(req.Address) == 0 {
+		req.Address = uri.Host
+	}
+
+	if len(req.Address) == 0 {
+		err = errors.New("payment uri has no address")
+		return
+	}
+
+	if _, err = parseAddress(req.Address); err != nil {
+		return
+	}
+
+	query := uri.Query()
+
+	if amount := query.Get("amount"); len(amount) != 0 {
+		i, ok := new(big.Int).SetString(amount, 10)
+
+		if !ok || i.Sign() < 0 {
+			err = fmt.Errorf("invalid payment uri amount %q", amount)
+			return
+		}
+
+		req.Amount = siatypes.NewCurrency(i)
+	}
+
+	req.Label = query.Get("label")
+
+	return
+}
+
+//newReceiveRequest derives the wallet's address at index and builds a payment request for the
+//amount and label to it
+func newReceiveRequest(w *wallet.SeedWallet, index uint64, amount siatypes.Currency, label string) (req receiveRequest, err error) {
+	uc := w.GetAddress(index).UnlockConditions
+
+	req = receiveRequest{
+		paymentRequest: paymentRequest{
+			Address: uc.UnlockHash().String(),
+			Amount:  amount,
+			Label:   label,
+		},
+		Index:            index,
+		UnlockConditions: uc,
+	}
+
+	req.URI, err = buildPaymentURI(req.paymentRequest)
+
+	return
+}
+
+//GenerateReceiveRequest derives the receive address at index and returns it with a payment request
+//URI for the amount and label, so requesting a payment only needs one call
+func GenerateReceiveRequest(phrase, currency string, index uint64, amount siatypes.Currency, label string, accountOffset uint64, callback js.Value) {
+	w, err := recoverWallet(phrase, currency, accountOffset)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	req, err := newReceiveRequest(w, index, amount, label)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(req)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//BuildPaymentURI encodes the address, optional amount in hastings, and optional label as a payment
+//request URI for QR codes
+func BuildPaymentURI(address string, amount siatypes.Currency, label string, callback js.Value) {
+	uri, err := buildPaymentURI(paymentRequest{
+		Address: address,
+		Amount:  amount,
+		Label:   label,
+	})
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), uri)
+}
+
+//ParsePaymentURI decodes a scanned payment request URI into its address, amount, and label
+func ParsePaymentURI(uri string, callback js.Value) {
+	req, err := parsePaymentURI(uri)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(req)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
@@ -0,0 +1,74 @@
+package modules
+
+import (
+	"testing"
+
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+func TestPaymentURI(t *testing.T) {
+	address := testOutputs(t, 1)[0].UnlockHash
+	req := paymentRequest{
+		Address: address,
+		Amount:  siatypes.SiacoinPrecision.Mul64(25),
+		Label:   "rent & bills",
+	}
+
+	uri, err := buildPaymentURI(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := parsePaymentURI(uri)
+	if err != nil {
+		t.Fatal(err)
+	} else if parsed.Address != req.Address || !parsed.Amount.Equals(req.Amount) || parsed.Label != req.Label {
+		t.Fatalf("expected %v, got %v from %s", req, parsed, uri)
+	}
+
+	uri, err = buildPaymentURI(paymentRequest{Address: address})
+	if err != nil {
+		t.Fatal(err)
+	} else if uri != "sia:"+address {
+		t.Fatalf("expected bare uri, got %s", uri)
+	}
+
+	for _, str := range []string{address, "sia://" + address, "SIA:" + address + "?amount=10"} {
+		if parsed, err := parsePaymentURI(str); err != nil {
+			t.Fatalf("unable to parse %q: %s", str, err)
+		} else if parsed.Address != address {
+			t.Fatalf("expected address %s, got %s", address, parsed.Address)
+		}
+	}
+
+	for _, str := range []string{"bitcoin:" + address, "sia:" + address[1:], "sia:" + address + "?amount=1.5", "sia:"} {
+		if _, err := parsePaymentURI(str); err == nil {
+			t.Fatalf("expected %q to fail", str)
+		}
+	}
+}
+
+func TestReceiveRequest(t *testing.T) {
+	w, err := recoverWallet(testPhrase, "sc", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	amount := siatypes.SiacoinPrecision.Mul64(10)
+	req, err := newReceiveRequest(w, 5, amount, "invoice 12")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address := w.GetAddress(5).UnlockConditions.UnlockHash().String()
+	if req.Address != address || req.Index != 5 {
+		t.Fatalf("expected address %s at index 5, got %s at %d", address, req.Address, req.Index)
+	}
+
+	parsed, err := parsePaymentURI(req.URI)
+	if err != nil {
+		t.Fatal(err)
+	} else if parsed.Address != address || !parsed.Amount.Equals(amount) || parsed.Label != "invoice 12" {
+		t.Fatalf("expected the URI to request the amount to the address, got %v from %s", parsed, req.URI)
+	}
+}
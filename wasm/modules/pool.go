@@ -0,0 +1,145 @@
+package modules
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
+	siacrypto "gitlab.com/NebulousLabs/Sia/crypto"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+type (
+	//pooledAddress a derived address cached in an address pool
+	pooledAddress struct {
+		Index            uint64                    `json:"index"`
+		Address          string                    `json:"address"`
+		UnlockConditions siatypes.UnlockConditions `json:"unlock_conditions"`
+	}
+
+	//addressPool a cache of a wallet's derived addresses. The MAC is keyed by the seed so a pool
+	//that was modified, or belongs to another wallet or account, is rejected when it is imported
+	addressPool struct {
+		Fingerprint   string          `json:"fingerprint"`
+		Currency      string          `json:"currency"`
+		AccountOffset uint64          `json:"account_offset"`
+		Addresses     []pooledAddress `json:"addresses"`
+		MAC           string          `json:"mac"`
+	}
+)
+
+//poolMAC returns the MAC of the pool's contents
+func poolMAC(key [32]byte, pool addressPool) siacrypto.Hash {
+	return siacrypto.HashAll(key, pool.Fingerprint, pool.Currency, pool.AccountOffset, pool.Addresses)
+}
+
+//verifyPool checks that the pool was exported from the account of the wallet the key and
+//fingerprint belong to and has not been modified since
+func verifyPool(pool addressPool, key [32]byte, fingerprint, currency string, accountOffset uint64) error {
+	if pool.Currency != currency {
+		return fmt.Errorf("address pool is for %s, not %s", pool.Currency, currency)
+	}
+
+	if pool.AccountOffset != accountOffset {
+		return fmt.Errorf("address pool is for account offset %d, not %d", pool.AccountOffset, accountOffset)
+	}
+
+	if pool.Fingerprint != fingerprint {
+		return errors.New("address pool belongs to a different wallet")
+	}
+
+	var mac siacrypto.Hash
+
+	if err := mac.LoadString(pool.MAC); err != nil {
+		return fmt.Errorf("invalid address pool mac: %w", err)
+	}
+
+	expected := poolMAC(key, pool)
+
+	if subtle.ConstantTimeCompare(mac[:], expected[:]) != 1 {
+		return errors.New("address pool has been modified")
+	}
+
+	return nil
+}
+
+//buildAddressPool derives the first count addresses of the wallet's account and signs the pool
+//with the wallet's pool key
+func buildAddressPool(w *wallet.SeedWallet, currency string, count uint64) addressPool {
+	pool := addressPool{
+		Fingerprint:   w.Fingerprint(),
+		Currency:      currency,
+		AccountOffset: w.AccountOffset,
+		Addresses:     make([]pooledAddress, count),
+	}
+
+	for i := range pool.Addresses {
+		key := w.GetAddress(uint64(i))
+
+		pool.Addresses[i] = pooledAddress{
+			Index:            uint64(i),
+			Address:          key.UnlockConditions.UnlockHash().String(),
+			UnlockConditions: key.UnlockConditions,
+		}
+	}
+
+	pool.MAC = poolMAC(w.AddressPoolKey(), pool).String()
+
+	return pool
+}
+
+//ExportAddressPool derives the first count addresses of the account into a pool the frontend can
+//cache and reload with ImportAddressPool instead of deriving the addresses on every load
+func ExportAddressPool(phrase, currency string, count, accountOffset uint64, callback js.Value) {
+	w, err := recoverWallet(phrase, currency, accountOffset)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(buildAddressPool(w, currency, count))
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//ImportAddressPool returns the addresses of a pool exported by ExportAddressPool. Only the
+//fingerprint and pool key are derived from the seed, the pool is rejected if either does not match
+//or it was exported for another account offset
+func ImportAddressPool(phrase, currency, poolJSON string, accountOffset uint64, callback js.Value) {
+	var pool addressPool
+
+	if err := json.Unmarshal([]byte(poolJSON), &pool); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding address pool: %s", err), js.Null())
+		return
+	}
+
+	w, err := recoverWallet(phrase, currency, accountOffset)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	if err := verifyPool(pool, w.AddressPoolKey(), w.Fingerprint(), currency, accountOffset); err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(pool.Addresses)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
@@ -0,0 +1,94 @@
+package modules
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
+)
+
+func TestVerifyAddressPool(t *testing.T) {
+	w, err := recoverWallet(testPhrase, "sc", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := buildAddressPool(w, "sc", 5)
+	if len(pool.Addresses) != 5 {
+		t.Fatalf("expected 5 addresses, got %d", len(pool.Addresses))
+	}
+
+	for i, addr := range pool.Addresses {
+		if expected := w.GetAddress(uint64(i)).UnlockConditions.UnlockHash().String(); addr.Address != expected {
+			t.Fatalf("address %d: expected %s, got %s", i, expected, addr.Address)
+		}
+	}
+
+	// the pool must survive the round trip through the frontend's cache
+	buf, err := json.Marshal(pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cached addressPool
+	if err := json.Unmarshal(buf, &cached); err != nil {
+		t.Fatal(err)
+	} else if err := verifyPool(cached, w.AddressPoolKey(), w.Fingerprint(), "sc", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := cached
+	tampered.Addresses = append([]pooledAddress(nil), cached.Addresses...)
+	tampered.Addresses[2].Address = cached.Addresses[0].Address
+	if err := verifyPool(tampered, w.AddressPoolKey(), w.Fingerprint(), "sc", 0); err == nil || !strings.Contains(err.Error(), "modified") {
+		t.Fatalf("expected modified error, got %v", err)
+	}
+
+	other, err := wallet.NewSiaRecoveryPhrase()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ow, err := recoverWallet(other, "sc", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifyPool(cached, ow.AddressPoolKey(), ow.Fingerprint(), "sc", 0); err == nil || !strings.Contains(err.Error(), "different wallet") {
+		t.Fatalf("expected different wallet error, got %v", err)
+	}
+
+	// a pool re-fingerprinted for another wallet still fails without that wallet's key
+	forged := cached
+	forged.Fingerprint = ow.Fingerprint()
+	if err := verifyPool(forged, ow.AddressPoolKey(), ow.Fingerprint(), "sc", 0); err == nil || !strings.Contains(err.Error(), "modified") {
+		t.Fatalf("expected modified error, got %v", err)
+	}
+
+	if err := verifyPool(cached, w.AddressPoolKey(), w.Fingerprint(), "scp", 0); err == nil {
+		t.Fatal("expected currency mismatch to fail")
+	}
+
+	// a pool is bound to the account it was derived from
+	account, err := recoverWallet(testPhrase, "sc", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accountPool := buildAddressPool(account, "sc", 5)
+	if accountPool.Addresses[0].Address != account.GetAddress(0).UnlockConditions.UnlockHash().String() {
+		t.Fatal("expected the account's addresses to be pooled")
+	} else if err := verifyPool(accountPool, w.AddressPoolKey(), w.Fingerprint(), "sc", 1000); err != nil {
+		t.Fatal(err)
+	} else if err := verifyPool(accountPool, w.AddressPoolKey(), w.Fingerprint(), "sc", 0); err == nil || !strings.Contains(err.Error(), "account offset") {
+		t.Fatalf("expected account offset error, got %v", err)
+	}
+
+	// relabelling the account offset breaks the mac
+	relabelled := accountPool
+	relabelled.AccountOffset = 0
+	if err := verifyPool(relabelled, w.AddressPoolKey(), w.Fingerprint(), "sc", 0); err == nil || !strings.Contains(err.Error(), "modified") {
+		t.Fatalf("expected modified error, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"syscall/js"
+
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+const (
+	//consolidationInputs the number of distinct addresses a transaction must spend from for the
+	//address it pays to be a consolidation target
+	consolidationInputs = 3
+)
+
+//privacyPenalties the points subtracted from the score of 100 for each flag, in the order the
+//flags are returned. A flag counts once no matter how many transactions raised it, the penalties
+//sum to 100 so the score cannot go below 0
+var privacyPenalties = []struct {
+	Flag    string
+	Penalty int
+}{
+	//received_after_send the address received after spending, its public key is already on the
+	//blockchain and links the new payments to the earlier ones
+	{"received_after_send", 35},
+	//self_change a transaction spending from the address paid change back to it, which shows
+	//which output of the transaction was the change
+	{"self_change", 25},
+	//reused the address received in more than one transaction
+	{"reused", 25},
+	//consolidation_target a transaction spending from several other addresses paid only to the
+	//address, linking every one of them to it
+	{"consolidation_target", 15},
+}
+
+type (
+	//addressPrivacy the privacy score of an address from 0 to 100, higher is better, and the
+	//flags that lowered it. Received and Sent are the number of transactions paying to and
+	//spending from the address
+	addressPrivacy struct {
+		Address      string   `json:"address"`
+		Score        int      `json:"score"`
+		Flags        []string `json:"flags"`
+		Received     int      `json:"received"`
+		Sent         int      `json:"sent"`
+		Transactions int      `json:"transactions"`
+	}
+)
+
+//scorePrivacy scores the transaction pattern of a single address. The transactions are checked
+//from oldest to newest, ordered by timestamp then ID, so the same history always has the same
+//score. Only the transactions returned by the API are scored
+func scorePrivacy(address string, transactions []processedTransaction) addressPrivacy {
+	privacy := addressPrivacy{
+		Address:      address,
+		Score:        100,
+		Flags:        []string{},
+		Transactions: len(transactions),
+	}
+
+	ordered := make([]processedTransaction, len(transactions))
+	copy(ordered, transactions)
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if !ordered[i].Timestamp.Equal(ordered[j].Timestamp) {
+			return ordered[i].Timestamp.Before(ordered[j].Timestamp)
+		}
+
+		return ordered[i].TransactionID < ordered[j].TransactionID
+	})
+
+	flagged := make(map[string]bool)
+
+	for _, txn := range ordered {
+		var spent, received bool
+
+		others := make(map[string]bool)
+
+		for _, input := range txn.SiacoinInputs {
+			if input.Owned {
+				spent = true
+			} else {
+				others[input.UnlockHash] = true
+			}
+		}
+
+		for _, input := range txn.SiafundInputs {
+			spent = spent || input.Owned
+		}
+
+		allOwned := len(txn.SiacoinOutputs) != 0
+
+		for _, output := range txn.SiacoinOutputs {
+			received = received || output.Owned
+			allOwned = allOwned && output.Owned
+		}
+
+		for _, output := range txn.SiafundOutputs {
+			received = received || output.Owned
+		}
+
+		switch {
+		case spent && received:
+			flagged["self_change"] = true
+		case received && privacy.Sent != 0:
+			flagged["received_after_send"] = true
+		}
+
+		if received && privacy.Received != 0 {
+			flagged["reused"] = true
+		}
+
+		if !spent && allOwned && len(others) >= consolidationInputs {
+			flagged["consolidation_target"] = true
+		}
+
+		if received {
+			privacy.Received++
+		}
+
+		if spent {
+			privacy.Sent++
+		}
+	}
+
+	for _, p := range privacyPenalties {
+		if !flagged[p.Flag] {
+			continue
+		}
+
+		privacy.Flags = append(privacy.Flags, p.Flag)
+		privacy.Score -= p.Penalty
+	}
+
+	return privacy
+}
+
+//AddressPrivacyScore scores how much the transaction pattern of an address reveals about the
+//wallet. Addresses with a low score should not be used to receive again
+func AddressPrivacyScore(address, currency string, callback js.Value) {
+	var uh siatypes.UnlockHash
+
+	if err := uh.LoadString(address); err != nil {
+		callback.Invoke(fmt.Sprintf("invalid address %q: %s", address, err), js.Null())
+		return
+	}
+
+	resp, err := loadTransactions(context.Background(), []string{address}, currency)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(scorePrivacy(address, resp.Transactions))
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
@@ -0,0 +1,81 @@
+package modules
+
+import (
+	"testing"
+	"time"
+
+	apitypes "github.com/siacentral/apisdkgo/types"
+)
+
+//privacyTxn returns a transaction at the timestamp spending the inputs and paying the outputs,
+//owned if they belong to "addr"
+func privacyTxn(id string, ts int64, inputs, outputs []string) processedTransaction {
+	txn := processedTransaction{
+		TransactionID: id,
+		Timestamp:     time.Unix(ts, 0),
+	}
+
+	for _, addr := range inputs {
+		input := processedSiacoinInput{Owned: addr == "addr"}
+		input.UnlockHash = addr
+		txn.SiacoinInputs = append(txn.SiacoinInputs, input)
+	}
+
+	for _, addr := range outputs {
+		txn.SiacoinOutputs = append(txn.SiacoinOutputs, processedSiacoinOutput{
+			SiacoinOutput: apitypes.SiacoinOutput{UnlockHash: addr},
+			Owned:         addr == "addr",
+		})
+	}
+
+	return txn
+}
+
+func TestScorePrivacy(t *testing.T) {
+	privacy := scorePrivacy("addr", nil)
+	if privacy.Score != 100 || len(privacy.Flags) != 0 {
+		t.Fatalf("expected an unused address to score 100, got %d %v", privacy.Score, privacy.Flags)
+	}
+
+	// received once and spent once is the expected pattern
+	privacy = scorePrivacy("addr", []processedTransaction{
+		privacyTxn("b", 2, []string{"addr"}, []string{"other"}),
+		privacyTxn("a", 1, []string{"sender"}, []string{"addr", "sender"}),
+	})
+	if privacy.Score != 100 || privacy.Received != 1 || privacy.Sent != 1 {
+		t.Fatalf("expected a single use address to score 100, got %+v", privacy)
+	}
+
+	// the order is by timestamp, not by the order of the history
+	privacy = scorePrivacy("addr", []processedTransaction{
+		privacyTxn("a", 1, []string{"sender"}, []string{"addr"}),
+		privacyTxn("b", 2, []string{"addr"}, []string{"other"}),
+		privacyTxn("c", 3, []string{"sender"}, []string{"addr"}),
+	})
+	if privacy.Score != 40 || len(privacy.Flags) != 2 || privacy.Flags[0] != "received_after_send" || privacy.Flags[1] != "reused" {
+		t.Fatalf("expected received_after_send and reused, got %d %v", privacy.Score, privacy.Flags)
+	}
+
+	privacy = scorePrivacy("addr", []processedTransaction{
+		privacyTxn("a", 1, []string{"sender"}, []string{"addr"}),
+		privacyTxn("b", 2, []string{"addr"}, []string{"other", "addr"}),
+	})
+	if privacy.Score != 50 || len(privacy.Flags) != 2 || privacy.Flags[0] != "self_change" || privacy.Flags[1] != "reused" {
+		t.Fatalf("expected self_change and reused, got %d %v", privacy.Score, privacy.Flags)
+	}
+
+	privacy = scorePrivacy("addr", []processedTransaction{
+		privacyTxn("a", 1, []string{"one", "two", "three", "three"}, []string{"addr"}),
+	})
+	if privacy.Score != 85 || len(privacy.Flags) != 1 || privacy.Flags[0] != "consolidation_target" {
+		t.Fatalf("expected consolidation_target, got %d %v", privacy.Score, privacy.Flags)
+	}
+
+	// a payment with change going back to the sender is not a consolidation
+	privacy = scorePrivacy("addr", []processedTransaction{
+		privacyTxn("a", 1, []string{"one", "two", "three"}, []string{"addr", "one"}),
+	})
+	if privacy.Score != 100 {
+		t.Fatalf("expected a payment to not be a consolidation, got %d %v", privacy.Score, privacy.Flags)
+	}
+}
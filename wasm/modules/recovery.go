@@ -1,33 +1,304 @@
 package modules
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"math"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"syscall/js"
+	"time"
 
+	"github.com/siacentral/apisdkgo"
+	apitypes "github.com/siacentral/apisdkgo/types"
 	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
 )
 
+const (
+	//retryDelay the time to wait before retrying a failed used address request
+	retryDelay = 250 * time.Millisecond
+
+	//defaultMinRoundSize the minimum number of addresses scanned in a round when the caller does not
+	//set a floor
+	defaultMinRoundSize = 100
+
+	//defaultSiafundGapLimit the number of consecutive addresses without siafund usage a siafund scan
+	//checks before it stops when the caller does not set a limit. Siafund addresses are rare and
+	//sparse, four times the gap of the default recovery of 10 rounds of 2500 addresses
+	defaultSiafundGapLimit = 100000
+)
+
 type (
+	//recoveryWork a round of addresses to check, either the indices from Start to End or, if set,
+	//only the listed Indices
 	recoveryWork struct {
 		Round, Start, End uint64
+		Indices           []uint64
 	}
 
 	recoveredAddress struct {
 		Address          string                  `json:"address"`
 		UsageType        string                  `json:"usage_type"`
 		Index            uint64                  `json:"index"`
+		Label            string                  `json:"label,omitempty"`
+		Reused           bool                    `json:"reused,omitempty"`
+		Maturing         []immatureOutput        `json:"maturing,omitempty"`
+		Assets           []string                `json:"assets,omitempty"`
 		UnlockConditions wallet.UnlockConditions `json:"unlock_conditions"`
 	}
 
+	//uniqueAddresses tracks the index each address was derived from during a scan so a derivation
+	//regression producing the same address for two indices is caught
+	uniqueAddresses struct {
+		mu      sync.Mutex
+		indices map[string]uint64
+	}
+
+	//retryBudget the number of failed requests that can be retried across every worker of a scan.
+	//A nil budget does not allow any retries
+	retryBudget struct {
+		remaining int64
+	}
+
+	//cancelGroup cancels scans by the ID they were started with
+	cancelGroup struct {
+		mu    sync.Mutex
+		scans map[string]*scanCancel
+	}
+
+	scanCancel struct {
+		ctx    context.Context
+		cancel context.CancelFunc
+	}
+
 	recoveryResults struct {
 		Round, LastUsedIndex, Start, End uint64
 		LastUsedType                     string
-		Addresses                        []recoveredAddress
-		Error                            error
+		//LastAssetIndex the highest used index of each asset used in the round
+		LastAssetIndex map[string]uint64
+		Addresses      []recoveredAddress
+		Error          error
 	}
 )
 
+//errRetriesExhausted returned when a request fails after the scan's retry budget is used up
+var errRetriesExhausted = errors.New("retry budget exhausted")
+
+//errSiafundGapReached stops a siafund scan once the gap limit is reached, it is not a failure
+var errSiafundGapReached = errors.New("siafund gap limit reached")
+
+func newRetryBudget(retries uint64) *retryBudget {
+	if retries > math.MaxInt64 {
+		retries = math.MaxInt64
+	}
+
+	return &retryBudget{
+		remaining: int64(retries),
+	}
+}
+
+//Take takes a retry from the budget, returning false if there are none left
+func (b *retryBudget) Take() bool {
+	if b == nil {
+		return false
+	}
+
+	return atomic.AddInt64(&b.remaining, -1) >= 0
+}
+
+//Exhausted returns true if every retry has been taken
+func (b *retryBudget) Exhausted() bool {
+	return b != nil && atomic.LoadInt64(&b.remaining) <= 0
+}
+
+//recoveryScans the running recovery scans by ID, cancelled by CancelRecovery
+var recoveryScans cancelGroup
+
+//Context returns the context of the scan with the ID and a release func that must be called once
+//the scan completes. The context is already cancelled if Cancel was called with the ID before the
+//scan started. A scan without an ID cannot be cancelled
+func (g *cancelGroup) Context(id string) (context.Context, func()) {
+	if len(id) == 0 {
+		return context.WithCancel(context.Background())
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	scan := g.scan(id)
+
+	return scan.ctx, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		scan.cancel()
+
+		if g.scans[id] == scan {
+			delete(g.scans, id)
+		}
+	}
+}
+
+//Cancel cancels the scan with the ID. A cancel sent before the scan starts is kept so the scan is
+//cancelled as soon as it starts, other scans are not affected
+func (g *cancelGroup) Cancel(id string) {
+	if len(id) == 0 {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.scan(id).cancel()
+}
+
+//scan returns the scan with the ID, adding it if it does not exist. The caller must hold the lock
+func (g *cancelGroup) scan(id string) *scanCancel {
+	if g.scans == nil {
+		g.scans = make(map[string]*scanCancel)
+	}
+
+	scan, ok := g.scans[id]
+
+	if !ok {
+		scan = new(scanCancel)
+		scan.ctx, scan.cancel = context.WithCancel(context.Background())
+		g.scans[id] = scan
+	}
+
+	return scan
+}
+
+//chunkAddresses splits the addresses into chunks of at most size addresses so a large round can
+//be encoded and passed to JS in pieces instead of one large allocation. Always returns at least
+//one chunk so every round reports progress
+func chunkAddresses(addresses []recoveredAddress, size int) [][]recoveredAddress {
+	if len(addresses) <= size {
+		return [][]recoveredAddress{addresses}
+	}
+
+	chunks := make([][]recoveredAddress, 0, (len(addresses)+size-1)/size)
+
+	for i := 0; i < len(addresses); i += size {
+		end := i + size
+
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+
+		chunks = append(chunks, addresses[i:end])
+	}
+
+	return chunks
+}
+
+//findGaps returns the ranges of unused indices below the highest used index
+func findGaps(used []uint64) (gaps []IndexRange) {
+	sorted := append([]uint64(nil), used...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var next uint64
+
+	for _, i := range sorted {
+		if i > next {
+			gaps = append(gaps, IndexRange{
+				Start: next,
+				End:   i - 1,
+			})
+		}
+
+		if i >= next {
+			next = i + 1
+		}
+	}
+
+	return
+}
+
+func newUniqueAddresses() *uniqueAddresses {
+	return &uniqueAddresses{
+		indices: make(map[string]uint64),
+	}
+}
+
+//Add records the index of the address, returning an error if a different index already produced
+//the same address
+func (u *uniqueAddresses) Add(address string, index uint64) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if existing, exists := u.indices[address]; exists && existing != index {
+		return fmt.Errorf("address %s derived from index %d and %d", address, existing, index)
+	}
+
+	u.indices[address] = index
+
+	return nil
+}
+
+//addressGap counts the consecutive unused addresses at the end of the scanned addresses. Rounds
+//complete out of order, so a round is only counted once every round before it has completed
+type addressGap struct {
+	next      uint64
+	end       uint64
+	firstFree uint64
+	completed map[uint64]recoveryResults
+}
+
+//newAddressGap starts counting from startIndex. Addresses up to and including lastKnownIndex are
+//known to be used and are never counted as unused, a lastKnownIndex of 0 is not known
+func newAddressGap(startIndex, lastKnownIndex uint64) *addressGap {
+	firstFree := startIndex
+
+	if lastKnownIndex == math.MaxUint64 {
+		firstFree = lastKnownIndex
+	} else if lastKnownIndex != 0 && lastKnownIndex >= firstFree {
+		firstFree = lastKnownIndex + 1
+	}
+
+	return &addressGap{
+		end:       startIndex,
+		firstFree: firstFree,
+		completed: make(map[uint64]recoveryResults),
+	}
+}
+
+//Add counts the round and returns the number of consecutive unused addresses after the last used
+//address in the rounds completed so far
+func (g *addressGap) Add(res recoveryResults) uint64 {
+	g.completed[res.Round] = res
+
+	for {
+		round, exists := g.completed[g.next]
+
+		if !exists {
+			break
+		}
+
+		delete(g.completed, g.next)
+		g.next++
+
+		for _, addr := range round.Addresses {
+			if addr.Index >= g.firstFree {
+				g.firstFree = addr.Index + 1
+			}
+		}
+
+		if round.End > g.end {
+			g.end = round.End
+		}
+	}
+
+	if g.end <= g.firstFree {
+		return 0
+	}
+
+	return g.end - g.firstFree
+}
+
 func consecutiveEmptyRounds(rounds []uint64) uint64 {
 	var lastRound uint64
 	roundMap := make(map[uint64]bool)
@@ -64,25 +335,236 @@ func generateAddress(w *wallet.SeedWallet, i uint64) recoveredAddress {
 	return addr
 }
 
-func recoveryWorker(w *wallet.SeedWallet, currency string, work <-chan recoveryWork, results chan<- recoveryResults) {
+//verifyRecoveredAddresses re-derives each address from its index and checks that both the derived
+//address and the address of the returned unlock conditions match the address string. Catches any
+//bug that associates an address with the wrong index. Every mismatch is logged before the error
+//is returned
+func verifyRecoveredAddresses(w *wallet.SeedWallet, addresses []recoveredAddress) error {
+	var mismatches int
+
+	for _, addr := range addresses {
+		uc, err := unmapUnlockConditions(addr.UnlockConditions)
+
+		if err != nil {
+			log.Printf("recovery verification failed: address %s at index %d has invalid unlock conditions: %s", addr.Address, addr.Index, err)
+			mismatches++
+			continue
+		}
+
+		derived := generateAddress(w, addr.Index).Address
+
+		if uh := uc.UnlockHash().String(); uh != addr.Address || derived != addr.Address {
+			log.Printf("recovery verification failed: address %s at index %d, unlock conditions hash to %s, index derives %s", addr.Address, addr.Index, uh, derived)
+			mismatches++
+		}
+	}
+
+	if mismatches != 0 {
+		return fmt.Errorf("recovery verification failed: %d of %d addresses do not match their index", mismatches, len(addresses))
+	}
+
+	return nil
+}
+
+//mergeAssetIndices raises the highest used index of each asset in last to the index in round
+func mergeAssetIndices(last, round map[string]uint64) {
+	for asset, index := range round {
+		if prev, exists := last[asset]; !exists || index > prev {
+			last[asset] = index
+		}
+	}
+}
+
+//addressAssets sets the assets each recovered address has used from the address balance response.
+//The used addresses endpoint does not report the asset, so an address has used siafunds if it holds
+//siafunds or one of the returned transactions moved siafunds to or from it. The response must hold
+//the full history of the addresses, see addressHistory, otherwise an address that spent its
+//siafunds is missed. An address without any evidence of either asset is assumed to have used
+//siacoins
+func addressAssets(resp apisdkgo.GetTransactionsResp, recovered []recoveredAddress) {
+	siacoins := make(map[string]bool)
+	siafunds := make(map[string]bool)
+
+	for _, output := range resp.UnspentSiacoinOutputs {
+		siacoins[output.UnlockHash] = true
+	}
+
+	for _, output := range resp.UnspentSiafundOutputs {
+		siafunds[output.UnlockHash] = true
+	}
+
+	for _, txns := range [][]apitypes.Transaction{resp.Transactions, resp.UnconfirmedTransactions} {
+		for _, txn := range txns {
+			for _, input := range txn.SiacoinInputs {
+				siacoins[input.UnlockHash] = true
+			}
+
+			for _, output := range txn.SiacoinOutputs {
+				siacoins[output.UnlockHash] = true
+			}
+
+			for _, input := range txn.SiafundInputs {
+				siafunds[input.UnlockHash] = true
+			}
+
+			for _, output := range txn.SiafundOutputs {
+				siafunds[output.UnlockHash] = true
+			}
+		}
+	}
+
+	for i, addr := range recovered {
+		recovered[i].Assets = nil
+
+		if siacoins[addr.Address] || !siafunds[addr.Address] {
+			recovered[i].Assets = append(recovered[i].Assets, "siacoin")
+		}
+
+		if siafunds[addr.Address] {
+			recovered[i].Assets = append(recovered[i].Assets, "siafund")
+		}
+	}
+}
+
+//addressHistory gets the unspent outputs and every transaction of the addresses. Like
+//estimateCreationHeight paging stops at a partial page or at a page without any transactions that
+//were not already seen
+func addressHistory(ctx context.Context, currency string, addresses []string) (resp apisdkgo.GetTransactionsResp, err error) {
+	apiclient := siacentralAPIClient(currency)
+	seen := make(map[string]bool)
+
+	for page := 0; ; page++ {
+		pageResp, err := apiclient.FindAddressBalance(ctx, historyPageSize, page, addresses)
+
+		if err != nil {
+			return apisdkgo.GetTransactionsResp{}, err
+		}
+
+		// every page has the same unspent outputs, only the transactions are paged
+		if page == 0 {
+			resp = pageResp
+			resp.Transactions = nil
+		}
+
+		var unseen int
+
+		for _, txn := range pageResp.Transactions {
+			id := txn.ID
+
+			// transactions without an id are named by their first output, like loadTransactions
+			if len(id) == 0 && len(txn.SiacoinOutputs) != 0 {
+				id = fmt.Sprintf("nontxn-%s", txn.SiacoinOutputs[0].OutputID)
+			}
+
+			if !seen[id] {
+				seen[id] = true
+				unseen++
+				resp.Transactions = append(resp.Transactions, txn)
+			}
+		}
+
+		if len(pageResp.Transactions) < historyPageSize || unseen == 0 {
+			return resp, nil
+		}
+	}
+}
+
+//addMaturingOutputs attaches any unspent outputs that have not reached their maturity height to
+//the recovered addresses and sets the assets each address has used from their full history, see
+//addressAssets. A height of 0 is not known, no outputs are attached
+func addMaturingOutputs(ctx context.Context, currency string, height uint64, recovered []recoveredAddress) error {
+	var addresses []string
+
+	indices := make(map[string]int)
+
+	for i, addr := range recovered {
+		indices[addr.Address] = i
+		addresses = append(addresses, addr.Address)
+	}
+
+	resp, err := addressHistory(ctx, currency, addresses)
+
+	if err != nil {
+		return err
+	}
+
+	addressAssets(resp, recovered)
+
+	for _, output := range resp.UnspentSiacoinOutputs {
+		i, exists := indices[output.UnlockHash]
+
+		if !exists || height == 0 || output.MaturityHeight <= height {
+			continue
+		}
+
+		recovered[i].Maturing = append(recovered[i].Maturing, immatureOutput{
+			OutputID:       output.OutputID,
+			Source:         output.Source,
+			Value:          output.Value,
+			MaturityHeight: output.MaturityHeight,
+		})
+	}
+
+	return nil
+}
+
+//recoveryWorker queries the used addresses of each round of work. If unique is not nil every
+//derived address is checked against the addresses derived by the other workers
+func recoveryWorker(ctx context.Context, w *wallet.SeedWallet, currency string, height uint64, unique *uniqueAddresses, budget *retryBudget, work <-chan recoveryWork, results chan<- recoveryResults) {
 	for r := range work {
 		var addresses []string
+
+		if ctx.Err() != nil {
+			return
+		}
+
 		recovered := recoveryResults{
-			Round: r.Round,
-			Start: r.Start,
-			End:   r.End,
+			Round:          r.Round,
+			Start:          r.Start,
+			End:            r.End,
+			LastAssetIndex: make(map[string]uint64),
 		}
 
 		addressMap := make(map[string]recoveredAddress)
+		indices := r.Indices
 
-		for i := r.Start; i < r.End; i++ {
+		if indices == nil {
+			for i := r.Start; i < r.End; i++ {
+				indices = append(indices, i)
+			}
+		}
+
+		for _, i := range indices {
 			addr := generateAddress(w, i)
+
+			if unique != nil {
+				if err := unique.Add(addr.Address, i); err != nil {
+					results <- recoveryResults{
+						Error: err,
+					}
+					return
+				}
+			}
+
 			addressMap[addr.Address] = addr
 			addresses = append(addresses, addr.Address)
 		}
 
 		apiclient := siacentralAPIClient(currency)
-		used, err := apiclient.FindUsedAddresses(addresses)
+		used, err := apiclient.FindUsedAddresses(ctx, addresses)
+
+		for err != nil && ctx.Err() == nil && retryable(err) && budget.Take() {
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryDelay):
+			}
+
+			used, err = apiclient.FindUsedAddresses(ctx, addresses)
+		}
+
+		if err != nil && retryable(err) && budget.Exhausted() {
+			err = fmt.Errorf("%w: %s", errRetriesExhausted, err)
+		}
 
 		if err != nil {
 			results <- recoveryResults{
@@ -91,48 +573,72 @@ func recoveryWorker(w *wallet.SeedWallet, currency string, work <-chan recoveryW
 			return
 		}
 
+		// the API returns an entry for each usage type of an address, only add the address once
+		// and flag it as reused if it was seen with more than one usage type
+		found := make(map[string]int)
+
 		for _, usage := range used {
 			addr, exists := addressMap[usage.Address]
 			if !exists {
 				continue
 			}
 
+			if i, seen := found[addr.Address]; seen {
+				if recovered.Addresses[i].UsageType != usage.UsageType {
+					recovered.Addresses[i].Reused = true
+				}
+
+				// the last used address still needs the lookahead if the API listed its send second
+				if usage.UsageType == "sent" && recovered.Addresses[i].Index == recovered.LastUsedIndex {
+					recovered.LastUsedType = usage.UsageType
+				}
+
+				continue
+			}
+
+			found[addr.Address] = len(recovered.Addresses)
 			addr.UsageType = usage.UsageType
+			addr.Label = addressLabels.Get(addr.Address)
 			recovered.Addresses = append(recovered.Addresses, addr)
 
-			if recovered.LastUsedIndex < addr.Index {
+			// the first used address is the last used even at index 0
+			if len(recovered.Addresses) == 1 || recovered.LastUsedIndex < addr.Index {
 				recovered.LastUsedIndex = addr.Index
 				recovered.LastUsedType = addr.UsageType
 			}
 		}
 
+		// the maturing outputs are extra detail, a round that cannot get them still succeeds
+		if len(recovered.Addresses) != 0 && ctx.Err() == nil {
+			if err := addMaturingOutputs(ctx, currency, height, recovered.Addresses); err != nil {
+				log.Printf("recovery round %d maturing outputs skipped: %s", r.Round, err)
+			}
+		}
+
+		for _, addr := range recovered.Addresses {
+			for _, asset := range addr.Assets {
+				if last, exists := recovered.LastAssetIndex[asset]; !exists || addr.Index > last {
+					recovered.LastAssetIndex[asset] = addr.Index
+				}
+			}
+		}
+
 		results <- recovered
 	}
 }
 
-// RecoverAddresses scans for addresses on the blockchain addressCount at a time up to a maximum of 100,000,000
-//addresses. Considers all addresses found if the scan goes more than minRounds * addressCount
-//addresses without seeing any used. It's possible the ranges will need to be tweaked for older or
-//larger wallets
-func RecoverAddresses(seed, currency string, startIndex, maxEmptyRounds, addressCount, lastKnownIndex uint64, callback js.Value) {
+//startRecoveryWorkers starts the workers checking the rounds sent on work. The returned channel is
+//closed once work is closed and every worker has exited
+func startRecoveryWorkers(ctx context.Context, w *wallet.SeedWallet, currency string, height uint64, unique *uniqueAddresses, budget *retryBudget, work <-chan recoveryWork) <-chan recoveryResults {
 	var wg sync.WaitGroup
 
-	w, err := recoverWallet(seed, currency)
-
-	if err != nil {
-		callback.Invoke(fmt.Errorf("unable to recover wallet: %w", err).Error(), js.Null())
-		return
-	}
-
-	work := make(chan recoveryWork, workers)
 	results := make(chan recoveryResults)
-	done := make(chan bool)
 
 	wg.Add(workers)
 
 	for i := 0; i < workers; i++ {
 		go func() {
-			recoveryWorker(w, currency, work, results)
+			recoveryWorker(ctx, w, currency, height, unique, budget, work, results)
 			wg.Done()
 		}()
 	}
@@ -143,40 +649,168 @@ func RecoverAddresses(seed, currency string, startIndex, maxEmptyRounds, address
 		close(results)
 	}()
 
+	return results
+}
+
+//scanIndices checks only the listed indices for usage, batchSize at a time, without scanning the
+//gaps between them. Returns the used addresses sorted by index
+func scanIndices(w *wallet.SeedWallet, currency string, indices []uint64, batchSize uint64) ([]recoveredAddress, error) {
+	var used []recoveredAddress
+	var scanErr error
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// like scanAddresses the height is only used to find maturing outputs
+	height, err := currentHeight(ctx, currency)
+
+	if err != nil {
+		log.Printf("recovery maturing outputs skipped, unable to get block height: %s", err)
+		height = 0
+	}
+
+	// dedupe and sort the indices so each address is only checked once
+	seen := make(map[uint64]bool)
+	unique := make([]uint64, 0, len(indices))
+
+	for _, i := range indices {
+		if !seen[i] {
+			seen[i] = true
+			unique = append(unique, i)
+		}
+	}
+
+	sort.Slice(unique, func(i, j int) bool { return unique[i] < unique[j] })
+
+	work := make(chan recoveryWork, workers)
+	results := startRecoveryWorkers(ctx, w, currency, height, nil, nil, work)
+
 	go func() {
-		var round uint64
+		defer close(work)
+
+		for i, round := uint64(0), uint64(0); i < uint64(len(unique)); i, round = i+batchSize, round+1 {
+			end := i + batchSize
+
+			if end > uint64(len(unique)) {
+				end = uint64(len(unique))
+			}
 
-		for i := startIndex; ; i += addressCount {
 			select {
-			case <-done:
-				close(work)
+			case <-ctx.Done():
 				return
-			default:
+			case work <- recoveryWork{
+				Round:   round,
+				Indices: unique[i:end],
+			}:
+			}
+		}
+	}()
+
+	// keep draining the results after an error so the workers can exit
+	for res := range results {
+		if res.Error != nil {
+			if scanErr == nil {
+				scanErr = res.Error
+			}
+
+			cancel()
+			continue
+		}
+
+		used = append(used, res.Addresses...)
+	}
+
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	sort.Slice(used, func(i, j int) bool { return used[i].Index < used[j].Index })
+
+	return used, nil
+}
+
+//scanAddresses scans for used addresses addressCount at a time starting at startIndex. The
+//scan stops after maxEmptyRounds consecutive rounds past lastKnownIndex without any used
+//addresses or when it reaches endIndex, an endIndex of 0 does not limit the scan. onRound is
+//called with the results of each round as they complete, returning an error stops the scan. Each
+//scan owns its channels and workers, the only shared state is read-only or guarded by a mutex, so
+//multiple wallets can be scanned concurrently. Cancelling ctx stops the scan, rounds that completed
+//before the cancel are still passed to onRound. Reaching the empty round limit only stops new
+//rounds from being sent, rounds already requested still complete since an earlier round may not
+//have finished yet. Reaching the gap limit aborts the requested rounds, the gap only counts rounds
+//once every round before them has completed
+func scanAddresses(parent context.Context, w *wallet.SeedWallet, currency string, startIndex, endIndex, maxEmptyRounds, addressCount, lastKnownIndex, gapLimit uint64, budget *retryBudget, onRound func(recoveryResults) error) error {
+	var scanErr error
+
+	// cancelling the context stops the scan and aborts any in-flight requests
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	// the height is only used to find maturing outputs, the scan does not depend on it
+	height, err := currentHeight(ctx, currency)
+
+	if err != nil {
+		log.Printf("recovery maturing outputs skipped, unable to get block height: %s", err)
+		height = 0
+	}
+
+	var unique *uniqueAddresses
+
+	if debugAssertions {
+		unique = newUniqueAddresses()
+	}
+
+	// stopping the dispatch stops new rounds without aborting the rounds already requested
+	dispatch, stopDispatch := context.WithCancel(ctx)
+	defer stopDispatch()
+
+	work := make(chan recoveryWork, workers)
+	results := startRecoveryWorkers(ctx, w, currency, height, unique, budget, work)
+
+	go func() {
+		var round uint64
+
+		defer close(work)
+
+		for i := startIndex; endIndex == 0 || i < endIndex; i += addressCount {
+			end := i + addressCount
+
+			if endIndex != 0 && end > endIndex {
+				end = endIndex
 			}
 
-			work <- recoveryWork{
+			select {
+			case <-dispatch.Done():
+				return
+			case work <- recoveryWork{
 				Start: i,
-				End:   i + addressCount,
+				End:   end,
 				Round: round,
+			}:
 			}
 
 			round++
 		}
 	}()
 
-	var lastIndex, usedTotal uint64
-	var lastUsageType string
 	var empty []uint64
 
+	gap := newAddressGap(startIndex, lastKnownIndex)
+
+	// keep draining the results after the scan is cancelled so the workers can exit
 	for res := range results {
+		// requests aborted by the scan stopping itself are not errors. A failed round has no
+		// results, it must not be counted as empty
 		if res.Error != nil {
-			//close the done channel to signal completion if it isn't already closed
-			select {
-			case <-done:
-				break
-			default:
-				close(done)
+			if scanErr == nil && ctx.Err() == nil {
+				scanErr = res.Error
+				cancel()
 			}
+
+			continue
+		}
+
+		if scanErr != nil {
 			continue
 		}
 
@@ -184,48 +818,489 @@ func RecoverAddresses(seed, currency string, startIndex, maxEmptyRounds, address
 			empty = append(empty, res.Round)
 
 			if consecutive := consecutiveEmptyRounds(empty); consecutive >= maxEmptyRounds {
-				//close the done channel to signal completion if it isn't already closed
-				select {
-				case <-done:
-					break
-				default:
-					close(done)
-				}
+				stopDispatch()
+			}
+		}
+
+		if unused := gap.Add(res); gapLimit != 0 && unused >= gapLimit {
+			cancel()
+		}
+
+		if err := onRound(res); err != nil {
+			scanErr = err
+			cancel()
+		}
+	}
+
+	return scanErr
+}
+
+//effectiveRoundSize raises addressCount to at least minRoundSize so a small round size does not
+//turn a scan into many tiny API requests. Each request has a fixed latency, so rounds below the
+//floor are slower without checking any fewer addresses. When the round size is raised, the number
+//of empty rounds is reduced so the scan still stops after roughly the same number of unused
+//addresses, rounded up to the next full round. A minRoundSize of 0 uses the default floor, a
+//minRoundSize of 1 disables it
+func effectiveRoundSize(addressCount, maxEmptyRounds, minRoundSize uint64) (uint64, uint64) {
+	if minRoundSize == 0 {
+		minRoundSize = defaultMinRoundSize
+	}
+
+	if addressCount >= minRoundSize {
+		return addressCount, maxEmptyRounds
+	}
+
+	// an unlimited or overflowing gap stays unlimited
+	if addressCount == 0 || maxEmptyRounds > math.MaxUint64/addressCount {
+		return minRoundSize, maxEmptyRounds
+	}
+
+	gap := addressCount * maxEmptyRounds
+
+	return minRoundSize, (gap + minRoundSize - 1) / minRoundSize
+}
+
+//lookaheadIndex returns the index of the lookahead address, the one after the highest used index.
+//Every used address found by the scan is at or below highest, so the lookahead never collides with
+//one. Returns false if no address was used or the next index would overflow
+func lookaheadIndex(highest uint64, used bool) (uint64, bool) {
+	if !used || highest == math.MaxUint64 {
+		return 0, false
+	}
+
+	return highest + 1, true
+}
+
+// RecoverAddresses scans for addresses on the blockchain AddressCount at a time up to a maximum of 100,000,000
+//addresses. Considers all addresses found if the scan goes more than MaxEmptyRounds * AddressCount
+//addresses without seeing any used. It's possible the ranges will need to be tweaked for older or
+//larger wallets. If Compress is set the progress and completion payloads are returned gzipped.
+//Each of the Additional ranges is scanned in full after the primary scan so addresses at a high
+//offset, like cold storage, are found without scanning the gap in between. Progress is sent at most
+//once per ProgressInterval milliseconds, rounds completed in between are merged into the next
+//event. Unless SkipLookahead is set, the address after the last used address is returned if it was
+//last used to send so the wallet has an unused address to receive change.
+//
+//Each found address is only sent once, in the progress event of the round it was found in. The
+//completion payload carries the summary and any addresses not sent as progress yet. If ResendAll is
+//set every found address is also included in the completion payload. Rounds smaller than
+//MinRoundSize are raised to the floor, see effectiveRoundSize.
+//
+//CancelRecovery with the ScanID stops the scan early. The completion payload is still sent with everything found
+//before the cancel and cancelled set. A cancelled scan never includes the lookahead address since
+//more used addresses may follow the last one found.
+//
+//Failed requests are retried up to MaxRetries times in total across every worker of the scan. Once
+//the retries are used up the next failure stops the scan, the completion payload is sent with the
+//addresses found so far and incomplete set. This bounds the duration of a scan on a bad connection.
+//
+//If AddressGapLimit is set it replaces the empty round limit, the scan stops after that many
+//consecutive unused addresses no matter the round size. Requests still in flight when the gap is
+//reached are aborted, only the rounds that completed before it are reported.
+//
+//If Verify is set every found address is re-derived from its index before it is returned, see
+//verifyRecoveredAddresses. A mismatch fails the scan. Verifying derives each address a second time.
+//
+//Each found address lists the assets it has used, see addressAssets. The payloads include the
+//highest used index of each asset in asset_index so a wallet with many siacoin addresses but few
+//siafund addresses can tell how far each asset extends. asset_index is only reported, index remains
+//the highest of both and the gap limit, empty round limit, and lookahead are all computed from it
+//
+//If Incremental is set LastKnownIndex is the highest used index found by a previous recovery. The
+//scan starts after it and only the newly used addresses are returned, the gap and empty round
+//limits apply from the new starting point. The payloads report LastKnownIndex as the index until
+//a higher used address is found. The additional ranges are still scanned in full
+func RecoverAddresses(seed, currency string, opts RecoveryOptions, callback js.Value) {
+	var lastIndex, usedTotal uint64
+	var lastUsageType string
+	var incomplete, hasUsage bool
+	var pending, all []recoveredAddress
+	var lastProgress time.Time
+
+	startIndex, maxEmptyRounds, addressCount := opts.StartIndex, opts.MaxEmptyRounds, opts.AddressCount
+	progressInterval := time.Duration(opts.ProgressInterval) * time.Millisecond
+
+	ctx, release := recoveryScans.Context(opts.ScanID)
+	defer release()
+
+	lastAssetIndex := make(map[string]uint64)
+
+	w, err := recoverWallet(seed, currency, opts.AccountOffset)
+
+	if err != nil {
+		callback.Invoke(fmt.Errorf("unable to recover wallet: %w", err).Error(), js.Null())
+		return
+	}
+
+	for _, r := range opts.Additional {
+		// the scan of a range stops before End+1, which would wrap to 0 and never stop
+		if r.Start > r.End || r.End == math.MaxUint64 {
+			callback.Invoke(fmt.Sprintf("invalid range %d-%d", r.Start, r.End), js.Null())
+			return
+		}
+	}
+
+	if opts.Incremental {
+		next, ok := lookaheadIndex(opts.LastKnownIndex, true)
+
+		if !ok {
+			callback.Invoke(fmt.Sprintf("no addresses after index %d", opts.LastKnownIndex), js.Null())
+			return
+		}
+
+		if next > startIndex {
+			startIndex = next
+		}
+
+		// the previous recovery's index is kept until a newer address is found, an older address
+		// found by an additional range must not replace it
+		lastIndex = opts.LastKnownIndex
+		hasUsage = true
+	}
+
+	addressCount, maxEmptyRounds = effectiveRoundSize(addressCount, maxEmptyRounds, opts.MinRoundSize)
+
+	// the address gap limit replaces the empty round limit
+	if opts.AddressGapLimit != 0 {
+		maxEmptyRounds = math.MaxUint64
+	}
+
+	onRound := func(res recoveryResults) error {
+		// verify before anything is sent so a mismatched address is never stored
+		if opts.Verify {
+			if err := verifyRecoveredAddresses(w, res.Addresses); err != nil {
+				return err
 			}
 		}
 
 		usedTotal += uint64(len(res.Addresses))
 
-		if res.LastUsedIndex > lastIndex {
+		// a round without used addresses reports index 0, it must not replace the usage type
+		if len(res.Addresses) != 0 && (!hasUsage || res.LastUsedIndex > lastIndex) {
 			lastIndex = res.LastUsedIndex
 			lastUsageType = res.LastUsedType
+			hasUsage = true
 		}
 
-		data, err := interfaceToJSON(map[string]interface{}{
-			"found":     len(res.Addresses),
-			"addresses": res.Addresses,
-			"index":     lastIndex,
-		})
+		mergeAssetIndices(lastAssetIndex, res.LastAssetIndex)
 
-		if err != nil {
+		if opts.ResendAll {
+			all = append(all, res.Addresses...)
+		}
+
+		// coalesce the results of rounds completed within the progress interval into one event
+		pending = append(pending, res.Addresses...)
+
+		if time.Since(lastProgress) < progressInterval {
+			return nil
+		}
+
+		for _, chunk := range chunkAddresses(pending, progressChunkSize) {
+			data, err := encodePayload(map[string]interface{}{
+				"found":       len(chunk),
+				"addresses":   chunk,
+				"index":       lastIndex,
+				"asset_index": lastAssetIndex,
+			}, opts.Compress)
+
+			if err != nil {
+				return err
+			}
+
+			callback.Invoke("progress", data)
+		}
+
+		pending = nil
+		lastProgress = time.Now()
+
+		return nil
+	}
+
+	budget := newRetryBudget(opts.MaxRetries)
+
+	// a request aborted by a cancel fails the scan, report it as cancelled instead
+	checkErr := func(err error) bool {
+		switch {
+		case err == nil, ctx.Err() != nil:
+		case errors.Is(err, errRetriesExhausted):
+			incomplete = true
+		default:
 			callback.Invoke(err.Error(), js.Null())
+			return false
+		}
+
+		return true
+	}
+
+	if !checkErr(scanAddresses(ctx, w, currency, startIndex, 0, maxEmptyRounds, addressCount, opts.LastKnownIndex, opts.AddressGapLimit, budget, onRound)) {
+		return
+	}
+
+	// scan every round of the additional ranges instead of stopping after empty rounds
+	for _, r := range opts.Additional {
+		if ctx.Err() != nil || incomplete {
+			break
+		}
+
+		if !checkErr(scanAddresses(ctx, w, currency, r.Start, r.End+1, math.MaxUint64, addressCount, r.End, 0, budget, onRound)) {
 			return
 		}
+	}
+
+	if opts.ResendAll {
+		pending = all
+	}
+
+	// the scan did not reach the end of the wallet, the next address may already be used
+	cancelled := ctx.Err() != nil
+	next, ok := lookaheadIndex(lastIndex, hasUsage)
+	lookahead := ok && lastUsageType == "sent" && !opts.SkipLookahead && !cancelled && !incomplete
+
+	if lookahead {
+		lastIndex = next
+
+		pending = append(pending, generateAddress(w, lastIndex))
+	}
+
+	data, err := encodePayload(map[string]interface{}{
+		"found":       usedTotal,
+		"addresses":   pending,
+		"index":       lastIndex,
+		"asset_index": lastAssetIndex,
+		"lookahead":   lookahead,
+		"cancelled":   cancelled,
+		"incomplete":  incomplete,
+	}, opts.Compress)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//RecoverIndices checks only the listed indices for usage instead of scanning a range. Useful when
+//restoring from a partial backup that lists which indices were used, the gaps between the indices
+//are not scanned
+func RecoverIndices(seed, currency string, indices []uint64, compress bool, accountOffset uint64, callback js.Value) {
+	w, err := recoverWallet(seed, currency, accountOffset)
+
+	if err != nil {
+		callback.Invoke(fmt.Errorf("unable to recover wallet: %w", err).Error(), js.Null())
+		return
+	}
+
+	used, err := scanIndices(w, currency, indices, 1e3)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	var lastIndex uint64
+
+	if len(used) != 0 {
+		lastIndex = used[len(used)-1].Index
+	}
+
+	data, err := encodePayload(map[string]interface{}{
+		"found":     len(used),
+		"addresses": used,
+		"index":     lastIndex,
+	}, compress)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//CancelRecovery cancels the RecoverAddresses or RecoverSiafundAddresses scan started with the scan
+//ID. Its in-flight requests are aborted and the scan completes with the addresses found so far,
+//scans with other IDs keep running
+func CancelRecovery(scanID string) {
+	recoveryScans.Cancel(scanID)
+}
+
+//FindGaps recovers the wallet's used addresses and returns the ranges of unused indices that fall
+//below the highest used index
+func FindGaps(seed, currency string, maxEmptyRounds, addressCount, accountOffset uint64, callback js.Value) {
+	var used []uint64
+	var lastIndex uint64
+
+	w, err := recoverWallet(seed, currency, accountOffset)
+
+	if err != nil {
+		callback.Invoke(fmt.Errorf("unable to recover wallet: %w", err).Error(), js.Null())
+		return
+	}
+
+	addressCount, maxEmptyRounds = effectiveRoundSize(addressCount, maxEmptyRounds, defaultMinRoundSize)
+
+	err = scanAddresses(context.Background(), w, currency, 0, 0, maxEmptyRounds, addressCount, 0, 0, nil, func(res recoveryResults) error {
+		for _, addr := range res.Addresses {
+			used = append(used, addr.Index)
+		}
 
-		callback.Invoke("progress", data)
+		if res.LastUsedIndex > lastIndex {
+			lastIndex = res.LastUsedIndex
+		}
+
+		callback.Invoke("progress", map[string]interface{}{
+			"found": len(used),
+			"index": lastIndex,
+		})
+
+		return nil
+	})
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(map[string]interface{}{
+		"gaps":  findGaps(used),
+		"used":  len(used),
+		"index": lastIndex,
+	})
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//hasAsset returns true if the recovered address has used the asset, see addressAssets
+func hasAsset(addr recoveredAddress, asset string) bool {
+	for _, a := range addr.Assets {
+		if a == asset {
+			return true
+		}
+	}
+
+	return false
+}
+
+//scanSiafundAddresses scans for addresses that have used siafunds addressCount at a time starting at
+//startIndex. Only siafund usage counts toward the gap, the scan stops after gapLimit consecutive
+//addresses without it no matter how many siacoin addresses are in between. onFound is called with
+//the siafund addresses of each round as they complete. Returns every siafund address found sorted
+//by index. The assets of every used address must be known, an address whose history could not be
+//loaded fails the scan instead of counting toward the gap
+func scanSiafundAddresses(ctx context.Context, w *wallet.SeedWallet, currency string, startIndex, addressCount, gapLimit uint64, budget *retryBudget, onFound func([]recoveredAddress)) (found []recoveredAddress, err error) {
+	gap := newAddressGap(startIndex, 0)
+
+	err = scanAddresses(ctx, w, currency, startIndex, 0, math.MaxUint64, addressCount, 0, 0, budget, func(res recoveryResults) error {
+		var siafunds []recoveredAddress
+
+		for _, addr := range res.Addresses {
+			// addressAssets always sets at least one asset, no assets means the history was not loaded
+			if len(addr.Assets) == 0 {
+				return fmt.Errorf("unable to get the assets of address %d", addr.Index)
+			}
+
+			if hasAsset(addr, "siafund") {
+				siafunds = append(siafunds, addr)
+			}
+		}
+
+		found = append(found, siafunds...)
+
+		if len(siafunds) != 0 {
+			onFound(siafunds)
+		}
+
+		res.Addresses = siafunds
+
+		if unused := gap.Add(res); unused >= gapLimit {
+			return errSiafundGapReached
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, errSiafundGapReached) {
+		err = nil
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Index < found[j].Index
+	})
+
+	return
+}
+
+//RecoverSiafundAddresses scans only for the addresses that have used siafunds. Siafund wallets
+//usually use few addresses spread far apart, a recovery tuned for siacoins can stop before reaching
+//them, so only siafund usage resets the gap and the default gap limit is larger, see
+//defaultSiafundGapLimit. A gapLimit of 0 uses the default. Siacoin only addresses are still
+//checked but never returned. Usage is detected from the balance and full history of each used
+//address, see addressAssets, so an address that has spent all of its siafunds is still found.
+//
+//Progress is sent each time siafund addresses are found. CancelRecovery with the scanID stops the
+//scan and the completion payload is sent with cancelled set, a scan that used up maxRetries
+//completes with incomplete set
+func RecoverSiafundAddresses(seed, currency, scanID string, startIndex, addressCount, gapLimit, maxRetries, accountOffset uint64, callback js.Value) {
+	var lastIndex uint64
+	var total int
+	var incomplete bool
+
+	w, err := recoverWallet(seed, currency, accountOffset)
+
+	if err != nil {
+		callback.Invoke(fmt.Errorf("unable to recover wallet: %w", err).Error(), js.Null())
+		return
+	}
+
+	if gapLimit == 0 {
+		gapLimit = defaultSiafundGapLimit
 	}
 
-	var additional []recoveredAddress
+	addressCount, _ = effectiveRoundSize(addressCount, 0, defaultMinRoundSize)
+
+	ctx, release := recoveryScans.Context(scanID)
+	defer release()
 
-	if lastUsageType == "sent" {
-		lastIndex++
+	found, err := scanSiafundAddresses(ctx, w, currency, startIndex, addressCount, gapLimit, newRetryBudget(maxRetries), func(addresses []recoveredAddress) {
+		total += len(addresses)
 
-		additional = append(additional, generateAddress(w, lastIndex))
+		for _, addr := range addresses {
+			if addr.Index > lastIndex {
+				lastIndex = addr.Index
+			}
+		}
+
+		callback.Invoke("progress", map[string]interface{}{
+			"found": total,
+			"index": lastIndex,
+		})
+	})
+
+	switch {
+	case err == nil, ctx.Err() != nil:
+	case errors.Is(err, errRetriesExhausted):
+		incomplete = true
+	default:
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	if len(found) != 0 {
+		lastIndex = found[len(found)-1].Index
 	}
 
 	data, err := interfaceToJSON(map[string]interface{}{
-		"addresses": additional,
-		"index":     lastIndex,
+		"found":      len(found),
+		"addresses":  found,
+		"index":      lastIndex,
+		"cancelled":  ctx.Err() != nil,
+		"incomplete": incomplete,
 	})
 
 	if err != nil {
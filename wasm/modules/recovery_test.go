@@ -0,0 +1,1092 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall/js"
+	"testing"
+	"time"
+
+	"github.com/siacentral/apisdkgo"
+	apitypes "github.com/siacentral/apisdkgo/types"
+	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
+)
+
+func syntheticRound(n int) []recoveredAddress {
+	addresses := make([]recoveredAddress, n)
+
+	for i := range addresses {
+		addresses[i] = recoveredAddress{
+			Address:   fmt.Sprintf("%076x", i),
+			UsageType: "received",
+			Index:     uint64(i),
+			UnlockConditions: wallet.UnlockConditions{
+				PublicKeys:         []string{fmt.Sprintf("ed25519:%064x", i)},
+				SignaturesRequired: 1,
+			},
+		}
+	}
+
+	return addresses
+}
+
+func TestChunkAddresses(t *testing.T) {
+	tests := []struct {
+		count, size, chunks int
+	}{
+		{0, 10, 1},
+		{5, 10, 1},
+		{10, 10, 1},
+		{11, 10, 2},
+		{25, 10, 3},
+	}
+
+	for _, test := range tests {
+		var total int
+
+		chunks := chunkAddresses(syntheticRound(test.count), test.size)
+
+		if len(chunks) != test.chunks {
+			t.Errorf("expected %d chunks for %d addresses, got %d", test.chunks, test.count, len(chunks))
+		}
+
+		for _, chunk := range chunks {
+			if len(chunk) > test.size {
+				t.Errorf("chunk has %d addresses, expected at most %d", len(chunk), test.size)
+			}
+
+			total += len(chunk)
+		}
+
+		if total != test.count {
+			t.Errorf("expected %d total addresses, got %d", test.count, total)
+		}
+	}
+}
+
+func TestFindGaps(t *testing.T) {
+	tests := []struct {
+		used []uint64
+		gaps []IndexRange
+	}{
+		{nil, nil},
+		{[]uint64{0, 1, 2}, nil},
+		{[]uint64{3}, []IndexRange{{0, 2}}},
+		{[]uint64{0, 5, 2, 2, 9}, []IndexRange{{1, 1}, {3, 4}, {6, 8}}},
+	}
+
+	for _, test := range tests {
+		gaps := findGaps(test.used)
+
+		if len(gaps) != len(test.gaps) {
+			t.Fatalf("expected gaps %v for %v, got %v", test.gaps, test.used, gaps)
+		}
+
+		for i := range gaps {
+			if gaps[i] != test.gaps[i] {
+				t.Fatalf("expected gaps %v for %v, got %v", test.gaps, test.used, gaps)
+			}
+		}
+	}
+}
+
+func BenchmarkProgressEncoding(b *testing.B) {
+	addresses := syntheticRound(50000)
+
+	b.Run("full", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			interfaceToJSON(map[string]interface{}{
+				"found":     len(addresses),
+				"addresses": addresses,
+			})
+		}
+	})
+	b.Run("chunked", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, chunk := range chunkAddresses(addresses, progressChunkSize) {
+				interfaceToJSON(map[string]interface{}{
+					"found":     len(chunk),
+					"addresses": chunk,
+				})
+			}
+		}
+	})
+}
+
+func TestDerivationRate(t *testing.T) {
+	if rate := derivationRate(500, 2*time.Second); rate != 250 {
+		t.Fatalf("expected 250 addresses per second, got %f", rate)
+	} else if rate := derivationRate(500, 0); rate != 0 {
+		t.Fatalf("expected an unmeasurable duration to return 0, got %f", rate)
+	}
+}
+
+func TestEstimateScanDuration(t *testing.T) {
+	best, worst := estimateScanDuration(10, 100*time.Millisecond, time.Second)
+
+	// 10 rounds of derivation, the requests of 5 workers overlap in 2 batches
+	if best != 3*time.Second {
+		t.Fatalf("expected best case of 3s, got %s", best)
+	} else if worst != 11*time.Second {
+		t.Fatalf("expected worst case of 11s, got %s", worst)
+	}
+}
+
+func TestEffectiveRoundSize(t *testing.T) {
+	tests := []struct {
+		addressCount, maxEmptyRounds, minRoundSize uint64
+		count, rounds                              uint64
+	}{
+		{2500, 10, 0, 2500, 10},
+		{100, 10, 0, 100, 10},
+		{10, 50, 0, 100, 5},
+		{10, 55, 0, 100, 6},
+		{10, 1, 0, 100, 1},
+		{10, 0, 0, 100, 0},
+		{10, 50, 1, 10, 50},
+		{10, 50, 250, 250, 2},
+		{10, math.MaxUint64, 0, 100, math.MaxUint64},
+	}
+
+	for _, tt := range tests {
+		count, rounds := effectiveRoundSize(tt.addressCount, tt.maxEmptyRounds, tt.minRoundSize)
+
+		if count != tt.count || rounds != tt.rounds {
+			t.Fatalf("effectiveRoundSize(%d, %d, %d): expected %d x %d, got %d x %d", tt.addressCount, tt.maxEmptyRounds, tt.minRoundSize, tt.count, tt.rounds, count, rounds)
+		}
+
+		// the floored scan must cover at least the requested gap of unused addresses
+		if tt.maxEmptyRounds != math.MaxUint64 && count*rounds < tt.addressCount*tt.maxEmptyRounds {
+			t.Fatalf("effectiveRoundSize(%d, %d, %d): gap of %d is smaller than the requested %d", tt.addressCount, tt.maxEmptyRounds, tt.minRoundSize, count*rounds, tt.addressCount*tt.maxEmptyRounds)
+		}
+	}
+}
+
+func TestUniqueAddresses(t *testing.T) {
+	unique := newUniqueAddresses()
+
+	if err := unique.Add("a", 1); err != nil {
+		t.Fatal(err)
+	} else if err := unique.Add("a", 1); err != nil {
+		t.Fatal("expected the same index to be accepted again")
+	} else if err := unique.Add("b", 2); err != nil {
+		t.Fatal(err)
+	} else if err := unique.Add("a", 3); err == nil {
+		t.Fatal("expected a collision between indices 1 and 3")
+	}
+}
+
+//usedAddressTransport responds to used address requests with the usage type of any requested
+//address in used
+func usedAddressTransport(used map[string]string) *cannedTransport {
+	return &cannedTransport{
+		responses: map[string]string{
+			"/v2/explorer/blocks":  `{"type":"success","block":{"height":1000}}`,
+			"/v2/wallet/addresses": `{"type":"success"}`,
+		},
+		handlers: map[string]func(*http.Request) string{
+			"/v2/wallet/addresses/used": func(req *http.Request) string {
+				var body struct {
+					Addresses []string `json:"addresses"`
+				}
+
+				if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+					return `{"type":"error","message":"bad request"}`
+				}
+
+				resp := usedAddressesResp{
+					Addresses: []apitypes.AddressUsage{},
+				}
+				resp.Type = "success"
+
+				for _, addr := range body.Addresses {
+					if usage, exists := used[addr]; exists {
+						resp.Addresses = append(resp.Addresses, apitypes.AddressUsage{Address: addr, UsageType: usage})
+					}
+				}
+
+				buf, _ := json.Marshal(resp)
+				return string(buf)
+			},
+		},
+	}
+}
+
+//spentSiafundHistory handles address balance requests with a history where each of the addresses
+//received siafunds, spent them, then received siacoins. The latest transactions only move
+//siacoins. The history is paged by the limit and page of the request
+func spentSiafundHistory(addresses ...string) func(*http.Request) string {
+	var history []apitypes.Transaction
+
+	for _, addr := range addresses {
+		history = append(history, apitypes.Transaction{ID: "siacoin-" + addr, SiacoinOutputs: []apitypes.SiacoinOutput{{UnlockHash: addr}}})
+	}
+
+	for _, addr := range addresses {
+		history = append(history, apitypes.Transaction{ID: "spend-" + addr, SiafundInputs: []apitypes.SiafundInput{{SiafundOutput: apitypes.SiafundOutput{UnlockHash: addr}}}})
+	}
+
+	for _, addr := range addresses {
+		history = append(history, apitypes.Transaction{ID: "receive-" + addr, SiafundOutputs: []apitypes.SiafundOutput{{UnlockHash: addr}}})
+	}
+
+	return func(req *http.Request) string {
+		limit, _ := strconv.Atoi(req.URL.Query().Get("limit"))
+		page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+
+		var resp apisdkgo.GetTransactionsResp
+		resp.Type = "success"
+
+		for i := page * limit; i < (page+1)*limit && i < len(history); i++ {
+			resp.Transactions = append(resp.Transactions, history[i])
+		}
+
+		buf, _ := json.Marshal(resp)
+		return string(buf)
+	}
+}
+
+func TestConcurrentScans(t *testing.T) {
+	phrase, err := wallet.NewBIP39RecoveryPhrase()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wallets := make([]*wallet.SeedWallet, 2)
+	for i, p := range []string{testPhrase, phrase} {
+		if wallets[i], err = wallet.RecoverBIP39Seed(p, "sc"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := [][]uint64{{3, 17}, {5}}
+	used := make(map[string]string)
+
+	for i, indices := range expected {
+		for _, index := range indices {
+			used[generateAddress(wallets[i], index).Address] = "received"
+		}
+	}
+
+	SetTransport(usedAddressTransport(used))
+	defer SetTransport(nil)
+
+	chainTips.Clear()
+	defer chainTips.Clear()
+
+	var wg sync.WaitGroup
+	found := make([][]recoveredAddress, len(wallets))
+	errs := make([]error, len(wallets))
+
+	for i, w := range wallets {
+		wg.Add(1)
+
+		go func(i int, w *wallet.SeedWallet) {
+			defer wg.Done()
+
+			errs[i] = scanAddresses(context.Background(), w, "sc", 0, 0, 3, 10, 0, 0, nil, func(res recoveryResults) error {
+				found[i] = append(found[i], res.Addresses...)
+				return nil
+			})
+		}(i, w)
+	}
+
+	wg.Wait()
+
+	for i, w := range wallets {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+
+		sort.Slice(found[i], func(a, b int) bool { return found[i][a].Index < found[i][b].Index })
+
+		if len(found[i]) != len(expected[i]) {
+			t.Fatalf("wallet %d: expected %d addresses, got %d", i, len(expected[i]), len(found[i]))
+		}
+
+		for j, addr := range found[i] {
+			if addr.Index != expected[i][j] || addr.Address != generateAddress(w, addr.Index).Address {
+				t.Fatalf("wallet %d: unexpected address %s at index %d", i, addr.Address, addr.Index)
+			}
+		}
+	}
+}
+
+func TestScanIndices(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	used := map[string]string{
+		generateAddress(w, 4).Address:     "received",
+		generateAddress(w, 9000).Address:  "sent",
+		generateAddress(w, 70000).Address: "received",
+		// never requested so it must not be found
+		generateAddress(w, 5).Address: "received",
+	}
+
+	canned := usedAddressTransport(used)
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	chainTips.Clear()
+	defer chainTips.Clear()
+
+	found, err := scanIndices(w, "sc", []uint64{70000, 4, 9000, 4, 12, 500000}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []uint64{4, 9000, 70000}
+	if len(found) != len(expected) {
+		t.Fatalf("expected %d used addresses, got %d", len(expected), len(found))
+	}
+
+	for i, index := range expected {
+		if found[i].Index != index {
+			t.Fatalf("expected used address %d at index %d, got %d", i, index, found[i].Index)
+		}
+	}
+
+	// 5 unique indices are checked in 3 batches of at most 2
+	var usedRequests int
+	for _, req := range canned.requests {
+		if req.URL.Path == "/v2/wallet/addresses/used" {
+			usedRequests++
+		}
+	}
+
+	if usedRequests != 3 {
+		t.Fatalf("expected 3 used address requests, got %d", usedRequests)
+	}
+}
+
+func TestCancelRecovery(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	used := map[string]string{
+		generateAddress(w, 3).Address: "received",
+		generateAddress(w, 7).Address: "sent",
+	}
+
+	SetTransport(usedAddressTransport(used))
+	defer SetTransport(nil)
+
+	chainTips.Clear()
+	defer chainTips.Clear()
+
+	done := make(chan map[string]interface{}, 1)
+	callback := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if args[0].Type() == js.TypeString {
+			if args[0].String() == "progress" {
+				// cancelling another scan must not stop this one before it finds the used addresses
+				if args[1].Get("found").Int() == 0 {
+					CancelRecovery("other")
+				} else {
+					CancelRecovery("scan")
+				}
+
+				return nil
+			}
+
+			t.Error(args[0].String())
+			done <- nil
+			return nil
+		}
+
+		done <- map[string]interface{}{
+			"found":     args[1].Get("found").Int(),
+			"addresses": args[1].Get("addresses").Length(),
+			"cancelled": args[1].Get("cancelled").Bool(),
+			"lookahead": args[1].Get("lookahead").Bool(),
+		}
+
+		return nil
+	})
+	defer callback.Release()
+
+	// without the cancel the scan would check 1000 empty rounds
+	go RecoverAddresses(testPhrase, "sc", RecoveryOptions{MaxEmptyRounds: 1000, AddressCount: 10, ResendAll: true, MinRoundSize: 1, ScanID: "scan"}, callback.Value)
+
+	var resp map[string]interface{}
+
+	select {
+	case resp = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("recovery was not cancelled")
+	}
+
+	if resp == nil {
+		t.FailNow()
+	} else if !resp["cancelled"].(bool) {
+		t.Fatal("expected the completion payload to be flagged cancelled")
+	} else if resp["found"] != 2 || resp["addresses"] != 2 {
+		t.Fatalf("expected the 2 addresses found before the cancel, got %v", resp)
+	} else if resp["lookahead"].(bool) {
+		t.Fatal("expected a cancelled scan to not add the lookahead address")
+	}
+}
+
+func TestCancelGroup(t *testing.T) {
+	var g cancelGroup
+
+	a, releaseA := g.Context("a")
+	b, releaseB := g.Context("b")
+	defer releaseB()
+
+	g.Cancel("a")
+
+	if a.Err() == nil {
+		t.Fatal("expected scan a to be cancelled")
+	} else if b.Err() != nil {
+		t.Fatal("expected cancelling scan a to not cancel scan b")
+	}
+
+	releaseA()
+
+	// a cancel sent before the scan starts is not lost
+	g.Cancel("c")
+
+	c, releaseC := g.Context("c")
+	defer releaseC()
+
+	if c.Err() == nil {
+		t.Fatal("expected scan c to start cancelled")
+	}
+
+	// a released ID can be reused by a new scan
+	a, releaseA = g.Context("a")
+	defer releaseA()
+
+	if a.Err() != nil {
+		t.Fatal("expected a new scan a to not be cancelled")
+	}
+
+	anon, releaseAnon := g.Context("")
+	defer releaseAnon()
+
+	g.Cancel("")
+
+	if anon.Err() != nil {
+		t.Fatal("expected a scan without an ID to not be cancelled")
+	}
+}
+
+func TestLookaheadIndex(t *testing.T) {
+	if _, ok := lookaheadIndex(0, false); ok {
+		t.Fatal("expected no lookahead without usage")
+	} else if next, ok := lookaheadIndex(0, true); !ok || next != 1 {
+		t.Fatalf("expected lookahead 1 after index 0, got %d", next)
+	} else if next, ok := lookaheadIndex(41, true); !ok || next != 42 {
+		t.Fatalf("expected lookahead 42, got %d", next)
+	} else if _, ok := lookaheadIndex(math.MaxUint64, true); ok {
+		t.Fatal("expected no lookahead when the index would overflow")
+	}
+}
+
+//recoverLookahead runs a full scan of a wallet with the used addresses and returns the reported
+//index, whether the lookahead was added, and the index of every returned address
+func recoverLookahead(t *testing.T, used map[string]string) (index uint64, lookahead bool, indices []uint64) {
+	return recoverScan(t, used, 0, false)
+}
+
+//recoverScan runs a scan of a wallet with the used addresses from lastKnownIndex, see
+//recoverLookahead
+func recoverScan(t *testing.T, used map[string]string, lastKnownIndex uint64, incremental bool) (index uint64, lookahead bool, indices []uint64) {
+	SetTransport(usedAddressTransport(used))
+	defer SetTransport(nil)
+
+	chainTips.Clear()
+	defer chainTips.Clear()
+
+	done := make(chan js.Value, 1)
+	callback := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if args[0].Type() == js.TypeString {
+			if args[0].String() != "progress" {
+				t.Error(args[0].String())
+				done <- js.Null()
+			}
+
+			return nil
+		}
+
+		done <- args[1]
+		return nil
+	})
+	defer callback.Release()
+
+	go RecoverAddresses(testPhrase, "sc", RecoveryOptions{
+		MaxEmptyRounds: 2,
+		AddressCount:   10,
+		LastKnownIndex: lastKnownIndex,
+		ResendAll:      true,
+		MinRoundSize:   1,
+		Incremental:    incremental,
+	}, callback.Value)
+
+	var resp js.Value
+
+	select {
+	case resp = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("recovery did not complete")
+	}
+
+	if resp.IsNull() {
+		t.FailNow()
+	}
+
+	// a scan without any addresses to send returns null
+	if addresses := resp.Get("addresses"); !addresses.IsNull() {
+		for i := 0; i < addresses.Length(); i++ {
+			indices = append(indices, uint64(addresses.Index(i).Get("index").Int()))
+		}
+	}
+
+	return uint64(resp.Get("index").Int()), resp.Get("lookahead").Bool(), indices
+}
+
+func TestRecoverLookahead(t *testing.T) {
+	w, err := recoverWallet(testPhrase, "sc", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if index, lookahead, indices := recoverLookahead(t, nil); lookahead || index != 0 || len(indices) != 0 {
+		t.Fatalf("expected no lookahead for an unused wallet, got index %d lookahead %t addresses %v", index, lookahead, indices)
+	}
+
+	// index 0 is both the first and the last used address
+	used := map[string]string{generateAddress(w, 0).Address: "sent"}
+	if index, lookahead, indices := recoverLookahead(t, used); !lookahead || index != 1 || len(indices) != 2 || indices[1] != 1 {
+		t.Fatalf("expected lookahead at index 1, got index %d lookahead %t addresses %v", index, lookahead, indices)
+	}
+
+	used = map[string]string{generateAddress(w, 0).Address: "received"}
+	if index, lookahead, _ := recoverLookahead(t, used); lookahead || index != 0 {
+		t.Fatalf("expected no lookahead after a receive, got index %d lookahead %t", index, lookahead)
+	}
+
+	used = map[string]string{generateAddress(w, 14).Address: "sent"}
+	if index, lookahead, indices := recoverLookahead(t, used); !lookahead || index != 15 || len(indices) != 2 || indices[0] != 14 || indices[1] != 15 {
+		t.Fatalf("expected lookahead at index 15, got index %d lookahead %t addresses %v", index, lookahead, indices)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	var nilBudget *retryBudget
+	if nilBudget.Take() || nilBudget.Exhausted() {
+		t.Fatal("expected a nil budget to allow no retries without being exhausted")
+	}
+
+	budget := newRetryBudget(100)
+
+	var wg sync.WaitGroup
+	var taken int64
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 20; j++ {
+				if budget.Take() {
+					atomic.AddInt64(&taken, 1)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if taken != 100 {
+		t.Fatalf("expected 100 retries to be taken, got %d", taken)
+	} else if !budget.Exhausted() {
+		t.Fatal("expected the budget to be exhausted")
+	}
+}
+
+func TestScanRetries(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	used := map[string]string{
+		generateAddress(w, 3).Address: "received",
+	}
+
+	chainTips.Clear()
+	defer chainTips.Clear()
+	defer SetTransport(nil)
+
+	// flakyScan scans with the first failures used address requests failing
+	flakyScan := func(failures int64, retries uint64) ([]recoveredAddress, error) {
+		canned := usedAddressTransport(used)
+		handler := canned.handlers["/v2/wallet/addresses/used"]
+		canned.handlers["/v2/wallet/addresses/used"] = func(req *http.Request) string {
+			if atomic.AddInt64(&failures, -1) >= 0 {
+				return `{"type":"error","message":"unavailable"}`
+			}
+
+			return handler(req)
+		}
+
+		SetTransport(canned)
+
+		var found []recoveredAddress
+		err := scanAddresses(context.Background(), w, "sc", 0, 0, 3, 10, 0, 0, newRetryBudget(retries), func(res recoveryResults) error {
+			found = append(found, res.Addresses...)
+			return nil
+		})
+
+		return found, err
+	}
+
+	found, err := flakyScan(2, 5)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(found) != 1 || found[0].Index != 3 {
+		t.Fatalf("expected the retried scan to find index 3, got %v", found)
+	}
+
+	if _, err := flakyScan(100, 3); !errors.Is(err, errRetriesExhausted) {
+		t.Fatalf("expected the retry budget to be exhausted, got %v", err)
+	}
+}
+
+func TestAddressGap(t *testing.T) {
+	gap := newAddressGap(0, 0)
+	round := func(n uint64, used ...uint64) recoveryResults {
+		res := recoveryResults{Round: n, Start: n * 10, End: (n + 1) * 10}
+
+		for _, index := range used {
+			res.Addresses = append(res.Addresses, recoveredAddress{Index: index})
+		}
+
+		return res
+	}
+
+	// later rounds are not counted until the rounds before them complete
+	if unused := gap.Add(round(1)); unused != 0 {
+		t.Fatalf("expected 0 unused addresses, got %d", unused)
+	} else if unused := gap.Add(round(0, 4)); unused != 15 {
+		t.Fatalf("expected 15 unused addresses, got %d", unused)
+	} else if unused := gap.Add(round(2, 25)); unused != 4 {
+		t.Fatalf("expected 4 unused addresses, got %d", unused)
+	}
+
+	// addresses up to the last known index are never unused
+	gap = newAddressGap(0, 35)
+
+	for n := uint64(0); n < 3; n++ {
+		if unused := gap.Add(round(n)); unused != 0 {
+			t.Fatalf("round %d: expected 0 unused addresses, got %d", n, unused)
+		}
+	}
+
+	if unused := gap.Add(round(3)); unused != 4 {
+		t.Fatalf("expected 4 unused addresses, got %d", unused)
+	}
+}
+
+func TestScanSlowEarlierRound(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slow := generateAddress(w, 3).Address
+	release := make(chan struct{})
+	canned := usedAddressTransport(map[string]string{slow: "received"})
+	used := canned.handlers["/v2/wallet/addresses/used"]
+
+	// the first round is held until the later empty rounds reached the limit, an aborted request
+	// fails like a cancelled fetch would
+	canned.handlers["/v2/wallet/addresses/used"] = func(req *http.Request) string {
+		buf, _ := ioutil.ReadAll(req.Body)
+		req.Body = ioutil.NopCloser(bytes.NewReader(buf))
+
+		if strings.Contains(string(buf), slow) {
+			select {
+			case <-release:
+			case <-time.After(5 * time.Second):
+			}
+
+			if req.Context().Err() != nil {
+				return `{"type":"error","message":"aborted"}`
+			}
+		}
+
+		return used(req)
+	}
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	chainTips.Clear()
+	defer chainTips.Clear()
+
+	var found []uint64
+	var empty int
+
+	err = scanAddresses(context.Background(), w, "sc", 0, 0, 2, 10, 0, 0, nil, func(res recoveryResults) error {
+		if len(res.Addresses) == 0 {
+			if empty++; empty == 2 {
+				close(release)
+			}
+		}
+
+		for _, addr := range res.Addresses {
+			found = append(found, addr.Index)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	} else if len(found) != 1 || found[0] != 3 {
+		t.Fatalf("expected the slow round's address at index 3, got %v", found)
+	}
+}
+
+func TestScanWithoutBalances(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the block height and balance requests fail, the used addresses are still found
+	canned := usedAddressTransport(map[string]string{generateAddress(w, 4).Address: "received"})
+	delete(canned.responses, "/v2/explorer/blocks")
+	delete(canned.responses, "/v2/wallet/addresses")
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	chainTips.Clear()
+	defer chainTips.Clear()
+
+	var found []uint64
+
+	err = scanAddresses(context.Background(), w, "sc", 0, 0, 2, 10, 0, 0, newRetryBudget(0), func(res recoveryResults) error {
+		for _, addr := range res.Addresses {
+			found = append(found, addr.Index)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	} else if len(found) != 1 || found[0] != 4 {
+		t.Fatalf("expected the address at index 4, got %v", found)
+	}
+}
+
+func TestScanAddressGapLimit(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	used := make(map[string]string)
+
+	for _, index := range []uint64{3, 17, 500} {
+		used[generateAddress(w, index).Address] = "received"
+	}
+
+	SetTransport(usedAddressTransport(used))
+	defer SetTransport(nil)
+
+	chainTips.Clear()
+	defer chainTips.Clear()
+
+	for _, test := range []struct {
+		gapLimit uint64
+		expected int
+	}{
+		{20, 2},
+		{500, 3},
+	} {
+		var found []recoveredAddress
+
+		// the empty round limit alone would reach index 500
+		err := scanAddresses(context.Background(), w, "sc", 0, 0, 100, 10, 0, test.gapLimit, nil, func(res recoveryResults) error {
+			found = append(found, res.Addresses...)
+			return nil
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		} else if len(found) != test.expected {
+			t.Fatalf("gap limit %d: expected %d addresses, got %d", test.gapLimit, test.expected, len(found))
+		}
+	}
+}
+
+func TestVerifyRecoveredAddresses(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addresses := []recoveredAddress{generateAddress(w, 3), generateAddress(w, 7)}
+
+	if err := verifyRecoveredAddresses(w, addresses); err != nil {
+		t.Fatal(err)
+	}
+
+	// an address associated with the wrong index
+	addresses[1].Index = 8
+
+	if err := verifyRecoveredAddresses(w, addresses); err == nil {
+		t.Fatal("expected mismatched index to fail")
+	}
+
+	// unlock conditions from a different address
+	addresses[1] = generateAddress(w, 7)
+	addresses[1].UnlockConditions = addresses[0].UnlockConditions
+
+	if err := verifyRecoveredAddresses(w, addresses); err == nil {
+		t.Fatal("expected mismatched unlock conditions to fail")
+	}
+}
+
+func TestAddressAssets(t *testing.T) {
+	recovered := syntheticRound(4)
+
+	var resp apisdkgo.GetTransactionsResp
+
+	resp.UnspentSiacoinOutputs = []apitypes.SiacoinOutput{{UnlockHash: recovered[0].Address}}
+	resp.UnspentSiafundOutputs = []apitypes.SiafundOutput{{UnlockHash: recovered[0].Address}, {UnlockHash: recovered[1].Address}}
+	resp.Transactions = []apitypes.Transaction{
+		{SiafundInputs: []apitypes.SiafundInput{{SiafundOutput: apitypes.SiafundOutput{UnlockHash: recovered[2].Address}}}},
+	}
+
+	addressAssets(resp, recovered)
+
+	expected := [][]string{
+		{"siacoin", "siafund"},
+		{"siafund"},
+		{"siafund"},
+		// no evidence of either asset
+		{"siacoin"},
+	}
+
+	for i, assets := range expected {
+		if fmt.Sprint(recovered[i].Assets) != fmt.Sprint(assets) {
+			t.Fatalf("address %d: expected assets %v, got %v", i, assets, recovered[i].Assets)
+		}
+	}
+}
+
+func TestScanAssetIndices(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	used := make(map[string]string)
+
+	for _, index := range []uint64{2, 5, 48} {
+		used[generateAddress(w, index).Address] = "received"
+	}
+
+	// only the address at index 5 holds siafunds
+	canned := usedAddressTransport(used)
+	canned.responses["/v2/wallet/addresses"] = fmt.Sprintf(`{"type":"success","unspent_siafund_outputs":[{"unlock_hash":%q,"value":"1"}]}`, generateAddress(w, 5).Address)
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	chainTips.Clear()
+	defer chainTips.Clear()
+
+	last := make(map[string]uint64)
+
+	err = scanAddresses(context.Background(), w, "sc", 0, 0, 10, 10, 0, 0, nil, func(res recoveryResults) error {
+		mergeAssetIndices(last, res.LastAssetIndex)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	} else if len(last) != 2 || last["siacoin"] != 48 || last["siafund"] != 5 {
+		t.Fatalf("expected siacoin index 48 and siafund index 5, got %v", last)
+	}
+}
+
+func TestScanSpentSiafundAddress(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	used := map[string]string{
+		generateAddress(w, 2).Address: "received",
+		generateAddress(w, 5).Address: "sent",
+	}
+
+	// the address at index 5 no longer holds siafunds and its latest transaction only moves siacoins
+	canned := usedAddressTransport(used)
+	canned.handlers["/v2/wallet/addresses"] = spentSiafundHistory(generateAddress(w, 5).Address)
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	chainTips.Clear()
+	defer chainTips.Clear()
+
+	assets := make(map[uint64][]string)
+
+	err = scanAddresses(context.Background(), w, "sc", 0, 0, 10, 10, 0, 0, nil, func(res recoveryResults) error {
+		for _, addr := range res.Addresses {
+			assets[addr.Index] = addr.Assets
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	} else if fmt.Sprint(assets[5]) != "[siacoin siafund]" {
+		t.Fatalf("expected the spent siafund address to have used both assets, got %v", assets[5])
+	} else if fmt.Sprint(assets[2]) != "[siacoin]" {
+		t.Fatalf("expected the address without siafund history to only use siacoins, got %v", assets[2])
+	}
+}
+
+func TestRecoverIncremental(t *testing.T) {
+	w, err := recoverWallet(testPhrase, "sc", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	used := map[string]string{
+		generateAddress(w, 3).Address: "received",
+		generateAddress(w, 7).Address: "received",
+	}
+
+	// nothing new since the previous recovery keeps its index
+	if index, lookahead, indices := recoverScan(t, used, 7, true); lookahead || index != 7 || len(indices) != 0 {
+		t.Fatalf("expected no new addresses after index 7, got index %d lookahead %t addresses %v", index, lookahead, indices)
+	}
+
+	// the addresses found by the previous recovery are not returned again
+	used[generateAddress(w, 25).Address] = "received"
+	if index, _, indices := recoverScan(t, used, 7, true); index != 25 || len(indices) != 1 || indices[0] != 25 {
+		t.Fatalf("expected only the new address at index 25, got index %d addresses %v", index, indices)
+	}
+
+	// an additional range below the previous recovery's index does not lower it
+	SetTransport(usedAddressTransport(map[string]string{generateAddress(w, 3).Address: "received"}))
+	defer SetTransport(nil)
+
+	chainTips.Clear()
+	defer chainTips.Clear()
+
+	errMsg, resp := invokeCallback(t, func(callback js.Value) {
+		RecoverAddresses(testPhrase, "sc", RecoveryOptions{
+			MaxEmptyRounds: 2,
+			AddressCount:   10,
+			LastKnownIndex: 7,
+			Additional:     []IndexRange{{Start: 0, End: 5}},
+			ResendAll:      true,
+			MinRoundSize:   1,
+			Incremental:    true,
+		}, callback)
+	})
+	if errMsg != "" {
+		t.Fatal(errMsg)
+	} else if index := resp.Get("index").Int(); index != 7 {
+		t.Fatalf("expected the previous index 7 to be kept, got %d", index)
+	} else if addresses := resp.Get("addresses"); addresses.IsNull() || addresses.Length() != 1 || addresses.Index(0).Get("index").Int() != 3 {
+		t.Fatal("expected the address at index 3 to be found by the additional range")
+	}
+}
+
+func TestScanSiafundAddresses(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	used := make(map[string]string)
+
+	for _, index := range []uint64{2, 5, 48, 300, 600} {
+		used[generateAddress(w, index).Address] = "received"
+	}
+
+	// only the addresses at index 5 and 600 used siafunds, both have spent them since
+	canned := usedAddressTransport(used)
+	canned.handlers["/v2/wallet/addresses"] = spentSiafundHistory(generateAddress(w, 5).Address, generateAddress(w, 600).Address)
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	chainTips.Clear()
+	defer chainTips.Clear()
+
+	for _, test := range []struct {
+		gapLimit uint64
+		expected []uint64
+	}{
+		// the siacoin address at index 300 does not extend the gap to reach index 600
+		{400, []uint64{5}},
+		{700, []uint64{5, 600}},
+	} {
+		var progress int
+
+		found, err := scanSiafundAddresses(context.Background(), w, "sc", 0, 10, test.gapLimit, nil, func(addresses []recoveredAddress) {
+			progress += len(addresses)
+		})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(found) != len(test.expected) || progress != len(found) {
+			t.Fatalf("gap limit %d: expected %v, got %v with %d in progress", test.gapLimit, test.expected, found, progress)
+		}
+
+		for i, addr := range found {
+			if addr.Index != test.expected[i] {
+				t.Fatalf("gap limit %d: expected %v, got %v", test.gapLimit, test.expected, found)
+			}
+		}
+	}
+}
+
+func TestRecoverAddressesInvalidRanges(t *testing.T) {
+	for _, r := range []IndexRange{
+		{Start: 10, End: 5},
+		// End+1 would wrap to 0, the unbounded end of a scan
+		{Start: 10, End: math.MaxUint64},
+	} {
+		errMsg, _ := invokeCallback(t, func(callback js.Value) {
+			RecoverAddresses(testPhrase, "sc", RecoveryOptions{MaxEmptyRounds: 2, AddressCount: 10, Additional: []IndexRange{r}, MinRoundSize: 1}, callback)
+		})
+
+		if !strings.HasPrefix(errMsg, "invalid range") {
+			t.Fatalf("range %d-%d: expected an invalid range error, got %q", r.Start, r.End, errMsg)
+		}
+	}
+}
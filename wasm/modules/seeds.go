@@ -1,6 +1,10 @@
 package modules
 
 import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
 	"strings"
 
 	"syscall/js"
@@ -8,6 +12,16 @@ import (
 	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
 )
 
+type (
+	//addressVerification whether an address belongs to the seed and the index it is derived at
+	addressVerification struct {
+		Address string `json:"address"`
+		Owned   bool   `json:"owned"`
+		Index   uint64 `json:"index"`
+		Error   string `json:"error,omitempty"`
+	}
+)
+
 //GenerateSeed generates a new 12 or 29 word seed phrase
 func GenerateSeed(seedType string, callback js.Value) {
 	var phrase string
@@ -30,9 +44,22 @@ func GenerateSeed(seedType string, callback js.Value) {
 	callback.Invoke(js.Null(), phrase)
 }
 
+//GetWordlist returns the ordered wordlist of the seed type so the frontend can validate and
+//autocomplete seed words without calling into WASM for each word
+func GetWordlist(seedType string, callback js.Value) {
+	words := wallet.Wordlist(seedType)
+	resp := make([]interface{}, len(words))
+
+	for i, word := range words {
+		resp[i] = word
+	}
+
+	callback.Invoke(js.Null(), resp)
+}
+
 //GetAddresses generates n addresses using the seed phrase starting at index i
-func GetAddresses(phrase, currency string, i uint64, n uint64, callback js.Value) {
-	w, err := recoverWallet(phrase, currency)
+func GetAddresses(phrase, currency string, i uint64, n uint64, accountOffset uint64, callback js.Value) {
+	w, err := recoverWallet(phrase, currency, accountOffset)
 
 	if err != nil {
 		callback.Invoke(err.Error(), js.Null())
@@ -61,3 +88,326 @@ func GetAddresses(phrase, currency string, i uint64, n uint64, callback js.Value
 
 	callback.Invoke(js.Null(), addresses)
 }
+
+//addressMap returns the n addresses of the wallet starting at index i keyed by their index
+func addressMap(w *wallet.SeedWallet, i, n uint64) map[string]interface{} {
+	addresses := make(map[string]interface{}, n)
+
+	for a := uint64(0); a < n; a++ {
+		addresses[strconv.FormatUint(i+a, 10)] = w.GetAddress(i + a).UnlockConditions.UnlockHash().String()
+	}
+
+	return addresses
+}
+
+//GenerateAddressMap generates n addresses starting at index i like GetAddresses, but only returns
+//the address strings as an object keyed by index. Smaller and faster to look up for callers that do
+//not need the unlock conditions
+func GenerateAddressMap(phrase, currency string, i, n, accountOffset uint64, callback js.Value) {
+	w, err := recoverWallet(phrase, currency, accountOffset)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), addressMap(w, i, n))
+}
+
+//GenerateAddressesBatch derives the addresses at each of the indices in a single call. The indices
+//do not need to be contiguous so sparse wallets can be regenerated without deriving every address
+//in between
+func GenerateAddressesBatch(phrase, currency string, indices []uint64, accountOffset uint64, callback js.Value) {
+	w, err := recoverWallet(phrase, currency, accountOffset)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	addresses := make([]map[string]interface{}, len(indices))
+
+	for a, i := range indices {
+		key := w.GetAddress(i)
+
+		addresses[a] = map[string]interface{}{
+			"unlock_conditions": key.UnlockConditions,
+			"address":           key.UnlockConditions.UnlockHash().String(),
+			"index":             i,
+		}
+	}
+
+	data, err := interfaceToJSON(addresses)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//verifyAddresses reports which of the addresses the wallet derives at an index up to and including
+//maxIndex. A maxIndex above maxDerivedIndex is an error. Derivation stops as soon as every valid
+//address is found
+func verifyAddresses(w *wallet.SeedWallet, addresses []string, maxIndex uint64) ([]addressVerification, error) {
+	if err := checkDerivedIndex(maxIndex); err != nil {
+		return nil, err
+	}
+
+	remaining := 0
+	verified := make([]addressVerification, len(addresses))
+	lookup := make(map[string][]int)
+
+	for i, address := range addresses {
+		verified[i].Address = address
+
+		uh, err := parseAddress(address)
+
+		if err != nil {
+			verified[i].Error = err.Error()
+			continue
+		}
+
+		// duplicates are found together so they only count once
+		key := uh.String()
+
+		if len(lookup[key]) == 0 {
+			remaining++
+		}
+
+		lookup[key] = append(lookup[key], i)
+	}
+
+	for index := uint64(0); remaining > 0 && index <= maxIndex; index++ {
+		key := w.GetAddress(index).UnlockConditions.UnlockHash().String()
+
+		if matches, exists := lookup[key]; exists {
+			for _, i := range matches {
+				verified[i].Owned = true
+				verified[i].Index = index
+			}
+
+			delete(lookup, key)
+			remaining--
+		}
+	}
+
+	return verified, nil
+}
+
+//VerifyAddresses checks whether each of the addresses belongs to the seed by deriving every address
+//up to and including maxIndex, returning the index of each address that was found. A maxIndex
+//above maxDerivedIndex fails the batch. Invalid addresses are reported with an error instead of
+//failing the whole batch
+func VerifyAddresses(phrase, currency string, addresses []string, maxIndex, accountOffset uint64, callback js.Value) {
+	w, err := recoverWallet(phrase, currency, accountOffset)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	verified, err := verifyAddresses(w, addresses, maxIndex)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(verified)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//ExportWatchOnly exports the public keys of the count addresses starting at start. The export can
+//be opened with OpenWatchOnly to generate the addresses, for balance, history, and receiving,
+//without the seed
+func ExportWatchOnly(phrase, currency string, start, count, accountOffset uint64, callback js.Value) {
+	w, err := recoverWallet(phrase, currency, accountOffset)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	export, err := w.ExportWatchOnly(start, count)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(export)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//OpenWatchOnly generates every address of a watch-only export from its public keys. No secret keys
+//are derived, passing the export to a function that signs fails with wallet.ErrWatchOnly
+func OpenWatchOnly(exportJSON, currency string, callback js.Value) {
+	var export wallet.WatchOnlyExport
+
+	if err := json.Unmarshal([]byte(exportJSON), &export); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding export: %s", err), js.Null())
+		return
+	}
+
+	w, err := wallet.OpenWatchOnly(export, currency)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	start, end := w.Range()
+	addresses := make([]interface{}, 0, end-start)
+
+	for i := start; i < end; i++ {
+		uc, err := w.GetAddress(i)
+
+		if err != nil {
+			callback.Invoke(err.Error(), js.Null())
+			return
+		}
+
+		unlockConditions, err := interfaceToJSON(mapUnlockConditions(uc))
+
+		if err != nil {
+			callback.Invoke(err.Error(), js.Null())
+			return
+		}
+
+		addresses = append(addresses, map[string]interface{}{
+			"unlock_conditions": unlockConditions,
+			"address":           uc.UnlockHash().String(),
+			"index":             i,
+		})
+	}
+
+	callback.Invoke(js.Null(), addresses)
+}
+
+//ComputeUnlockHash returns the address of the unlock conditions without deriving them from a
+//seed. Used to verify multisig setups and watch-only unlock conditions from another source
+func ComputeUnlockHash(unlockConditionsJSON string, callback js.Value) {
+	var unlockConds wallet.UnlockConditions
+
+	if err := json.Unmarshal([]byte(unlockConditionsJSON), &unlockConds); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding unlock conditions: %s", err), js.Null())
+		return
+	}
+
+	sia, err := unmapUnlockConditions(unlockConds)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), sia.UnlockHash().String())
+}
+
+//ValidateSeed checks that the seed phrase can be recovered and reports its expected strength.
+//Invalid seeds are reported with the reason instead of returning an error
+func ValidateSeed(phrase, currency string, callback js.Value) {
+	resp := map[string]interface{}{
+		"valid": false,
+	}
+
+	if _, err := recoverWallet(phrase, currency, 0); err != nil {
+		resp["error"] = err.Error()
+		callback.Invoke(js.Null(), resp)
+		return
+	}
+
+	// the wallet recovered, so the phrase normalizes without an error
+	phrase, _ = normalizeSeed(phrase)
+	report, err := wallet.AnalyzeSeed(phrase)
+
+	if err != nil {
+		resp["error"] = err.Error()
+		callback.Invoke(js.Null(), resp)
+		return
+	}
+
+	resp["valid"] = true
+	resp["type"] = report.Type
+	resp["words"] = report.Words
+	resp["entropyBits"] = report.EntropyBits
+	resp["weak"] = report.Weak
+
+	callback.Invoke(js.Null(), resp)
+}
+
+//VerifySeedChecksum checks only the words and checksum of the seed phrase, fast enough to run as
+//the user types. A phrase that passes still needs ValidateSeed. Like ValidateSeed, invalid seeds
+//are reported with the reason instead of returning an error. The checksum does not depend on the
+//currency, it is accepted to match ValidateSeed
+func VerifySeedChecksum(phrase, currency string, callback js.Value) {
+	resp := map[string]interface{}{
+		"valid": false,
+	}
+
+	phrase, err := normalizeSeed(phrase)
+
+	if err != nil {
+		resp["error"] = err.Error()
+		callback.Invoke(js.Null(), resp)
+		return
+	}
+
+	seedType, err := wallet.VerifySeedChecksum(phrase)
+
+	if seedType != "" {
+		resp["type"] = seedType
+	}
+
+	if err != nil {
+		resp["error"] = err.Error()
+		callback.Invoke(js.Null(), resp)
+		return
+	}
+
+	resp["valid"] = true
+
+	callback.Invoke(js.Null(), resp)
+}
+
+//WalletFingerprint returns a short deterministic identifier of the seed derived from public key
+//material only, letting the UI tell imported wallets apart without storing the seed
+func WalletFingerprint(phrase, currency string, callback js.Value) {
+	w, err := recoverWallet(phrase, currency, 0)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), w.Fingerprint())
+}
+
+//GetLabelKey returns the hex encoded key for encrypting the wallet's local metadata. The key
+//cannot sign transactions so it is safe to keep unlocked while the wallet is in use
+func GetLabelKey(phrase, currency string, callback js.Value) {
+	w, err := recoverWallet(phrase, currency, 0)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	key := w.LabelKey()
+
+	callback.Invoke(js.Null(), hex.EncodeToString(key[:]))
+}
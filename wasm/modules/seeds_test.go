@@ -0,0 +1,113 @@
+package modules
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"syscall/js"
+	"testing"
+)
+
+func TestVerifyAddresses(t *testing.T) {
+	w, err := recoverWallet(testPhrase, "sc", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := w.GetAddress(0).UnlockConditions.UnlockHash().String()
+	tenth := w.GetAddress(10).UnlockConditions.UnlockHash().String()
+	beyond := w.GetAddress(50).UnlockConditions.UnlockHash().String()
+
+	verified, err := verifyAddresses(w, []string{tenth, "invalid", beyond, first, tenth}, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(verified) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(verified))
+	}
+
+	expected := []struct {
+		owned bool
+		index uint64
+	}{
+		{true, 10},
+		{false, 0},
+		{false, 0},
+		{true, 0},
+		{true, 10},
+	}
+
+	for i, exp := range expected {
+		if verified[i].Owned != exp.owned || verified[i].Index != exp.index {
+			t.Errorf("address %d: expected owned %t at %d, got %t at %d", i, exp.owned, exp.index, verified[i].Owned, verified[i].Index)
+		}
+	}
+
+	if verified[1].Error == "" {
+		t.Fatal("expected invalid address to have an error")
+	} else if verified[2].Error != "" {
+		t.Fatalf("expected address past max index to not have an error, got %s", verified[2].Error)
+	}
+
+	// max index is inclusive
+	if verified, err := verifyAddresses(w, []string{tenth}, 10); err != nil || !verified[0].Owned {
+		t.Fatalf("expected address at max index to be found, got %v", err)
+	}
+
+	// a max index past the derived index limit is refused instead of silently capped
+	if _, err := verifyAddresses(w, []string{tenth}, maxDerivedIndex); err != nil {
+		t.Fatalf("expected the derived index limit to be allowed, got %v", err)
+	} else if _, err := verifyAddresses(w, []string{tenth}, math.MaxUint64); err == nil || !strings.Contains(err.Error(), "above the maximum") {
+		t.Fatalf("expected an unbounded max index to be refused, got %v", err)
+	}
+}
+
+func TestAddressMap(t *testing.T) {
+	w, err := recoverWallet(testPhrase, "sc", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addresses := addressMap(w, 5, 3)
+	if len(addresses) != 3 {
+		t.Fatalf("expected 3 addresses, got %d", len(addresses))
+	}
+
+	for i := uint64(5); i < 8; i++ {
+		key := strconv.FormatUint(i, 10)
+		if expected := w.GetAddress(i).UnlockConditions.UnlockHash().String(); addresses[key] != expected {
+			t.Fatalf("index %s: expected %s, got %v", key, expected, addresses[key])
+		}
+	}
+}
+
+func TestValidateSeed(t *testing.T) {
+	// a sloppy paste recovers the same wallet, so it must also validate
+	words := strings.Fields(testPhrase)
+	words[0] = strings.ToUpper(words[0])
+	words[3] = strings.Title(words[3])
+	phrase := "  " + strings.Join(words[:6], " \t") + "\n" + strings.Join(words[6:], "  ") + " "
+
+	for _, p := range []string{testPhrase, phrase} {
+		errMsg, resp := invokeCallback(t, func(callback js.Value) {
+			ValidateSeed(p, "sc", callback)
+		})
+		if errMsg != "" {
+			t.Fatal(errMsg)
+		} else if !resp.Get("valid").Bool() {
+			t.Fatalf("expected %q to be valid, got %s", p, resp.Get("error").String())
+		} else if resp.Get("words").Int() != 12 {
+			t.Fatalf("expected 12 words, got %d", resp.Get("words").Int())
+		}
+	}
+
+	errMsg, resp := invokeCallback(t, func(callback js.Value) {
+		ValidateSeed("abandon abandon", "sc", callback)
+	})
+	if errMsg != "" {
+		t.Fatal(errMsg)
+	} else if resp.Get("valid").Bool() {
+		t.Fatal("expected a short phrase to be invalid")
+	}
+}
@@ -0,0 +1,548 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+type (
+	//sendWarning returned instead of a transaction when the balance cannot cover the amount and
+	//the fee. MaxSendable is the most that can be sent after the fee, for a "send max" option
+	sendWarning struct {
+		Code        string            `json:"code"`
+		Message     string            `json:"message"`
+		Balance     siatypes.Currency `json:"balance"`
+		Fee         siatypes.Currency `json:"fee"`
+		MaxSendable siatypes.Currency `json:"max_sendable"`
+	}
+
+	inputCountResp struct {
+		Inputs         int               `json:"inputs"`
+		Fee            siatypes.Currency `json:"fee"`
+		MaxInputs      int               `json:"max_inputs"`
+		RequiresDefrag bool              `json:"requires_defrag"`
+	}
+
+	sendPreview struct {
+		Warning       *sendWarning         `json:"warning,omitempty"`
+		Inputs        []SpendableOutput    `json:"inputs"`
+		Amount        siatypes.Currency    `json:"amount"`
+		Fee           siatypes.Currency    `json:"fee"`
+		Change        siatypes.Currency    `json:"change"`
+		ChangeAddress string               `json:"change_address,omitempty"`
+		Transaction   siatypes.Transaction `json:"transaction"`
+		RequiredSigs  []uint64             `json:"requiredSignatures"`
+		FeeInclusive  bool                 `json:"fee_inclusive"`
+	}
+)
+
+//maxSendable returns the most that can be sent in a transaction with outputCount outputs from the
+//outputs spendable at height and the fee of that transaction. Sending the maximum spends the
+//largest outputs up to the input limit. buildSend always reserves room for a change output, so it
+//must be included in outputCount for the maximum to leave no change
+func maxSendable(outputs []SpendableOutput, height uint64, feePerByte siatypes.Currency, outputCount int) (max, fee siatypes.Currency) {
+	sorted := spendableOutputs(outputs, height)
+
+	sortOutputsDesc(sorted)
+
+	if len(sorted) > maxInputsPerTxn {
+		sorted = sorted[:maxInputsPerTxn]
+	}
+
+	balance := sumOutputs(sorted)
+	fee = estimateTransactionFee(feePerByte, len(sorted), outputCount)
+
+	if balance.Cmp(fee) <= 0 {
+		return siatypes.ZeroCurrency, fee
+	}
+
+	return balance.Sub(fee), fee
+}
+
+//checkFeeHeadroom returns a warning if the outputs spendable at height cannot cover the amount
+//and the fee of a transaction with outputCount outputs, nil otherwise
+func checkFeeHeadroom(outputs []SpendableOutput, height uint64, amount, feePerByte siatypes.Currency, outputCount int) *sendWarning {
+	max, fee := maxSendable(outputs, height, feePerByte, outputCount)
+
+	if amount.Cmp(max) <= 0 {
+		return nil
+	}
+
+	return &sendWarning{
+		Code:        "insufficient_fee_headroom",
+		Message:     fmt.Sprintf("the balance cannot cover the amount and a fee of %s H, at most %s H can be sent", fee, max),
+		Balance:     sumOutputs(spendableOutputs(outputs, height)),
+		Fee:         fee,
+		MaxSendable: max,
+	}
+}
+
+//buildUnsignedSend creates an unsigned transaction sending siacoins to each of the recipients from
+//the outputs selected by strategy. Change is returned to the address of the first input.
+//
+//By default the fee is added on top so each recipient receives exactly its amount. If feeInclusive
+//is set the fee is taken out of the first recipient's amount instead, so the transaction spends
+//exactly the total of the amounts. The inputs of a fee inclusive send only need to cover the
+//amounts, so they are selected without a fee and the fee is estimated once for the selected inputs.
+//Adding an input to pay a higher fee is never needed since the fee does not add to the total
+func buildUnsignedSend(height uint64, recipients []SendRecipient, feePerByte siatypes.Currency, outputs []SpendableOutput, strategy selectionStrategy, feeInclusive bool) (preview sendPreview, err error) {
+	var siacoinOutputs []siatypes.SiacoinOutput
+
+	if len(recipients) == 0 {
+		err = errors.New("transaction has no recipients")
+		return
+	}
+
+	for i, recipient := range recipients {
+		uh, err := parseAddress(recipient.Address)
+
+		if err != nil {
+			return preview, fmt.Errorf("recipient %d: %w", i, err)
+		}
+
+		if recipient.Amount.IsZero() {
+			return preview, fmt.Errorf("recipient %d: amount must be greater than 0", i)
+		}
+
+		preview.Amount = preview.Amount.Add(recipient.Amount)
+		siacoinOutputs = append(siacoinOutputs, siatypes.SiacoinOutput{
+			Value:      recipient.Amount,
+			UnlockHash: uh,
+		})
+	}
+
+	if feeInclusive {
+		preview.FeeInclusive = true
+		preview.Inputs, _, err = selectUTXOs(outputs, height, preview.Amount, siatypes.ZeroCurrency, len(siacoinOutputs)+1, strategy)
+
+		if err != nil {
+			return
+		}
+
+		preview.Fee = estimateTransactionFee(feePerByte, len(preview.Inputs), len(siacoinOutputs)+1)
+
+		if siacoinOutputs[0].Value.Cmp(preview.Fee) <= 0 {
+			err = fmt.Errorf("recipient 0: amount must be greater than the fee of %s H", preview.Fee)
+			return
+		}
+
+		siacoinOutputs[0].Value = siacoinOutputs[0].Value.Sub(preview.Fee)
+		preview.Amount = preview.Amount.Sub(preview.Fee)
+	} else {
+		preview.Inputs, preview.Fee, err = selectUTXOs(outputs, height, preview.Amount, feePerByte, len(siacoinOutputs)+1, strategy)
+
+		if err != nil {
+			return
+		}
+	}
+
+	preview.Change = sumOutputs(preview.Inputs).Sub(preview.Amount).Sub(preview.Fee)
+
+	if !preview.Change.IsZero() {
+		changeAddress, err := parseAddress(preview.Inputs[0].UnlockHash)
+
+		if err != nil {
+			return preview, err
+		}
+
+		preview.ChangeAddress = changeAddress.String()
+		siacoinOutputs = append(siacoinOutputs, siatypes.SiacoinOutput{
+			Value:      preview.Change,
+			UnlockHash: changeAddress,
+		})
+	}
+
+	unsigned, err := buildTransaction(preview.Inputs, siacoinOutputs, preview.Fee)
+
+	if err != nil {
+		return
+	}
+
+	preview.Transaction = unsigned.Transaction
+	preview.RequiredSigs = unsigned.RequiredSigs
+
+	return
+}
+
+//changeOutputs returns the outputs of the transaction that do not pay one of the recipients. Each
+//recipient must be paid by its own output to the recipient's address of at most the recipient's
+//amount, a fee inclusive send pays the first recipient less. Every other output is change, it
+//must be verified before the transaction is signed or the outputs could leave the wallet
+func changeOutputs(txn siatypes.Transaction, recipients []SendRecipient) (change []siatypes.SiacoinOutput, err error) {
+	paid := make([]bool, len(txn.SiacoinOutputs))
+
+	for i, recipient := range recipients {
+		uh, err := parseAddress(recipient.Address)
+
+		if err != nil {
+			return nil, fmt.Errorf("recipient %d: %w", i, err)
+		}
+
+		found := false
+
+		for j, output := range txn.SiacoinOutputs {
+			if !paid[j] && output.UnlockHash == uh && output.Value.Cmp(recipient.Amount) <= 0 {
+				paid[j], found = true, true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("transaction has no output paying recipient %d %s H to %s", i, recipient.Amount, uh)
+		}
+	}
+
+	for j, output := range txn.SiacoinOutputs {
+		if !paid[j] {
+			change = append(change, output)
+		}
+	}
+
+	return
+}
+
+//verifyChange checks that every output of a send that does not pay one of the recipients, see
+//changeOutputs, pays an address the wallet derives at an index up to and including maxIndex.
+//Returns the verification of each change output's address. A maxIndex above maxDerivedIndex is an
+//error. Change sent to any other address is lost, so a send that fails the check must not be signed
+func verifyChange(w *wallet.SeedWallet, txn siatypes.Transaction, recipients []SendRecipient, maxIndex uint64) (verified []addressVerification, err error) {
+	if err = checkDerivedIndex(maxIndex); err != nil {
+		return
+	}
+
+	change, err := changeOutputs(txn, recipients)
+
+	if err != nil {
+		return
+	}
+
+	addresses := make([]string, 0, len(change))
+
+	for _, output := range change {
+		addresses = append(addresses, output.UnlockHash.String())
+	}
+
+	verified, err = verifyAddresses(w, addresses, maxIndex)
+
+	if err != nil {
+		return
+	}
+
+	for _, v := range verified {
+		if !v.Owned {
+			return nil, fmt.Errorf("change address %s is not one of the wallet's addresses up to index %d", v.Address, maxIndex)
+		}
+	}
+
+	return
+}
+
+//buildSend builds the transaction with buildUnsignedSend and signs it with the wallet. The change
+//returns to the address of the first input, every output not paying a recipient must be to the
+//address the wallet derives at that input's index, otherwise the transaction is not signed
+func buildSend(w *wallet.SeedWallet, height uint64, recipients []SendRecipient, feePerByte siatypes.Currency, outputs []SpendableOutput, strategy selectionStrategy, feeInclusive bool) (preview sendPreview, err error) {
+	preview, err = buildUnsignedSend(height, recipients, feePerByte, outputs, strategy, feeInclusive)
+
+	if err != nil {
+		return
+	}
+
+	change, err := changeOutputs(preview.Transaction, recipients)
+
+	if err != nil {
+		err = fmt.Errorf("refusing to sign transaction: %w", err)
+		return
+	}
+
+	// only the first input's index is derived instead of every address up to the highest index
+	if len(change) != 0 {
+		index := preview.Inputs[0].Index
+		uh := w.GetAddress(index).UnlockConditions.UnlockHash()
+
+		for _, output := range change {
+			if output.UnlockHash != uh {
+				err = fmt.Errorf("refusing to sign transaction: change address %s is not the wallet's address at index %d", output.UnlockHash, index)
+				return
+			}
+		}
+	}
+
+	if err = w.SignTransaction(&preview.Transaction, preview.RequiredSigs); err != nil {
+		err = fmt.Errorf("unable to sign transaction: %w", err)
+		return
+	}
+
+	return
+}
+
+//prepareSend checks the fee headroom of the send at the current height, or at outputsHeight if
+//the outputs are a snapshot. If the balance cannot cover the amount and the fee the returned
+//preview only contains a warning with the maximum sendable amount. A fee inclusive send only needs
+//the balance to cover the amount
+func prepareSend(currency string, outputsHeight uint64, recipients []SendRecipient, feePerByte siatypes.Currency, outputs []SpendableOutput, feeInclusive bool) (height uint64, warning *sendPreview, err error) {
+	height, err = snapshotHeight(context.Background(), currency, outputsHeight)
+
+	if err != nil {
+		err = fmt.Errorf("unable to get block height: %w", err)
+		return
+	}
+
+	var amount siatypes.Currency
+
+	for _, recipient := range recipients {
+		amount = amount.Add(recipient.Amount)
+	}
+
+	headroomFee := feePerByte
+
+	// the fee comes out of the amount, the maximum is the balance of the spendable inputs
+	if feeInclusive {
+		headroomFee = siatypes.ZeroCurrency
+	}
+
+	// warn before building so the UI can offer to send the maximum instead of failing
+	if w := checkFeeHeadroom(outputs, height, amount, headroomFee, len(recipients)+1); w != nil {
+		if feeInclusive {
+			_, w.Fee = maxSendable(outputs, height, feePerByte, len(recipients)+1)
+			w.Message = fmt.Sprintf("the balance cannot cover the amount, at most %s H can be sent including the fee", w.MaxSendable)
+		}
+
+		warning = &sendPreview{
+			Warning: w,
+			Amount:  amount,
+			Fee:     w.Fee,
+		}
+	}
+
+	return
+}
+
+//PreviewSend builds and signs a transaction sending siacoins to each of the recipients without
+//broadcasting it, so the UI can confirm the exact inputs, fee, and change before sending. If the
+//balance cannot cover the amount and the fee the preview only contains a warning with the maximum
+//sendable amount. strategyName selects the coin selection strategy, empty for the default. If
+//feeInclusive is set the fee is taken out of the first recipient's amount, see buildUnsignedSend.
+//outputsHeight is the height the outputs were fetched at, if it is not zero the API is not used
+func PreviewSend(phrase, currency string, recipients []SendRecipient, feePerByte siatypes.Currency, outputs []SpendableOutput, strategyName string, accountOffset uint64, feeInclusive bool, outputsHeight uint64, callback js.Value) {
+	w, err := recoverWallet(phrase, currency, accountOffset)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	strategy, err := parseSelectionStrategy(strategyName)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	height, warning, err := prepareSend(currency, outputsHeight, recipients, feePerByte, outputs, feeInclusive)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	if warning != nil {
+		sendResult(*warning, callback)
+		return
+	}
+
+	preview, err := buildSend(w, height, recipients, feePerByte, outputs, strategy, feeInclusive)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	sendResult(preview, callback)
+}
+
+//BuildUnsignedSend builds a transaction sending siacoins to each of the recipients without signing
+//it. The seed is not needed to build, the transaction and its required signatures can be signed
+//later with SignTransactions. Rebuilding after the inputs change only needs the transaction to be
+//signed again. Returns the same warning as PreviewSend if the balance cannot cover the fee. Like
+//PreviewSend the API is not used if outputsHeight is not zero
+func BuildUnsignedSend(currency string, recipients []SendRecipient, feePerByte siatypes.Currency, outputs []SpendableOutput, strategyName string, feeInclusive bool, outputsHeight uint64, callback js.Value) {
+	strategy, err := parseSelectionStrategy(strategyName)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	height, warning, err := prepareSend(currency, outputsHeight, recipients, feePerByte, outputs, feeInclusive)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	if warning != nil {
+		sendResult(*warning, callback)
+		return
+	}
+
+	preview, err := buildUnsignedSend(height, recipients, feePerByte, outputs, strategy, feeInclusive)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	sendResult(preview, callback)
+}
+
+//countSendInputs returns the number of inputs the default coin selection strategy would spend to
+//send the amount to recipientCount recipients and the estimated fee. The count is not limited to
+//the inputs allowed in one transaction so the UI can suggest defragging first
+func countSendInputs(outputs []SpendableOutput, height uint64, amount, feePerByte siatypes.Currency, recipientCount int) (inputCountResp, error) {
+	inputs, fee, err := fundUTXOs(outputs, height, amount, feePerByte, recipientCount+1, strategySmallestFirst)
+
+	if err != nil {
+		return inputCountResp{}, err
+	}
+
+	return inputCountResp{
+		Inputs:         len(inputs),
+		Fee:            fee,
+		MaxInputs:      maxInputsPerTxn,
+		RequiresDefrag: len(inputs) > maxInputsPerTxn,
+	}, nil
+}
+
+//CountSendInputs returns the number of inputs a send of the amount would spend without building the
+//transaction, so the UI can warn before a send consumes many small outputs. The API is not used if
+//outputsHeight is not zero
+func CountSendInputs(currency string, amount, feePerByte siatypes.Currency, recipientCount int, outputs []SpendableOutput, outputsHeight uint64, callback js.Value) {
+	height, err := snapshotHeight(context.Background(), currency, outputsHeight)
+
+	if err != nil {
+		callback.Invoke(fmt.Sprintf("unable to get block height: %s", err), js.Null())
+		return
+	}
+
+	resp, err := countSendInputs(outputs, height, amount, feePerByte, recipientCount)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(resp)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//spendOutput builds and signs a transaction spending only the output with the ID. If amount is
+//zero the whole output is sent to the destination with the fee taken out of it, otherwise the
+//amount is sent and the change returns to the output's address
+func spendOutput(w *wallet.SeedWallet, height uint64, outputID, destination string, amount, feePerByte siatypes.Currency, outputs []SpendableOutput) (preview sendPreview, err error) {
+	var spend []SpendableOutput
+
+	for _, output := range outputs {
+		if output.OutputID == outputID {
+			spend = append(spend, output)
+			break
+		}
+	}
+
+	if len(spend) == 0 {
+		err = fmt.Errorf("output %s not found", outputID)
+		return
+	}
+
+	if len(spendableOutputs(spend, height)) == 0 {
+		err = fmt.Errorf("output %s is not spendable at height %d", outputID, height)
+		return
+	}
+
+	feeInclusive := amount.IsZero()
+
+	if feeInclusive {
+		amount = spend[0].Value
+	}
+
+	return buildSend(w, height, []SendRecipient{{Address: destination, Amount: amount}}, feePerByte, spend, strategyLargestFirst, feeInclusive)
+}
+
+//SpendOutput builds and signs a transaction spending exactly one output of the wallet, for coin
+//control. An amount of zero sends the whole output less the fee. The output is looked up in
+//outputs since its address index is needed to sign
+func SpendOutput(phrase, currency, outputID, destination string, amount, feePerByte siatypes.Currency, outputs []SpendableOutput, accountOffset uint64, callback js.Value) {
+	w, err := recoverWallet(phrase, currency, accountOffset)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	height, err := currentHeight(context.Background(), currency)
+
+	if err != nil {
+		callback.Invoke(fmt.Errorf("unable to get block height: %w", err).Error(), js.Null())
+		return
+	}
+
+	preview, err := spendOutput(w, height, outputID, destination, amount, feePerByte, outputs)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	sendResult(preview, callback)
+}
+
+//VerifyChange checks that the change of an unsigned send, like one built by BuildUnsignedSend,
+//returns to one of the wallet's addresses up to and including maxIndex before it is signed. Every
+//output not paying one of the recipients is change. Returns the index of each change address, or
+//an error if any of the change would leave the wallet. A send without change always passes
+func VerifyChange(phrase, currency string, txn siatypes.Transaction, recipients []SendRecipient, maxIndex, accountOffset uint64, callback js.Value) {
+	w, err := recoverWallet(phrase, currency, accountOffset)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	verified, err := verifyChange(w, txn, recipients, maxIndex)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(verified)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//sendResult returns the preview to the callback
+func sendResult(preview sendPreview, callback js.Value) {
+	data, err := interfaceToJSON(preview)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
@@ -0,0 +1,326 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"syscall/js"
+	"time"
+
+	"github.com/siacentral/apisdkgo"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+const (
+	//historyPageSize the number of transactions requested per page when paging through a wallet's
+	//full history
+	historyPageSize = 500
+	//topCounterparties the number of counterparties returned by CounterpartyExposure
+	topCounterparties = 25
+)
+
+type (
+	//walletStats a summary of a wallet's activity and balance
+	walletStats struct {
+		Transactions           int               `json:"transactions"`
+		FirstActivityHeight    uint64            `json:"first_activity_height"`
+		LastActivityHeight     uint64            `json:"last_activity_height"`
+		SiacoinsReceived       siatypes.Currency `json:"siacoins_received"`
+		SiacoinsSent           siatypes.Currency `json:"siacoins_sent"`
+		SiacoinBalance         siatypes.Currency `json:"siacoin_balance"`
+		ImmatureSiacoinBalance siatypes.Currency `json:"immature_siacoin_balance"`
+		SiafundBalance         siatypes.Currency `json:"siafund_balance"`
+	}
+
+	//creationEstimate the height of the wallet's first activity on the blockchain. Timestamp is
+	//only set if the first activity was a transaction. Found is false for a wallet without any
+	//confirmed activity
+	creationEstimate struct {
+		Height    uint64    `json:"height"`
+		Timestamp time.Time `json:"timestamp,omitempty"`
+		Found     bool      `json:"found"`
+	}
+
+	//counterparty the siacoins the wallet sent to and received from an address outside of the
+	//wallet. Volume is the sum of both
+	counterparty struct {
+		Address      string            `json:"address"`
+		Sent         siatypes.Currency `json:"sent"`
+		Received     siatypes.Currency `json:"received"`
+		Volume       siatypes.Currency `json:"volume"`
+		Transactions int               `json:"transactions"`
+	}
+
+	//counterpartyExposure the top counterparties by volume and the number of counterparties found
+	counterpartyExposure struct {
+		Counterparties []counterparty `json:"counterparties"`
+		Total          int            `json:"total"`
+	}
+)
+
+//computeWalletStats summarizes the wallet's transactions and balance. Unconfirmed transactions are
+//counted but do not change the activity heights
+func computeWalletStats(resp transactionResp) (stats walletStats) {
+	stats.Transactions = len(resp.Transactions)
+	stats.SiacoinBalance = resp.ConfirmedSiacoinBalance
+	stats.ImmatureSiacoinBalance = resp.ImmatureSiacoinBalance
+	stats.SiafundBalance = resp.ConfirmedSiafundBalance
+
+	for _, txn := range resp.Transactions {
+		switch txn.SiacoinValue.Direction {
+		case "received":
+			stats.SiacoinsReceived = stats.SiacoinsReceived.Add(txn.SiacoinValue.Value)
+		case "sent":
+			stats.SiacoinsSent = stats.SiacoinsSent.Add(txn.SiacoinValue.Value)
+		}
+
+		if txn.Confirmations == 0 {
+			continue
+		}
+
+		if stats.FirstActivityHeight == 0 || txn.BlockHeight < stats.FirstActivityHeight {
+			stats.FirstActivityHeight = txn.BlockHeight
+		}
+
+		if txn.BlockHeight > stats.LastActivityHeight {
+			stats.LastActivityHeight = txn.BlockHeight
+		}
+	}
+
+	return
+}
+
+//GetWalletStats returns a summary of the transactions and balance of the addresses. Addresses
+//without any activity are included in the balance but otherwise ignored
+func GetWalletStats(addresses []string, currency string, callback js.Value) {
+	resp, err := loadTransactions(context.Background(), addresses, currency)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(computeWalletStats(resp))
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//computeCounterpartyExposure aggregates the siacoins that flowed between the wallet and each address
+//outside of it. Outputs to the wallet's own addresses, like change, are never counterparties.
+//Siacoins sent are the outputs paid to the address by a transaction spending the wallet's outputs.
+//Siacoins received are split between the addresses funding the transaction in proportion to
+//their inputs, rounded down, since the outputs do not say which input paid them
+func computeCounterpartyExposure(transactions []processedTransaction, limit int) (exposure counterpartyExposure) {
+	counterparties := make(map[string]*counterparty)
+
+	get := func(address string) *counterparty {
+		c, exists := counterparties[address]
+
+		if !exists {
+			c = &counterparty{Address: address}
+			counterparties[address] = c
+		}
+
+		return c
+	}
+
+	for _, txn := range transactions {
+		var funded, received siatypes.Currency
+		var spent bool
+
+		seen := make(map[string]bool)
+		funders := make(map[string]siatypes.Currency)
+
+		for _, input := range txn.SiacoinInputs {
+			if input.Owned {
+				spent = true
+				continue
+			}
+
+			funders[input.UnlockHash] = funders[input.UnlockHash].Add(input.Value)
+			funded = funded.Add(input.Value)
+		}
+
+		for _, output := range txn.SiacoinOutputs {
+			if output.Owned {
+				received = received.Add(output.Value)
+				continue
+			}
+
+			// only the wallet's own spends pay counterparties, another wallet's change is not sent
+			if !spent {
+				continue
+			}
+
+			c := get(output.UnlockHash)
+			c.Sent = c.Sent.Add(output.Value)
+			seen[output.UnlockHash] = true
+		}
+
+		if !spent && !received.IsZero() && !funded.IsZero() {
+			for address, value := range funders {
+				c := get(address)
+				c.Received = c.Received.Add(received.Mul(value).Div(funded))
+				seen[address] = true
+			}
+		}
+
+		for address := range seen {
+			counterparties[address].Transactions++
+		}
+	}
+
+	exposure.Total = len(counterparties)
+	exposure.Counterparties = make([]counterparty, 0, len(counterparties))
+
+	for _, c := range counterparties {
+		c.Volume = c.Sent.Add(c.Received)
+		exposure.Counterparties = append(exposure.Counterparties, *c)
+	}
+
+	sort.Slice(exposure.Counterparties, func(i, j int) bool {
+		a, b := exposure.Counterparties[i], exposure.Counterparties[j]
+
+		if cmp := a.Volume.Cmp(b.Volume); cmp != 0 {
+			return cmp > 0
+		}
+
+		return a.Address < b.Address
+	})
+
+	if len(exposure.Counterparties) > limit {
+		exposure.Counterparties = exposure.Counterparties[:limit]
+	}
+
+	return
+}
+
+//CounterpartyExposure returns the addresses outside of the wallet the most siacoins were sent to
+//or received from. Like the wallet stats, only the most recent transactions are included
+func CounterpartyExposure(addresses []string, currency string, callback js.Value) {
+	resp, err := loadTransactions(context.Background(), addresses, currency)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(computeCounterpartyExposure(resp.Transactions, topCounterparties))
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
+
+//add lowers the estimate to the height if it is earlier than any height added so far
+func (e *creationEstimate) add(height uint64, timestamp time.Time) {
+	if e.Found && height >= e.Height {
+		return
+	}
+
+	e.Height = height
+	e.Timestamp = timestamp
+	e.Found = true
+}
+
+//addBalance adds the confirmed transactions and unspent outputs of the response to the estimate.
+//Unconfirmed transactions and outputs do not have a height yet
+func (e *creationEstimate) addBalance(resp apisdkgo.GetTransactionsResp) {
+	for _, txn := range resp.Transactions {
+		if txn.Confirmations != 0 {
+			e.add(txn.BlockHeight, txn.Timestamp)
+		}
+	}
+
+	for _, output := range resp.UnspentSiacoinOutputs {
+		if output.BlockHeight != 0 {
+			e.add(output.BlockHeight, time.Time{})
+		}
+	}
+
+	for _, output := range resp.UnspentSiafundOutputs {
+		if output.BlockHeight != 0 {
+			e.add(output.BlockHeight, time.Time{})
+		}
+	}
+}
+
+//estimateCreationHeight pages through the full history of the addresses for the earliest
+//confirmed activity. Unlike the wallet stats, it is not limited to the most recent transactions.
+//Paging stops at a partial page or at a page without any transactions that were not already seen,
+//so an API that ignores the page cannot make it loop forever
+func estimateCreationHeight(ctx context.Context, addresses []string, currency string) (estimate creationEstimate, err error) {
+	count := len(addresses)
+	apiclient := siacentralAPIClient(currency)
+
+	for i := 0; i < count; i += 1e3 {
+		end := i + 1e3
+
+		if end > count {
+			end = count
+		}
+
+		seen := make(map[string]bool)
+
+		for page := 0; ; page++ {
+			resp, err := apiclient.FindAddressBalance(ctx, historyPageSize, page, addresses[i:end])
+
+			if err != nil {
+				return creationEstimate{}, err
+			}
+
+			estimate.addBalance(resp)
+
+			var unseen int
+
+			for _, txn := range resp.Transactions {
+				id := txn.ID
+
+				// transactions without an id are named by their first output, like loadTransactions
+				if len(id) == 0 && len(txn.SiacoinOutputs) != 0 {
+					id = fmt.Sprintf("nontxn-%s", txn.SiacoinOutputs[0].OutputID)
+				}
+
+				if !seen[id] {
+					seen[id] = true
+					unseen++
+				}
+			}
+
+			// a partial page is the end of the history, a page of seen transactions is repeated
+			if len(resp.Transactions) < historyPageSize || unseen == 0 {
+				break
+			}
+		}
+	}
+
+	return
+}
+
+//EstimateCreationHeight returns the lowest block height any of the addresses first appeared at,
+//so later history and output queries can skip the blocks before the wallet was used
+func EstimateCreationHeight(addresses []string, currency string, callback js.Value) {
+	estimate, err := estimateCreationHeight(context.Background(), addresses, currency)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(estimate)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
@@ -0,0 +1,176 @@
+package modules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/siacentral/apisdkgo"
+	apitypes "github.com/siacentral/apisdkgo/types"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+func TestComputeWalletStats(t *testing.T) {
+	sc := siatypes.SiacoinPrecision
+
+	stats := computeWalletStats(transactionResp{})
+	if stats.Transactions != 0 || stats.FirstActivityHeight != 0 || stats.LastActivityHeight != 0 || !stats.SiacoinsReceived.IsZero() {
+		t.Fatalf("expected empty stats for a wallet without activity, got %v", stats)
+	}
+
+	stats = computeWalletStats(transactionResp{
+		ConfirmedSiacoinBalance: sc.Mul64(60),
+		Transactions: []processedTransaction{
+			{BlockHeight: 0, SiacoinValue: processedTxnValue{Direction: "received", Value: sc.Mul64(5)}},
+			{BlockHeight: 300, Confirmations: 10, SiacoinValue: processedTxnValue{Direction: "sent", Value: sc.Mul64(45)}},
+			{BlockHeight: 100, Confirmations: 210, SiacoinValue: processedTxnValue{Direction: "received", Value: sc.Mul64(100)}},
+		},
+	})
+
+	if stats.Transactions != 3 {
+		t.Fatalf("expected 3 transactions, got %d", stats.Transactions)
+	} else if stats.FirstActivityHeight != 100 || stats.LastActivityHeight != 300 {
+		t.Fatalf("expected activity from 100 to 300, got %d to %d", stats.FirstActivityHeight, stats.LastActivityHeight)
+	} else if !stats.SiacoinsReceived.Equals(sc.Mul64(105)) {
+		t.Fatalf("expected 105 SC received, got %s", stats.SiacoinsReceived.HumanString())
+	} else if !stats.SiacoinsSent.Equals(sc.Mul64(45)) {
+		t.Fatalf("expected 45 SC sent, got %s", stats.SiacoinsSent.HumanString())
+	} else if !stats.SiacoinBalance.Equals(sc.Mul64(60)) {
+		t.Fatalf("expected 60 SC balance, got %s", stats.SiacoinBalance.HumanString())
+	}
+}
+
+func TestEstimateCreationHeight(t *testing.T) {
+	// a full first page of newer transactions followed by the oldest on the second page
+	firstPage := make([]apitypes.Transaction, historyPageSize)
+	for i := range firstPage {
+		firstPage[i] = apitypes.Transaction{ID: fmt.Sprintf("txn%d", i), BlockHeight: 2000 - uint64(i), Confirmations: 1}
+	}
+
+	pages := [][]apitypes.Transaction{
+		firstPage,
+		{
+			{BlockHeight: 900, Confirmations: 1},
+			{BlockHeight: 0, Confirmations: 0},
+		},
+	}
+
+	canned := &cannedTransport{
+		handlers: map[string]func(*http.Request) string{
+			"/v2/wallet/addresses": func(req *http.Request) string {
+				page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+
+				var resp apisdkgo.GetTransactionsResp
+				resp.Type = "success"
+				resp.UnspentSiacoinOutputs = []apitypes.SiacoinOutput{{BlockHeight: 1500}, {BlockHeight: 0}}
+
+				if page < len(pages) {
+					resp.Transactions = pages[page]
+				}
+
+				buf, _ := json.Marshal(resp)
+				return string(buf)
+			},
+		},
+	}
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	estimate, err := estimateCreationHeight(context.Background(), []string{"addr1"}, "sc")
+	if err != nil {
+		t.Fatal(err)
+	} else if !estimate.Found || estimate.Height != 900 {
+		t.Fatalf("expected creation height 900, got %+v", estimate)
+	} else if len(canned.requests) != 2 {
+		t.Fatalf("expected 2 pages to be requested, got %d", len(canned.requests))
+	}
+
+	// an API that ignores the page returns the first page again, paging stops instead of looping
+	canned = &cannedTransport{
+		handlers: map[string]func(*http.Request) string{
+			"/v2/wallet/addresses": func(req *http.Request) string {
+				var resp apisdkgo.GetTransactionsResp
+				resp.Type = "success"
+				resp.Transactions = firstPage
+
+				buf, _ := json.Marshal(resp)
+				return string(buf)
+			},
+		},
+	}
+
+	SetTransport(canned)
+
+	if estimate, err := estimateCreationHeight(context.Background(), []string{"addr1"}, "sc"); err != nil {
+		t.Fatal(err)
+	} else if estimate.Height != 2000-historyPageSize+1 {
+		t.Fatalf("expected creation height %d, got %+v", 2000-historyPageSize+1, estimate)
+	} else if len(canned.requests) != 2 {
+		t.Fatalf("expected paging to stop after the repeated page, got %d requests", len(canned.requests))
+	}
+
+	// unconfirmed activity does not have a height
+	var empty creationEstimate
+	empty.addBalance(apisdkgo.GetTransactionsResp{
+		Transactions: []apitypes.Transaction{{BlockHeight: 0, Confirmations: 0}},
+	})
+	if empty.Found {
+		t.Fatalf("expected no creation height without confirmed activity, got %+v", empty)
+	}
+}
+
+func TestComputeCounterpartyExposure(t *testing.T) {
+	sc := siatypes.SiacoinPrecision
+
+	input := func(addr string, value uint64, owned bool) processedSiacoinInput {
+		in := processedSiacoinInput{Owned: owned}
+		in.UnlockHash = addr
+		in.Value = sc.Mul64(value)
+		return in
+	}
+
+	output := func(addr string, value uint64, owned bool) processedSiacoinOutput {
+		return processedSiacoinOutput{
+			SiacoinOutput: apitypes.SiacoinOutput{UnlockHash: addr, Value: sc.Mul64(value)},
+			Owned:         owned,
+		}
+	}
+
+	transactions := []processedTransaction{
+		// 100 SC received from two addresses, split by their inputs
+		{
+			SiacoinInputs:  []processedSiacoinInput{input("alice", 75, false), input("bob", 25, false)},
+			SiacoinOutputs: []processedSiacoinOutput{output("wallet1", 100, true)},
+		},
+		// the sender's change is not counted as received
+		{
+			SiacoinInputs:  []processedSiacoinInput{input("alice", 50, false)},
+			SiacoinOutputs: []processedSiacoinOutput{output("wallet2", 10, true), output("alice", 40, false)},
+		},
+		// the wallet's change is not a counterparty
+		{
+			SiacoinInputs:  []processedSiacoinInput{input("wallet1", 100, true)},
+			SiacoinOutputs: []processedSiacoinOutput{output("bob", 30, false), output("wallet3", 69, true)},
+		},
+	}
+
+	exposure := computeCounterpartyExposure(transactions, 10)
+	if exposure.Total != 2 || len(exposure.Counterparties) != 2 {
+		t.Fatalf("expected 2 counterparties, got %+v", exposure)
+	}
+
+	alice, bob := exposure.Counterparties[0], exposure.Counterparties[1]
+	if alice.Address != "alice" || !alice.Received.Equals(sc.Mul64(85)) || !alice.Sent.IsZero() || alice.Transactions != 2 {
+		t.Fatalf("expected 85 SC received from alice in 2 transactions, got %+v", alice)
+	} else if bob.Address != "bob" || !bob.Received.Equals(sc.Mul64(25)) || !bob.Sent.Equals(sc.Mul64(30)) || !bob.Volume.Equals(sc.Mul64(55)) {
+		t.Fatalf("expected 25 SC received from and 30 SC sent to bob, got %+v", bob)
+	}
+
+	if exposure := computeCounterpartyExposure(transactions, 1); exposure.Total != 2 || len(exposure.Counterparties) != 1 || exposure.Counterparties[0].Address != "alice" {
+		t.Fatalf("expected only the top counterparty, got %+v", exposure)
+	}
+}
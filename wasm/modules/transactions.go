@@ -2,13 +2,17 @@ package modules
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"math/big"
 	"sort"
 
 	"syscall/js"
 
+	"github.com/siacentral/apisdkgo"
 	apitypes "github.com/siacentral/apisdkgo/types"
+	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
+	siacrypto "gitlab.com/NebulousLabs/Sia/crypto"
 	siatypes "gitlab.com/NebulousLabs/Sia/types"
 )
 
@@ -28,16 +32,163 @@ func EncodeTransaction(txn siatypes.Transaction, callback js.Value) {
 	callback.Invoke(js.Null(), value)
 }
 
-//SignTransaction signs a transaction using the seed and required signatures
-func SignTransaction(txn siatypes.Transaction, phrase, currency string, requiredSignatures []uint64, callback js.Value) {
-	w, err := recoverWallet(phrase, currency)
+//encodedCurrencySize returns the size of the currency in the Sia encoding, a length prefix followed
+//by the big-endian bytes of the value
+func encodedCurrencySize(c siatypes.Currency) uint64 {
+	return 8 + uint64(len(c.Big().Bytes()))
+}
+
+//encodedUnlockConditionsSize returns the size of the unlock conditions in the Sia encoding. Only
+//ed25519 keys have a known size
+func encodedUnlockConditionsSize(uc apitypes.UnlockCondition) (uint64, bool) {
+	// timelock, public key count, and required signatures
+	size := uint64(24)
+
+	for _, key := range uc.PublicKeys {
+		var pk siatypes.SiaPublicKey
+
+		if err := pk.LoadString(key); err != nil || pk.Algorithm != siatypes.SignatureEd25519 {
+			return 0, false
+		}
+
+		// algorithm specifier, key length prefix, and key
+		size += 16 + 8 + uint64(len(pk.Key))
+	}
+
+	return size, true
+}
+
+//transactionSize computes the size of the transaction in the Sia encoding from the explorer's
+//transaction. The explorer does not return the full contracts, revisions, storage proofs, or
+//signatures of non-ed25519 keys so the size of transactions containing them is not known
+func transactionSize(txn apitypes.Transaction) (uint64, bool) {
+	if len(txn.StorageContracts) != 0 || len(txn.ContractRevisions) != 0 || len(txn.StorageProofs) != 0 {
+		return 0, false
+	}
+
+	// the length prefix of each of the 10 transaction fields
+	size := uint64(10 * 8)
+
+	for _, input := range txn.SiacoinInputs {
+		uc, ok := encodedUnlockConditionsSize(input.UnlockConditions)
+
+		if !ok {
+			return 0, false
+		}
+
+		size += 32 + uc
+	}
+
+	for _, output := range txn.SiacoinOutputs {
+		size += encodedCurrencySize(output.Value) + 32
+	}
+
+	for _, input := range txn.SiafundInputs {
+		uc, ok := encodedUnlockConditionsSize(input.UnlockConditions)
+
+		if !ok {
+			return 0, false
+		}
+
+		// parent id, unlock conditions, and claim unlock hash
+		size += 32 + uc + 32
+	}
+
+	for _, output := range txn.SiafundOutputs {
+		// value, unlock hash, and claim start. The claim start of an output in a transaction must be
+		// zero, the explorer's siacoin claim is the output's accrued claim not its claim start
+		size += encodedCurrencySize(output.Value) + 32 + encodedCurrencySize(siatypes.ZeroCurrency)
+	}
+
+	for _, fee := range txn.MinerFees {
+		size += encodedCurrencySize(fee)
+	}
+
+	for _, data := range txn.ArbitraryData {
+		size += 8 + uint64(len(data))
+	}
+
+	for _, sig := range txn.TransactionSignatures {
+		cf := sig.CoveredFields
+		indices := len(cf.SiacoinInputs) + len(cf.SiacoinOutputs) + len(cf.StorageContracts) +
+			len(cf.StorageContractRevisions) + len(cf.StorageProofs) + len(cf.MinerFees) +
+			len(cf.ArbitraryData) + len(cf.TransactionSignatures)
+
+		// parent id, public key index, timelock, covered fields with the length prefix of their 10
+		// fields, and an ed25519 signature with its length prefix
+		size += 32 + 8 + 8 + 1 + 10*8 + 8*uint64(indices) + 8 + siacrypto.SignatureSize
+	}
+
+	return size, true
+}
+
+//sigHashes returns the sig hash of each of the transaction's signatures. If coverage is not nil it
+//replaces the covered fields of every signature. Any coverage consensus accepts is hashed, even
+//coverage the wallet would refuse to sign
+func sigHashes(txn siatypes.Transaction, coverage *siatypes.CoveredFields, height siatypes.BlockHeight) ([]siacrypto.Hash, error) {
+	if coverage != nil {
+		sigs := make([]siatypes.TransactionSignature, len(txn.TransactionSignatures))
+		copy(sigs, txn.TransactionSignatures)
+
+		for i := range sigs {
+			sigs[i].CoveredFields = *coverage
+		}
+
+		txn.TransactionSignatures = sigs
+	}
+
+	hashes := make([]siacrypto.Hash, len(txn.TransactionSignatures))
+
+	for i, sig := range txn.TransactionSignatures {
+		// out of range covered fields would panic when hashing
+		if err := wallet.ConsensusCoverage(&txn, sig.CoveredFields); err != nil {
+			return nil, fmt.Errorf("signature %d: %w", i, err)
+		}
+
+		hashes[i] = txn.SigHash(i, height)
+	}
+
+	return hashes, nil
+}
+
+//HashCoveredFields returns the hex encoded sig hash of each of the transaction's signatures, the
+//exact hash SignTransaction signs. If coverage is not nil it replaces the covered fields of every
+//signature. Used to compare against other implementations when a signature does not verify
+func HashCoveredFields(txn siatypes.Transaction, coverage *siatypes.CoveredFields, currency string, callback js.Value) {
+	hashes, err := sigHashes(txn, coverage, wallet.ASICHardForkHeight(currency))
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	resp := make([]interface{}, len(hashes))
+
+	for i, h := range hashes {
+		resp[i] = h.String()
+	}
+
+	callback.Invoke(js.Null(), resp)
+}
+
+//SignTransaction signs a transaction using the seed and required signatures. If coverage is not
+//nil the signatures only commit to the covered fields, see SeedWallet.SignTransactionCoverage for
+//when it is safe to leave fields uncovered
+func SignTransaction(txn siatypes.Transaction, phrase, currency string, requiredSignatures []uint64, coverage *siatypes.CoveredFields, accountOffset uint64, callback js.Value) {
+	w, err := recoverWallet(phrase, currency, accountOffset)
 
 	if err != nil {
 		callback.Invoke(err.Error(), js.Null())
 		return
 	}
 
-	if err := w.SignTransaction(&txn, requiredSignatures); err != nil {
+	if coverage != nil {
+		err = w.SignTransactionCoverage(&txn, requiredSignatures, *coverage)
+	} else {
+		err = w.SignTransaction(&txn, requiredSignatures)
+	}
+
+	if err != nil {
 		callback.Invoke(err.Error(), js.Null())
 		return
 	}
@@ -53,8 +204,8 @@ func SignTransaction(txn siatypes.Transaction, phrase, currency string, required
 }
 
 //SignTransactions signs a list of transaction using the seed and required signatures returns an error if any of the transactions cannot be signed
-func SignTransactions(transactions []UnsignedTransaction, phrase, currency string, callback js.Value) {
-	w, err := recoverWallet(phrase, currency)
+func SignTransactions(transactions []UnsignedTransaction, phrase, currency string, accountOffset uint64, callback js.Value) {
+	w, err := recoverWallet(phrase, currency, accountOffset)
 
 	if err != nil {
 		callback.Invoke(err.Error(), js.Null())
@@ -88,35 +239,317 @@ func SignTransactions(transactions []UnsignedTransaction, phrase, currency strin
 	callback.Invoke(js.Null(), signed)
 }
 
-//GetTransactions gets the last 500 transactions belonging to each address
-func GetTransactions(addresses []string, currency string, callback js.Value) {
+//processSiacoins marks the wallet's siacoin inputs and outputs of a transaction. Owned outputs of a
+//transaction that spends owned inputs are change, they return funds to the wallet and only the
+//difference between the owned inputs and outputs is counted as the value moved
+func processSiacoins(inputs []apitypes.SiacoinInput, outputs []apitypes.SiacoinOutput, ownedAddresses map[string]bool) (flow siacoinFlow) {
+	var ownedInput, ownedOutput siatypes.Currency
+
+	for _, input := range inputs {
+		procInput := processedSiacoinInput{
+			SiacoinInput: input,
+		}
+
+		if _, exists := ownedAddresses[input.UnlockHash]; exists {
+			procInput.Owned = true
+			ownedInput = ownedInput.Add(input.Value)
+			flow.OwnedInputs++
+		}
+
+		flow.Inputs = append(flow.Inputs, procInput)
+	}
+
+	for _, output := range outputs {
+		procOutput := processedSiacoinOutput{
+			SiacoinOutput: output,
+		}
+
+		if _, exists := ownedAddresses[output.UnlockHash]; exists {
+			procOutput.Owned = true
+			procOutput.Change = flow.OwnedInputs != 0
+			ownedOutput = ownedOutput.Add(output.Value)
+			flow.OwnedOutputs++
+		}
+
+		flow.Outputs = append(flow.Outputs, procOutput)
+	}
+
+	if ownedOutput.Cmp(ownedInput) == -1 {
+		flow.Value.Direction = "sent"
+		flow.Value.Value = ownedInput.Sub(ownedOutput)
+	} else {
+		flow.Value.Direction = "received"
+		flow.Value.Value = ownedOutput.Sub(ownedInput)
+	}
+
+	return
+}
+
+//processTransaction marks the wallet's inputs and outputs of the transaction and the value it moved
+//in or out of the wallet. Returns false if the transaction did not change the wallet's balance
+func processTransaction(txn apitypes.Transaction, ownedAddresses map[string]bool, height uint64) (processed processedTransaction, ok bool) {
+	var ownedSiafundInput, ownedSiafundOutput siatypes.Currency
+	var ownedSiafundInputsCount, ownedSiafundOutputsCount int
+
+	processed = processedTransaction{
+		TransactionID:     txn.ID,
+		BlockHeight:       txn.BlockHeight,
+		Timestamp:         txn.Timestamp,
+		Fees:              txn.Fees,
+		StorageProofs:     txn.StorageProofs,
+		HostAnnouncements: txn.HostAnnouncements,
+		Contracts:         make([]processedContract, len(txn.StorageContracts)),
+		ContractRevisions: make([]processedContract, len(txn.ContractRevisions)),
+	}
+
+	// the fee is reported even when the size, and so the fee rate, cannot be computed
+	if size, ok := transactionSize(txn); ok {
+		rate := txn.Fees.Div64(size)
+
+		processed.Size = size
+		processed.FeeRate = &rate
+	}
+
+	// confirmed transactions use the shared height so every confirmation count agrees
+	if txn.Confirmations != 0 {
+		processed.Confirmations = confirmations(height, txn.BlockHeight)
+	} else {
+		processed.Pending = true
+	}
+
+	for i, contract := range txn.StorageContracts {
+		procContract := processedContract{
+			ID:                     contract.ID,
+			BlockID:                contract.BlockID,
+			TransactionID:          contract.TransactionID,
+			MerkleRoot:             contract.MerkleRoot,
+			UnlockHash:             contract.UnlockHash,
+			Status:                 contract.Status,
+			RevisionNumber:         contract.RevisionNumber,
+			NegotiationHeight:      contract.NegotiationHeight,
+			ExpirationHeight:       contract.ExpirationHeight,
+			ProofDeadline:          contract.ProofDeadline,
+			ProofHeight:            contract.ProofHeight,
+			Payout:                 contract.Payout,
+			FileSize:               contract.FileSize,
+			ValidProofOutputs:      make([]processedSiacoinOutput, len(contract.ValidProofOutputs)),
+			MissedProofOutputs:     make([]processedSiacoinOutput, len(contract.MissedProofOutputs)),
+			NegotiationTimestamp:   contract.NegotiationTimestamp,
+			ExpirationTimestamp:    contract.ExpirationTimestamp,
+			ProofDeadlineTimestamp: contract.ProofDeadlineTimestamp,
+			ProofTimestamp:         contract.ProofTimestamp,
+			ProofConfirmed:         contract.ProofConfirmed,
+			Unused:                 contract.Unused,
+		}
+
+		for j, output := range contract.ValidProofOutputs {
+			_, exists := ownedAddresses[output.UnlockHash]
+			procContract.ValidProofOutputs[j].SiacoinOutput = output
+			procContract.ValidProofOutputs[j].Owned = exists
+		}
+
+		for j, output := range contract.MissedProofOutputs {
+			_, exists := ownedAddresses[output.UnlockHash]
+			procContract.MissedProofOutputs[j].SiacoinOutput = output
+			procContract.MissedProofOutputs[j].Owned = exists
+		}
+
+		processed.Contracts[i] = procContract
+	}
+
+	for i, contract := range txn.ContractRevisions {
+		procContract := processedContract{
+			ID:                     contract.ID,
+			BlockID:                contract.BlockID,
+			TransactionID:          contract.TransactionID,
+			MerkleRoot:             contract.MerkleRoot,
+			UnlockHash:             contract.UnlockHash,
+			Status:                 contract.Status,
+			RevisionNumber:         contract.RevisionNumber,
+			NegotiationHeight:      contract.NegotiationHeight,
+			ExpirationHeight:       contract.ExpirationHeight,
+			ProofDeadline:          contract.ProofDeadline,
+			ProofHeight:            contract.ProofHeight,
+			Payout:                 contract.Payout,
+			FileSize:               contract.FileSize,
+			ValidProofOutputs:      make([]processedSiacoinOutput, len(contract.ValidProofOutputs)),
+			MissedProofOutputs:     make([]processedSiacoinOutput, len(contract.MissedProofOutputs)),
+			NegotiationTimestamp:   contract.NegotiationTimestamp,
+			ExpirationTimestamp:    contract.ExpirationTimestamp,
+			ProofDeadlineTimestamp: contract.ProofDeadlineTimestamp,
+			ProofTimestamp:         contract.ProofTimestamp,
+			ProofConfirmed:         contract.ProofConfirmed,
+			Unused:                 contract.Unused,
+		}
+
+		for j, output := range contract.ValidProofOutputs {
+			_, exists := ownedAddresses[output.UnlockHash]
+			procContract.ValidProofOutputs[j].SiacoinOutput = output
+			procContract.ValidProofOutputs[j].Owned = exists
+		}
+
+		for j, output := range contract.MissedProofOutputs {
+			_, exists := ownedAddresses[output.UnlockHash]
+			procContract.MissedProofOutputs[j].SiacoinOutput = output
+			procContract.MissedProofOutputs[j].Owned = exists
+		}
+
+		processed.ContractRevisions[i] = procContract
+	}
+
+	for _, txnSiafundInput := range txn.SiafundInputs {
+		procSiafundInput := processedSiafundInput{
+			SiafundInput: txnSiafundInput,
+		}
+
+		if _, exists := ownedAddresses[txnSiafundInput.UnlockHash]; exists {
+			procSiafundInput.Owned = true
+			ownedSiafundInput = ownedSiafundInput.Add(txnSiafundInput.Value)
+			ownedSiafundInputsCount++
+		}
+
+		processed.SiafundInputs = append(processed.SiafundInputs, procSiafundInput)
+	}
+
+	for _, txnSiafundOutput := range txn.SiafundOutputs {
+		procSiafundOutput := processedSiafundOutput{
+			SiafundOutput: txnSiafundOutput,
+		}
+
+		if _, exists := ownedAddresses[txnSiafundOutput.UnlockHash]; exists {
+			procSiafundOutput.Owned = true
+			ownedSiafundOutput = ownedSiafundOutput.Add(txnSiafundOutput.Value)
+			ownedSiafundOutputsCount++
+		}
+
+		processed.SiafundOutputs = append(processed.SiafundOutputs, procSiafundOutput)
+	}
+
+	flow := processSiacoins(txn.SiacoinInputs, txn.SiacoinOutputs, ownedAddresses)
+	processed.SiacoinInputs = flow.Inputs
+	processed.SiacoinOutputs = flow.Outputs
+	processed.SiacoinValue = flow.Value
+
+	if len(txn.SiafundInputs) != 0 && len(txn.SiafundOutputs) != 0 {
+		processed.Tags = append(processed.Tags, "siafund_transaction")
+	}
+
+	if len(txn.SiacoinInputs) != 0 && len(txn.SiacoinOutputs) != 0 {
+		processed.Tags = append(processed.Tags, "siacoin_transaction")
+	}
+
+	if len(txn.SiacoinInputs) == 0 && len(txn.SiacoinOutputs) != 0 {
+		processed.Tags = append(processed.Tags, txn.SiacoinOutputs[0].Source)
+	}
+
+	if len(txn.StorageProofs) != 0 {
+		processed.Tags = append(processed.Tags, "storage_proof")
+	}
+
+	if len(txn.StorageContracts) != 0 {
+		processed.Tags = append(processed.Tags, "contract_formation")
+	}
+
+	if len(txn.ContractRevisions) != 0 {
+		processed.Tags = append(processed.Tags, "contract_revision")
+	}
+
+	if len(txn.HostAnnouncements) != 0 {
+		processed.Tags = append(processed.Tags, "host_announcement")
+	}
+
+	if len(txn.SiafundOutputs) == 0 && len(txn.SiacoinOutputs) != 0 && len(txn.SiacoinInputs) == flow.OwnedInputs && len(txn.SiacoinOutputs) == flow.OwnedOutputs {
+		processed.Tags = append(processed.Tags, "defrag")
+	}
+
+	if ownedSiafundOutput.Cmp(ownedSiafundInput) == 1 {
+		processed.SiafundValue.Direction = "received"
+		processed.SiafundValue.Value = ownedSiafundOutput.Sub(ownedSiafundInput)
+	} else {
+		processed.SiafundValue.Direction = "sent"
+		processed.SiafundValue.Value = ownedSiafundInput.Sub(ownedSiafundOutput)
+	}
+
+	ok = processed.SiacoinValue.Value.Cmp64(0) != 0 || processed.SiafundValue.Value.Cmp64(0) != 0
+
+	return
+
+}
+
+//historyLimit the number of transactions loaded for each batch of addresses
+const historyLimit = 500
+
+//historyFloor returns the height the loaded history is complete from. A batch that returned fewer
+//transactions than the limit has its full history, a full batch may be missing transactions
+//older than its oldest one. The seen transactions do not record their batch, so the highest floor
+//of the full batches is used
+func historyFloor(responses []apisdkgo.GetTransactionsResp, limit int) (floor uint64) {
+	for _, callResp := range responses {
+		if len(callResp.Transactions) < limit {
+			continue
+		}
+
+		oldest := callResp.Transactions[0].BlockHeight
+
+		for _, txn := range callResp.Transactions {
+			if txn.BlockHeight < oldest {
+				oldest = txn.BlockHeight
+			}
+		}
+
+		if oldest > floor {
+			floor = oldest
+		}
+	}
+
+	return
+}
+
+//loadTransactions gets the balance, unspent outputs, and last 500 transactions belonging to each
+//address
+func loadTransactions(ctx context.Context, addresses []string, currency string) (resp transactionResp, err error) {
 	transactions := make(map[string]apitypes.Transaction)
 	ownedAddresses := make(map[string]bool)
-	count := len(addresses)
-	resp := transactionResp{}
 
 	for _, addr := range addresses {
 		ownedAddresses[addr] = true
 	}
 
-	for i := 0; i < count; i += 1e3 {
-		end := i + 1e3
+	height, err := currentHeight(ctx, currency)
 
-		if end > count {
-			end = count
-		}
+	if err != nil {
+		err = fmt.Errorf("unable to get block height: %w", err)
+		return
+	}
 
-		apiclient := siacentralAPIClient(currency)
-		callResp, err := apiclient.FindAddressBalance(500, 0, addresses[i:end])
+	responses, err := findBalances(ctx, currency, historyLimit, addresses)
 
-		if err != nil {
-			callback.Invoke(err.Error(), js.Null())
-			return
-		}
+	if err != nil {
+		return
+	}
+
+	resp.historyFloor = historyFloor(responses, historyLimit)
 
+	for _, callResp := range responses {
 		resp.ConfirmedSiacoinBalance = resp.ConfirmedSiacoinBalance.Add(callResp.UnspentSiacoins)
 		resp.ConfirmedSiafundBalance = resp.ConfirmedSiafundBalance.Add(callResp.UnspentSiafunds)
-		resp.UnspentSiacoinOutputs = append(resp.UnspentSiacoinOutputs, callResp.UnspentSiacoinOutputs...)
+
+		// immature block rewards and contract payouts are not spendable until the maturity height
+		for _, output := range callResp.UnspentSiacoinOutputs {
+			if output.MaturityHeight > height {
+				resp.ImmatureSiacoinOutputs = append(resp.ImmatureSiacoinOutputs, output)
+				resp.ImmatureSiacoinBalance = resp.ImmatureSiacoinBalance.Add(output.Value)
+
+				if resp.ConfirmedSiacoinBalance.Cmp(output.Value) >= 0 {
+					resp.ConfirmedSiacoinBalance = resp.ConfirmedSiacoinBalance.Sub(output.Value)
+				}
+
+				continue
+			}
+
+			resp.UnspentSiacoinOutputs = append(resp.UnspentSiacoinOutputs, output)
+		}
+
 		resp.UnspentSiafundOutputs = append(resp.UnspentSiafundOutputs, callResp.UnspentSiafundOutputs...)
 
 		unconfirmedSiacoinDelta := new(big.Int)
@@ -177,224 +610,215 @@ func GetTransactions(addresses []string, currency string, callback js.Value) {
 	}
 
 	for _, txn := range transactions {
-		var ownedSiacoinInput, ownedSiacoinOutput siatypes.Currency
-		var ownedSiafundInput, ownedSiafundOutput siatypes.Currency
-		var ownedSiacoinInputsCount, ownedSiacoinOutputsCount int
-		var ownedSiafundInputsCount, ownedSiafundOutputsCount int
-
-		processed := processedTransaction{
-			TransactionID:     txn.ID,
-			BlockHeight:       txn.BlockHeight,
-			Confirmations:     txn.Confirmations,
-			Timestamp:         txn.Timestamp,
-			Fees:              txn.Fees,
-			StorageProofs:     txn.StorageProofs,
-			HostAnnouncements: txn.HostAnnouncements,
-			Contracts:         make([]processedContract, len(txn.StorageContracts)),
-			ContractRevisions: make([]processedContract, len(txn.ContractRevisions)),
-		}
-
-		for i, contract := range txn.StorageContracts {
-			procContract := processedContract{
-				ID:                     contract.ID,
-				BlockID:                contract.BlockID,
-				TransactionID:          contract.TransactionID,
-				MerkleRoot:             contract.MerkleRoot,
-				UnlockHash:             contract.UnlockHash,
-				Status:                 contract.Status,
-				RevisionNumber:         contract.RevisionNumber,
-				NegotiationHeight:      contract.NegotiationHeight,
-				ExpirationHeight:       contract.ExpirationHeight,
-				ProofDeadline:          contract.ProofDeadline,
-				ProofHeight:            contract.ProofHeight,
-				Payout:                 contract.Payout,
-				FileSize:               contract.FileSize,
-				ValidProofOutputs:      make([]processedSiacoinOutput, len(contract.ValidProofOutputs)),
-				MissedProofOutputs:     make([]processedSiacoinOutput, len(contract.MissedProofOutputs)),
-				NegotiationTimestamp:   contract.NegotiationTimestamp,
-				ExpirationTimestamp:    contract.ExpirationTimestamp,
-				ProofDeadlineTimestamp: contract.ProofDeadlineTimestamp,
-				ProofTimestamp:         contract.ProofTimestamp,
-				ProofConfirmed:         contract.ProofConfirmed,
-				Unused:                 contract.Unused,
-			}
+		if processed, ok := processTransaction(txn, ownedAddresses, height); ok {
+			resp.Transactions = append(resp.Transactions, processed)
+		}
+	}
 
-			for j, output := range contract.ValidProofOutputs {
-				_, exists := ownedAddresses[output.UnlockHash]
-				procContract.ValidProofOutputs[j].SiacoinOutput = output
-				procContract.ValidProofOutputs[j].Owned = exists
-			}
+	sort.Slice(resp.Transactions, func(i, j int) bool {
+		return resp.Transactions[i].Timestamp.After(resp.Transactions[j].Timestamp)
+	})
 
-			for j, output := range contract.MissedProofOutputs {
-				_, exists := ownedAddresses[output.UnlockHash]
-				procContract.MissedProofOutputs[j].SiacoinOutput = output
-				procContract.MissedProofOutputs[j].Owned = exists
-			}
+	annotateConfirmations(&resp, height)
 
-			processed.Contracts[i] = procContract
-		}
-
-		for i, contract := range txn.ContractRevisions {
-			procContract := processedContract{
-				ID:                     contract.ID,
-				BlockID:                contract.BlockID,
-				TransactionID:          contract.TransactionID,
-				MerkleRoot:             contract.MerkleRoot,
-				UnlockHash:             contract.UnlockHash,
-				Status:                 contract.Status,
-				RevisionNumber:         contract.RevisionNumber,
-				NegotiationHeight:      contract.NegotiationHeight,
-				ExpirationHeight:       contract.ExpirationHeight,
-				ProofDeadline:          contract.ProofDeadline,
-				ProofHeight:            contract.ProofHeight,
-				Payout:                 contract.Payout,
-				FileSize:               contract.FileSize,
-				ValidProofOutputs:      make([]processedSiacoinOutput, len(contract.ValidProofOutputs)),
-				MissedProofOutputs:     make([]processedSiacoinOutput, len(contract.MissedProofOutputs)),
-				NegotiationTimestamp:   contract.NegotiationTimestamp,
-				ExpirationTimestamp:    contract.ExpirationTimestamp,
-				ProofDeadlineTimestamp: contract.ProofDeadlineTimestamp,
-				ProofTimestamp:         contract.ProofTimestamp,
-				ProofConfirmed:         contract.ProofConfirmed,
-				Unused:                 contract.Unused,
-			}
+	if addressLabels.Len() != 0 {
+		resp.Labels = addressLabels.Lookup(addresses)
+	}
 
-			for j, output := range contract.ValidProofOutputs {
-				_, exists := ownedAddresses[output.UnlockHash]
-				procContract.ValidProofOutputs[j].SiacoinOutput = output
-				procContract.ValidProofOutputs[j].Owned = exists
-			}
+	return
+}
 
-			for j, output := range contract.MissedProofOutputs {
-				_, exists := ownedAddresses[output.UnlockHash]
-				procContract.MissedProofOutputs[j].SiacoinOutput = output
-				procContract.MissedProofOutputs[j].Owned = exists
-			}
+//annotateConfirmations counts the confirmations of each unspent and immature output from the same
+//height used for the transactions, so spend policies agree with the history
+func annotateConfirmations(resp *transactionResp, height uint64) {
+	resp.OutputConfirmations = make(map[string]uint64)
 
-			processed.ContractRevisions[i] = procContract
-		}
+	for _, output := range resp.UnspentSiacoinOutputs {
+		resp.OutputConfirmations[output.OutputID] = outputConfirmations(height, output.BlockHeight)
+	}
 
-		for _, txnSiafundInput := range txn.SiafundInputs {
-			procSiafundInput := processedSiafundInput{
-				SiafundInput: txnSiafundInput,
-			}
+	for _, output := range resp.ImmatureSiacoinOutputs {
+		resp.OutputConfirmations[output.OutputID] = outputConfirmations(height, output.BlockHeight)
+	}
 
-			if _, exists := ownedAddresses[txnSiafundInput.UnlockHash]; exists {
-				procSiafundInput.Owned = true
-				ownedSiafundInput = ownedSiafundInput.Add(txnSiafundInput.Value)
-				ownedSiafundInputsCount++
-			}
+	for _, output := range resp.UnspentSiafundOutputs {
+		resp.OutputConfirmations[output.OutputID] = outputConfirmations(height, output.BlockHeight)
+	}
+}
 
-			processed.SiafundInputs = append(processed.SiafundInputs, procSiafundInput)
+//applyPendingSpends locks the outputs spent by transactions the wallet has broadcast but are not
+//confirmed yet. The API only reports spends once they reach its transaction pool so the pending
+//spends are added to the spent outputs. Locked outputs are removed from the unspent outputs, so
+//coin selection over them cannot spend an output twice, and the available balance is their sum
+func applyPendingSpends(resp *transactionResp, pending []string) {
+	locked := make(map[string]bool)
+
+	for _, id := range resp.SpentSiacoinOutputs {
+		locked[id] = true
+	}
+
+	for _, id := range pending {
+		if locked[id] {
+			continue
 		}
 
-		for _, txnSiafundOutput := range txn.SiafundOutputs {
-			procSiafundOutput := processedSiafundOutput{
-				SiafundOutput: txnSiafundOutput,
-			}
+		locked[id] = true
+		resp.SpentSiacoinOutputs = append(resp.SpentSiacoinOutputs, id)
+	}
 
-			if _, exists := ownedAddresses[txnSiafundOutput.UnlockHash]; exists {
-				procSiafundOutput.Owned = true
-				ownedSiafundOutput = ownedSiafundOutput.Add(txnSiafundOutput.Value)
-				ownedSiafundOutputsCount++
-			}
+	resp.AvailableSiacoinBalance = siatypes.ZeroCurrency
+	unspent := resp.UnspentSiacoinOutputs[:0]
 
-			processed.SiafundOutputs = append(processed.SiafundOutputs, procSiafundOutput)
+	for _, output := range resp.UnspentSiacoinOutputs {
+		if locked[output.OutputID] {
+			continue
 		}
 
-		for _, txnSiacoinInput := range txn.SiacoinInputs {
-			procSiacoinInput := processedSiacoinInput{
-				SiacoinInput: txnSiacoinInput,
-			}
+		unspent = append(unspent, output)
+		resp.AvailableSiacoinBalance = resp.AvailableSiacoinBalance.Add(output.Value)
+	}
 
-			if _, exists := ownedAddresses[txnSiacoinInput.UnlockHash]; exists {
-				procSiacoinInput.Owned = true
-				ownedSiacoinInput = ownedSiacoinInput.Add(txnSiacoinInput.Value)
-				ownedSiacoinInputsCount++
-			}
+	resp.UnspentSiacoinOutputs = unspent
+}
 
-			processed.SiacoinInputs = append(processed.SiacoinInputs, procSiacoinInput)
+//flagDust lists the unspent outputs worth no more than the dust threshold
+func flagDust(resp *transactionResp, threshold siatypes.Currency) {
+	resp.DustSiacoinOutputs = nil
+
+	for _, output := range resp.UnspentSiacoinOutputs {
+		if output.Value.Cmp(threshold) <= 0 {
+			resp.DustSiacoinOutputs = append(resp.DustSiacoinOutputs, output.OutputID)
 		}
+	}
+}
 
-		for _, txnSiacoinOutput := range txn.SiacoinOutputs {
-			procSiacoinOutput := processedSiacoinOutput{
-				SiacoinOutput: txnSiacoinOutput,
-			}
+//flagInvalidated lists the previously confirmed transactions that are no longer in the history,
+//most likely removed by a reorg. Only the last 500 transactions of each batch of addresses are
+//loaded, so a seen transaction is only flagged if it was confirmed at or after the history floor.
+//Older transactions may have fallen outside the history instead. Nothing is flagged if the history
+//has no confirmed transactions, an empty history is more likely an API failure than a reorg of
+//every transaction. Pending seen transactions are ignored since they can be dropped from the
+//transaction pool
+func flagInvalidated(resp *transactionResp, seen []SeenTransaction) {
+	var confirmed bool
 
-			if _, exists := ownedAddresses[txnSiacoinOutput.UnlockHash]; exists {
-				procSiacoinOutput.Owned = true
-				ownedSiacoinOutput = ownedSiacoinOutput.Add(txnSiacoinOutput.Value)
-				ownedSiacoinInputsCount++
-			}
+	current := make(map[string]bool)
 
-			processed.SiacoinOutputs = append(processed.SiacoinOutputs, procSiacoinOutput)
-		}
+	resp.InvalidatedTransactions = nil
 
-		if len(txn.SiafundInputs) != 0 && len(txn.SiafundOutputs) != 0 {
-			processed.Tags = append(processed.Tags, "siafund_transaction")
-		}
+	for _, txn := range resp.Transactions {
+		current[txn.TransactionID] = true
 
-		if len(txn.SiacoinInputs) != 0 && len(txn.SiacoinOutputs) != 0 {
-			processed.Tags = append(processed.Tags, "siacoin_transaction")
+		if !txn.Pending {
+			confirmed = true
 		}
+	}
 
-		if len(txn.SiacoinInputs) == 0 && len(txn.SiacoinOutputs) != 0 {
-			processed.Tags = append(processed.Tags, txn.SiacoinOutputs[0].Source)
-		}
+	if !confirmed {
+		return
+	}
 
-		if len(txn.StorageProofs) != 0 {
-			processed.Tags = append(processed.Tags, "storage_proof")
+	for _, txn := range seen {
+		if txn.Pending || current[txn.TransactionID] || txn.BlockHeight < resp.historyFloor {
+			continue
 		}
 
-		if len(txn.StorageContracts) != 0 {
-			processed.Tags = append(processed.Tags, "contract_formation")
-		}
+		current[txn.TransactionID] = true
+		resp.InvalidatedTransactions = append(resp.InvalidatedTransactions, txn.TransactionID)
+	}
+}
 
-		if len(txn.ContractRevisions) != 0 {
-			processed.Tags = append(processed.Tags, "contract_revision")
-		}
+//GetTransactions gets the last 500 transactions belonging to each address. The pending output IDs
+//are excluded from the unspent outputs and the available balance. If feePerByte is not zero, the
+//unspent outputs that are not worth spending at the fee are flagged as dust. Any of the seen
+//transactions that were confirmed but are no longer in the history are flagged as invalidated
+func GetTransactions(addresses []string, currency string, pending []string, seen []SeenTransaction, feePerByte siatypes.Currency, callback js.Value) {
+	resp, err := loadTransactions(context.Background(), addresses, currency)
 
-		if len(txn.HostAnnouncements) != 0 {
-			processed.Tags = append(processed.Tags, "host_announcement")
-		}
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
 
-		if len(txn.SiafundOutputs) == 0 && len(txn.SiacoinOutputs) != 0 && len(txn.SiacoinInputs) == ownedSiacoinInputsCount && len(txn.SiacoinOutputs) == ownedSiacoinOutputsCount {
-			processed.Tags = append(processed.Tags, "defrag")
-		}
+	applyPendingSpends(&resp, pending)
+	flagInvalidated(&resp, seen)
 
-		if ownedSiacoinOutput.Cmp(ownedSiacoinInput) == -1 {
-			processed.SiacoinValue.Direction = "sent"
-			processed.SiacoinValue.Value = ownedSiacoinInput.Sub(ownedSiacoinOutput)
-		} else {
-			processed.SiacoinValue.Direction = "received"
-			processed.SiacoinValue.Value = ownedSiacoinOutput.Sub(ownedSiacoinInput)
-		}
+	if !feePerByte.IsZero() {
+		flagDust(&resp, dustThreshold(feePerByte))
+	}
 
-		if ownedSiafundOutput.Cmp(ownedSiafundInput) == 1 {
-			processed.SiafundValue.Direction = "received"
-			processed.SiafundValue.Value = ownedSiafundOutput.Sub(ownedSiafundInput)
-		} else {
-			processed.SiafundValue.Direction = "sent"
-			processed.SiafundValue.Value = ownedSiafundInput.Sub(ownedSiafundOutput)
-		}
+	obj, err := interfaceToJSON(resp)
 
-		if processed.SiacoinValue.Value.Cmp64(0) == 0 && processed.SiafundValue.Value.Cmp64(0) == 0 {
-			continue
-		}
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
 
-		resp.Transactions = append(resp.Transactions, processed)
+	callback.Invoke(js.Null(), obj)
+}
+
+//pendingTransactions gets the unconfirmed transactions in the transaction pool that spend from or
+//send to any of the addresses. A transaction touching more than one address is only returned once
+func pendingTransactions(ctx context.Context, addresses []string, currency string) ([]processedTransaction, error) {
+	seen := make(map[string]bool)
+	ownedAddresses := make(map[string]bool)
+	pending := []processedTransaction{}
+
+	for _, addr := range addresses {
+		ownedAddresses[addr] = true
 	}
 
-	sort.Slice(resp.Transactions, func(i, j int) bool {
-		return resp.Transactions[i].Timestamp.After(resp.Transactions[j].Timestamp)
+	// only the unconfirmed transactions are needed, skip as much of the history as possible
+	responses, err := findBalances(ctx, currency, 1, addresses)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, callResp := range responses {
+		for _, txn := range callResp.UnconfirmedTransactions {
+			if seen[txn.ID] {
+				continue
+			}
+
+			seen[txn.ID] = true
+			txn.Confirmations = 0
+
+			if processed, ok := processTransaction(txn, ownedAddresses, 0); ok {
+				pending = append(pending, processed)
+			}
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Timestamp.After(pending[j].Timestamp)
 	})
 
-	obj, err := interfaceToJSON(resp)
+	return pending, nil
+}
+
+//GetPendingTransactions gets the wallet's transactions that are in the transaction pool but not
+//confirmed yet, with the value each moves in or out of the wallet and its fee
+func GetPendingTransactions(addresses []string, currency string, callback js.Value) {
+	pending, err := pendingTransactions(context.Background(), addresses, currency)
 
 	if err != nil {
 		callback.Invoke(err.Error(), js.Null())
 		return
 	}
 
-	callback.Invoke(js.Null(), obj)
+	transactions := make([]interface{}, len(pending))
+
+	for i, txn := range pending {
+		data, err := interfaceToJSON(txn)
+
+		if err != nil {
+			callback.Invoke(err.Error(), js.Null())
+			return
+		}
+
+		transactions[i] = data
+	}
+
+	callback.Invoke(js.Null(), transactions)
 }
@@ -0,0 +1,510 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/siacentral/apisdkgo"
+	apitypes "github.com/siacentral/apisdkgo/types"
+	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
+	siacrypto "gitlab.com/NebulousLabs/Sia/crypto"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+func TestProcessSiacoinsChange(t *testing.T) {
+	owned := map[string]bool{
+		"wallet1": true,
+		"wallet2": true,
+	}
+
+	inputs := []apitypes.SiacoinInput{
+		{SiacoinOutput: apitypes.SiacoinOutput{UnlockHash: "wallet1", Value: siatypes.SiacoinPrecision.Mul64(1000)}},
+	}
+
+	// send 100 SC with 899 SC change back to the wallet and a 1 SC fee
+	outputs := []apitypes.SiacoinOutput{
+		{UnlockHash: "external", Value: siatypes.SiacoinPrecision.Mul64(100)},
+		{UnlockHash: "wallet2", Value: siatypes.SiacoinPrecision.Mul64(899)},
+	}
+
+	flow := processSiacoins(inputs, outputs, owned)
+
+	if flow.Value.Direction != "sent" {
+		t.Fatalf("expected sent, got %s", flow.Value.Direction)
+	}
+
+	if !flow.Value.Value.Equals(siatypes.SiacoinPrecision.Mul64(101)) {
+		t.Fatalf("expected 101 SC sent, got %s", flow.Value.Value.HumanString())
+	}
+
+	if flow.Outputs[0].Owned || flow.Outputs[0].Change {
+		t.Fatal("expected the recipient output to be external")
+	}
+
+	if !flow.Outputs[1].Owned || !flow.Outputs[1].Change {
+		t.Fatal("expected the wallet output to be change")
+	}
+
+	if flow.OwnedInputs != 1 || flow.OwnedOutputs != 1 {
+		t.Fatalf("expected 1 owned input and output, got %d and %d", flow.OwnedInputs, flow.OwnedOutputs)
+	}
+}
+
+func TestProcessTransactionDefrag(t *testing.T) {
+	owned := map[string]bool{
+		"wallet1": true,
+		"wallet2": true,
+	}
+
+	hasTag := func(processed processedTransaction, tag string) bool {
+		for _, have := range processed.Tags {
+			if have == tag {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	// every input and output belongs to the wallet, only the fee leaves it
+	txn := apitypes.Transaction{
+		SiacoinInputs: []apitypes.SiacoinInput{
+			{SiacoinOutput: apitypes.SiacoinOutput{UnlockHash: "wallet1", Value: siatypes.SiacoinPrecision.Mul64(10)}},
+			{SiacoinOutput: apitypes.SiacoinOutput{UnlockHash: "wallet2", Value: siatypes.SiacoinPrecision.Mul64(20)}},
+		},
+		SiacoinOutputs: []apitypes.SiacoinOutput{
+			{UnlockHash: "wallet1", Value: siatypes.SiacoinPrecision.Mul64(29)},
+		},
+	}
+
+	if processed, ok := processTransaction(txn, owned, 0); !ok || !hasTag(processed, "defrag") {
+		t.Fatalf("expected the consolidation to be tagged as a defrag, got %v", processed.Tags)
+	}
+
+	// paying anything to another address is a send, not a defrag
+	txn.SiacoinOutputs = append(txn.SiacoinOutputs, apitypes.SiacoinOutput{UnlockHash: "external", Value: siatypes.SiacoinPrecision})
+
+	if processed, ok := processTransaction(txn, owned, 0); !ok || hasTag(processed, "defrag") {
+		t.Fatalf("expected a send to not be tagged as a defrag, got %v", processed.Tags)
+	}
+}
+
+func TestProcessSiacoinsReceive(t *testing.T) {
+	owned := map[string]bool{
+		"wallet1": true,
+	}
+
+	inputs := []apitypes.SiacoinInput{
+		{SiacoinOutput: apitypes.SiacoinOutput{UnlockHash: "external", Value: siatypes.SiacoinPrecision.Mul64(1000)}},
+	}
+
+	outputs := []apitypes.SiacoinOutput{
+		{UnlockHash: "wallet1", Value: siatypes.SiacoinPrecision.Mul64(250)},
+		{UnlockHash: "external", Value: siatypes.SiacoinPrecision.Mul64(749)},
+	}
+
+	flow := processSiacoins(inputs, outputs, owned)
+
+	if flow.Value.Direction != "received" {
+		t.Fatalf("expected received, got %s", flow.Value.Direction)
+	}
+
+	if !flow.Value.Value.Equals(siatypes.SiacoinPrecision.Mul64(250)) {
+		t.Fatalf("expected 250 SC received, got %s", flow.Value.Value.HumanString())
+	}
+
+	if !flow.Outputs[0].Owned || flow.Outputs[0].Change {
+		t.Fatal("expected the wallet output to be owned but not change")
+	}
+}
+
+func TestSigHashes(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := testOutputs(t, 10, 20)
+	recipient, err := parseAddress(outputs[0].UnlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unsigned, err := buildTransaction(outputs, []siatypes.SiacoinOutput{
+		{Value: sumOutputs(outputs), UnlockHash: recipient},
+	}, siatypes.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txn := unsigned.Transaction
+	if err := w.SignTransaction(&txn, unsigned.RequiredSigs); err != nil {
+		t.Fatal(err)
+	}
+
+	height := wallet.ASICHardForkHeight("sc")
+
+	hashes, err := sigHashes(txn, nil, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the hashes must be the exact hashes the wallet signed
+	for i, h := range hashes {
+		var sig siacrypto.Signature
+		var pk siacrypto.PublicKey
+
+		copy(sig[:], txn.TransactionSignatures[i].Signature)
+		copy(pk[:], txn.SiacoinInputs[i].UnlockConditions.PublicKeys[0].Key)
+
+		if err := siacrypto.VerifyHash(h, pk, sig); err != nil {
+			t.Fatalf("signature %d does not verify against its sig hash: %s", i, err)
+		}
+	}
+
+	partial, err := sigHashes(txn, &siatypes.CoveredFields{SiacoinInputs: []uint64{0, 1}}, height)
+	if err != nil {
+		t.Fatal(err)
+	} else if partial[0] == hashes[0] {
+		t.Fatal("expected partial coverage to change the sig hash")
+	} else if !txn.TransactionSignatures[0].CoveredFields.WholeTransaction {
+		t.Fatal("expected the coverage to not modify the transaction")
+	}
+
+	// coverage the wallet would not sign is still accepted by consensus
+	foreign, err := sigHashes(txn, &siatypes.CoveredFields{SiacoinOutputs: []uint64{0}}, height)
+	if err != nil {
+		t.Fatal(err)
+	} else if foreign[0] == hashes[0] || foreign[0] == partial[0] {
+		t.Fatal("expected the output coverage to change the sig hash")
+	}
+
+	if _, err := sigHashes(txn, &siatypes.CoveredFields{SiacoinInputs: []uint64{0, 5}}, height); err == nil {
+		t.Fatal("expected out of range coverage to fail")
+	} else if _, err := sigHashes(txn, &siatypes.CoveredFields{SiacoinInputs: []uint64{1, 0}}, height); err == nil {
+		t.Fatal("expected unsorted coverage to fail")
+	}
+}
+
+func TestTransactionSize(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := testOutputs(t, 10, 20, 30)
+	recipient, err := parseAddress(outputs[0].UnlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fee := siatypes.SiacoinPrecision.Div64(10)
+	unsigned, err := buildTransaction(outputs, []siatypes.SiacoinOutput{
+		{Value: siatypes.SiacoinPrecision.Mul64(50), UnlockHash: recipient},
+		{Value: sumOutputs(outputs).Sub(siatypes.SiacoinPrecision.Mul64(50)).Sub(fee), UnlockHash: recipient},
+	}, fee)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txn := unsigned.Transaction
+	txn.ArbitraryData = [][]byte{[]byte("memo")}
+	if err := w.SignTransaction(&txn, unsigned.RequiredSigs); err != nil {
+		t.Fatal(err)
+	}
+
+	// map the transaction to the explorer's representation
+	explorer := apitypes.Transaction{
+		Fees:          fee,
+		MinerFees:     txn.MinerFees,
+		ArbitraryData: txn.ArbitraryData,
+	}
+
+	for _, input := range txn.SiacoinInputs {
+		uc := apitypes.UnlockCondition{
+			Timelock:           uint64(input.UnlockConditions.Timelock),
+			RequiredSignatures: input.UnlockConditions.SignaturesRequired,
+		}
+
+		for _, pk := range input.UnlockConditions.PublicKeys {
+			uc.PublicKeys = append(uc.PublicKeys, pk.String())
+		}
+
+		explorer.SiacoinInputs = append(explorer.SiacoinInputs, apitypes.SiacoinInput{UnlockConditions: uc})
+	}
+
+	for _, output := range txn.SiacoinOutputs {
+		explorer.SiacoinOutputs = append(explorer.SiacoinOutputs, apitypes.SiacoinOutput{Value: output.Value})
+	}
+
+	for _, sig := range txn.TransactionSignatures {
+		explorer.TransactionSignatures = append(explorer.TransactionSignatures, apitypes.TransactionSignature{
+			CoveredFields: apitypes.CoveredFields{WholeTransaction: sig.CoveredFields.WholeTransaction},
+		})
+	}
+
+	var buf bytes.Buffer
+	txn.MarshalSia(&buf)
+
+	size, ok := transactionSize(explorer)
+	if !ok {
+		t.Fatal("expected the size of a siacoin transaction to be known")
+	} else if size != uint64(buf.Len()) {
+		t.Fatalf("expected size %d, got %d", buf.Len(), size)
+	}
+
+	// the explorer reports the accrued claim of a siafund output, the encoded claim start is zero
+	txn.SiafundInputs = []siatypes.SiafundInput{{UnlockConditions: txn.SiacoinInputs[0].UnlockConditions}}
+	txn.SiafundOutputs = []siatypes.SiafundOutput{{Value: siatypes.NewCurrency64(100), UnlockHash: recipient}}
+	explorer.SiafundInputs = []apitypes.SiafundInput{{UnlockConditions: explorer.SiacoinInputs[0].UnlockConditions}}
+	explorer.SiafundOutputs = []apitypes.SiafundOutput{{
+		Value:        siatypes.NewCurrency64(100),
+		SiacoinClaim: siatypes.SiacoinPrecision.Mul64(1000),
+	}}
+
+	buf.Reset()
+	txn.MarshalSia(&buf)
+
+	size, ok = transactionSize(explorer)
+	if !ok {
+		t.Fatal("expected the size of a siafund transaction to be known")
+	} else if size != uint64(buf.Len()) {
+		t.Fatalf("expected siafund transaction size %d, got %d", buf.Len(), size)
+	}
+
+	explorer.StorageProofs = []apitypes.StorageProof{{}}
+	if _, ok := transactionSize(explorer); ok {
+		t.Fatal("expected the size of a transaction with a storage proof to be unknown")
+	}
+}
+
+func TestApplyPendingSpends(t *testing.T) {
+	outputs := testOutputs(t, 1, 2, 4)
+	resp := transactionResp{
+		// the first output is already in the API's transaction pool
+		SpentSiacoinOutputs: []string{outputs[0].OutputID},
+	}
+
+	for _, output := range outputs {
+		resp.UnspentSiacoinOutputs = append(resp.UnspentSiacoinOutputs, output.SiacoinOutput)
+	}
+
+	applyPendingSpends(&resp, []string{outputs[0].OutputID, outputs[1].OutputID})
+
+	if !resp.AvailableSiacoinBalance.Equals(siatypes.SiacoinPrecision.Mul64(4)) {
+		t.Fatalf("expected 4 SC available, got %s", resp.AvailableSiacoinBalance.HumanString())
+	}
+
+	if len(resp.SpentSiacoinOutputs) != 2 || resp.SpentSiacoinOutputs[1] != outputs[1].OutputID {
+		t.Fatalf("expected pending spends to be added once, got %v", resp.SpentSiacoinOutputs)
+	}
+
+	if len(resp.UnspentSiacoinOutputs) != 1 || resp.UnspentSiacoinOutputs[0].OutputID != outputs[2].OutputID {
+		t.Fatalf("expected only the unlocked output to be unspent, got %v", resp.UnspentSiacoinOutputs)
+	}
+
+	// selecting from the unspent outputs skips the locked outputs, even though the smallest first
+	// strategy would otherwise pick them
+	var spendable []SpendableOutput
+
+	for _, output := range outputs {
+		for _, unspent := range resp.UnspentSiacoinOutputs {
+			if output.OutputID == unspent.OutputID {
+				spendable = append(spendable, output)
+			}
+		}
+	}
+
+	inputs, _, err := selectUTXOs(spendable, 0, siatypes.SiacoinPrecision, siatypes.NewCurrency64(1), 2, strategySmallestFirst)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(inputs) != 1 || inputs[0].OutputID != outputs[2].OutputID {
+		t.Fatalf("expected only the unlocked output to be selected, got %v", inputs)
+	}
+
+	applyPendingSpends(&resp, nil)
+
+	if !resp.AvailableSiacoinBalance.Equals(siatypes.SiacoinPrecision.Mul64(4)) {
+		t.Fatalf("expected available balance to be recomputed, got %s", resp.AvailableSiacoinBalance.HumanString())
+	}
+}
+
+func TestPendingTransactions(t *testing.T) {
+	txn := `{"id":"txn1","fees":"1000","siacoin_inputs":[{"output_id":"out1","unlock_hash":"wallet1","value":"5000"}],"siacoin_outputs":[{"output_id":"out2","unlock_hash":"other","value":"4000"}]}`
+	unrelated := `{"id":"txn2","siacoin_inputs":[{"output_id":"out3","unlock_hash":"other","value":"10"}],"siacoin_outputs":[{"output_id":"out4","unlock_hash":"other","value":"10"}]}`
+	canned := &cannedTransport{
+		responses: map[string]string{
+			// the same transaction is returned for each address it touches
+			"/v2/wallet/addresses": `{"type":"success","unconfirmed_transactions":[` + txn + `,` + txn + `,` + unrelated + `]}`,
+		},
+	}
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	pending, err := pendingTransactions(context.Background(), []string{"wallet1"}, "sc")
+
+	if err != nil {
+		t.Fatal(err)
+	} else if len(pending) != 1 {
+		t.Fatalf("expected 1 pending transaction, got %d", len(pending))
+	}
+
+	if !pending[0].Pending || pending[0].TransactionID != "txn1" {
+		t.Fatalf("unexpected transaction %+v", pending[0])
+	} else if pending[0].SiacoinValue.Direction != "sent" || !pending[0].SiacoinValue.Value.Equals64(5000) {
+		t.Fatalf("unexpected value %s %s", pending[0].SiacoinValue.Direction, pending[0].SiacoinValue.Value)
+	} else if !pending[0].Fees.Equals64(1000) {
+		t.Fatalf("unexpected fee %s", pending[0].Fees)
+	}
+}
+
+func TestLoadTransactionsSharedOutput(t *testing.T) {
+	canned := &cannedTransport{
+		responses: map[string]string{
+			"/v2/explorer/blocks": `{"type":"success","block":{"height":1000}}`,
+			// the multisig output is returned for the wallet's addresses in both batches
+			"/v2/wallet/addresses": `{"type":"success","unspent_siacoins":"5000","unspent_siafunds":"10","unspent_siacoin_outputs":[{"output_id":"shared","unlock_hash":"multisig","value":"5000"}],"unspent_siafund_outputs":[{"output_id":"sharedsf","unlock_hash":"multisig","value":"10"}]}`,
+		},
+	}
+
+	chainTips.Clear()
+	defer chainTips.Clear()
+
+	SetTransport(canned)
+	defer SetTransport(nil)
+
+	addresses := make([]string, 1001)
+	for i := range addresses {
+		addresses[i] = fmt.Sprintf("wallet%d", i)
+	}
+
+	resp, err := loadTransactions(context.Background(), addresses, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(canned.requests) != 3 {
+		t.Fatalf("expected the block and 2 batch requests, got %d", len(canned.requests))
+	} else if len(resp.UnspentSiacoinOutputs) != 1 || len(resp.UnspentSiafundOutputs) != 1 {
+		t.Fatalf("expected the shared outputs once, got %d and %d", len(resp.UnspentSiacoinOutputs), len(resp.UnspentSiafundOutputs))
+	} else if !resp.ConfirmedSiacoinBalance.Equals64(5000) {
+		t.Fatalf("expected the shared output to be counted once, got %s", resp.ConfirmedSiacoinBalance)
+	} else if !resp.ConfirmedSiafundBalance.Equals64(10) {
+		t.Fatalf("expected the shared siafund output to be counted once, got %s", resp.ConfirmedSiafundBalance)
+	}
+}
+
+func TestFlagDust(t *testing.T) {
+	feePerByte := siatypes.SiacoinPrecision.Div64(1e6)
+	threshold := dustThreshold(feePerByte)
+	resp := transactionResp{
+		UnspentSiacoinOutputs: []apitypes.SiacoinOutput{
+			{OutputID: "dust", Value: threshold.Sub64(1)},
+			{OutputID: "even", Value: threshold},
+			{OutputID: "spendable", Value: threshold.Add64(1)},
+		},
+	}
+
+	// the threshold is the fee difference of adding one more input
+	if !threshold.Equals(estimateTransactionFee(feePerByte, 2, 1).Sub(estimateTransactionFee(feePerByte, 1, 1))) {
+		t.Fatalf("expected threshold to match the marginal input fee, got %s", threshold)
+	}
+
+	flagDust(&resp, threshold)
+
+	if len(resp.DustSiacoinOutputs) != 2 || resp.DustSiacoinOutputs[0] != "dust" || resp.DustSiacoinOutputs[1] != "even" {
+		t.Fatalf("unexpected dust outputs %v", resp.DustSiacoinOutputs)
+	}
+}
+
+func TestAnnotateConfirmations(t *testing.T) {
+	resp := transactionResp{
+		UnspentSiacoinOutputs: []apitypes.SiacoinOutput{
+			{OutputID: "confirmed", BlockHeight: 98},
+			{OutputID: "unconfirmed"},
+		},
+		ImmatureSiacoinOutputs: []apitypes.SiacoinOutput{
+			{OutputID: "immature", BlockHeight: 100},
+		},
+		UnspentSiafundOutputs: []apitypes.SiafundOutput{
+			{OutputID: "siafund", BlockHeight: 91},
+		},
+	}
+
+	annotateConfirmations(&resp, 100)
+
+	expected := map[string]uint64{
+		"confirmed":   3,
+		"unconfirmed": 0,
+		"immature":    1,
+		"siafund":     10,
+	}
+
+	if len(resp.OutputConfirmations) != len(expected) {
+		t.Fatalf("expected %d outputs, got %v", len(expected), resp.OutputConfirmations)
+	}
+
+	for id, confirmations := range expected {
+		if c, exists := resp.OutputConfirmations[id]; !exists || c != confirmations {
+			t.Errorf("%s: expected %d confirmations, got %d", id, confirmations, c)
+		}
+	}
+}
+
+func TestFlagInvalidated(t *testing.T) {
+	resp := transactionResp{
+		Transactions: []processedTransaction{
+			{TransactionID: "txn1", BlockHeight: 120},
+			{TransactionID: "txn2", BlockHeight: 110},
+			{TransactionID: "txn3", Pending: true},
+		},
+		historyFloor: 110,
+	}
+
+	flagInvalidated(&resp, []SeenTransaction{
+		{TransactionID: "txn1", BlockHeight: 120},
+		// reorged out of the chain
+		{TransactionID: "txn4", BlockHeight: 115},
+		{TransactionID: "txn4", BlockHeight: 115},
+		// older than the loaded history
+		{TransactionID: "txn5", BlockHeight: 100},
+		// dropped from the transaction pool
+		{TransactionID: "txn6", Pending: true},
+	})
+
+	if len(resp.InvalidatedTransactions) != 1 || resp.InvalidatedTransactions[0] != "txn4" {
+		t.Fatalf("expected only txn4 to be invalidated, got %v", resp.InvalidatedTransactions)
+	}
+
+	// a history without confirmed transactions is not trusted to invalidate anything
+	resp = transactionResp{Transactions: []processedTransaction{{TransactionID: "txn3", Pending: true}}}
+	flagInvalidated(&resp, []SeenTransaction{{TransactionID: "txn1", BlockHeight: 120}})
+
+	if len(resp.InvalidatedTransactions) != 0 {
+		t.Fatalf("expected nothing to be invalidated, got %v", resp.InvalidatedTransactions)
+	}
+}
+
+func TestHistoryFloor(t *testing.T) {
+	batch := func(heights ...uint64) (resp apisdkgo.GetTransactionsResp) {
+		for _, height := range heights {
+			resp.Transactions = append(resp.Transactions, apitypes.Transaction{BlockHeight: height})
+		}
+
+		return
+	}
+
+	// the batch with its full history does not lower the floor of the truncated batch
+	responses := []apisdkgo.GetTransactionsResp{batch(50, 60), batch(300, 200, 250), batch(400, 100, 90)}
+
+	if floor := historyFloor(responses, 3); floor != 200 {
+		t.Fatalf("expected floor 200, got %d", floor)
+	}
+
+	if floor := historyFloor(responses[:1], 3); floor != 0 {
+		t.Fatalf("expected a complete history to have floor 0, got %d", floor)
+	}
+}
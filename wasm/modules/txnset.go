@@ -0,0 +1,103 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+//transactionSet returns the transaction set needed to broadcast the transactions. Any unconfirmed
+//parent transaction creating an output spent by the transactions is added before the transactions
+//that depend on it. Inputs not created by one of the parents are assumed to be confirmed
+func transactionSet(txns, parents []siatypes.Transaction) ([]siatypes.Transaction, error) {
+	var set []siatypes.Transaction
+
+	creators := make(map[siatypes.SiacoinOutputID]int)
+
+	for i, parent := range parents {
+		for j := range parent.SiacoinOutputs {
+			creators[parent.SiacoinOutputID(uint64(j))] = i
+		}
+	}
+
+	// 1 while a parent's own parents are being added, 2 once it has been added
+	state := make(map[int]int)
+
+	var addParents func(txn siatypes.Transaction) error
+	addParents = func(txn siatypes.Transaction) error {
+		for _, input := range txn.SiacoinInputs {
+			i, exists := creators[input.ParentID]
+
+			if !exists {
+				continue
+			}
+
+			switch state[i] {
+			case 1:
+				return errors.New("parent transactions contain a cycle")
+			case 2:
+				continue
+			}
+
+			state[i] = 1
+
+			if err := addParents(parents[i]); err != nil {
+				return err
+			}
+
+			state[i] = 2
+			set = append(set, parents[i])
+		}
+
+		return nil
+	}
+
+	for _, txn := range txns {
+		if err := addParents(txn); err != nil {
+			return nil, err
+		}
+	}
+
+	return append(set, txns...), nil
+}
+
+//BuildTransactionSet signs the transactions and returns the full transaction set to broadcast,
+//including any of the unconfirmed parent transactions the signed transactions spend outputs of.
+//Without the parents a transaction spending unconfirmed change is rejected
+func BuildTransactionSet(phrase, currency string, unsigned []UnsignedTransaction, parents []siatypes.Transaction, accountOffset uint64, callback js.Value) {
+	w, err := recoverWallet(phrase, currency, accountOffset)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	signed := make([]siatypes.Transaction, len(unsigned))
+
+	for i, u := range unsigned {
+		signed[i] = u.Transaction
+
+		if err := w.SignTransaction(&signed[i], u.RequiredSigs); err != nil {
+			callback.Invoke(fmt.Errorf("unable to sign transaction %d: %w", i, err).Error(), js.Null())
+			return
+		}
+	}
+
+	set, err := transactionSet(signed, parents)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(set)
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
@@ -0,0 +1,51 @@
+package modules
+
+import (
+	"testing"
+
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+func TestTransactionSet(t *testing.T) {
+	unrelated := siatypes.Transaction{
+		SiacoinOutputs: []siatypes.SiacoinOutput{{Value: siatypes.SiacoinPrecision}},
+	}
+	grandparent := siatypes.Transaction{
+		SiacoinInputs:  []siatypes.SiacoinInput{{ParentID: siatypes.SiacoinOutputID{1}}},
+		SiacoinOutputs: []siatypes.SiacoinOutput{{Value: siatypes.SiacoinPrecision.Mul64(10)}},
+	}
+	parent := siatypes.Transaction{
+		SiacoinInputs:  []siatypes.SiacoinInput{{ParentID: grandparent.SiacoinOutputID(0)}},
+		SiacoinOutputs: []siatypes.SiacoinOutput{{Value: siatypes.SiacoinPrecision.Mul64(5)}, {Value: siatypes.SiacoinPrecision.Mul64(4)}},
+	}
+	child := siatypes.Transaction{
+		SiacoinInputs: []siatypes.SiacoinInput{
+			{ParentID: parent.SiacoinOutputID(0)},
+			{ParentID: parent.SiacoinOutputID(1)},
+			{ParentID: siatypes.SiacoinOutputID{2}},
+		},
+	}
+
+	set, err := transactionSet([]siatypes.Transaction{child}, []siatypes.Transaction{parent, unrelated, grandparent})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []siatypes.TransactionID{grandparent.ID(), parent.ID(), child.ID()}
+	if len(set) != len(expected) {
+		t.Fatalf("expected %d transactions, got %d", len(expected), len(set))
+	}
+
+	for i, id := range expected {
+		if set[i].ID() != id {
+			t.Fatalf("transaction %d: expected %s, got %s", i, id, set[i].ID())
+		}
+	}
+
+	set, err = transactionSet([]siatypes.Transaction{child}, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(set) != 1 {
+		t.Fatalf("expected only the child with confirmed inputs, got %d transactions", len(set))
+	}
+}
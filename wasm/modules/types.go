@@ -4,6 +4,7 @@ import (
 	"time"
 
 	apitypes "github.com/siacentral/apisdkgo/types"
+	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
 	siatypes "gitlab.com/NebulousLabs/Sia/types"
 )
 
@@ -37,7 +38,10 @@ type (
 		TransactionID     string                   `json:"transaction_id"`
 		BlockHeight       uint64                   `json:"block_height"`
 		Confirmations     uint64                   `json:"confirmations"`
+		Pending           bool                     `json:"pending"`
 		Fees              siatypes.Currency        `json:"fees"`
+		Size              uint64                   `json:"size,omitempty"`
+		FeeRate           *siatypes.Currency       `json:"fee_rate,omitempty"`
 		SiacoinValue      processedTxnValue        `json:"siacoin_value"`
 		SiafundValue      processedTxnValue        `json:"siafund_value"`
 		Timestamp         time.Time                `json:"timestamp"`
@@ -59,7 +63,8 @@ type (
 
 	processedSiacoinOutput struct {
 		apitypes.SiacoinOutput
-		Owned bool `json:"owned"`
+		Owned  bool `json:"owned"`
+		Change bool `json:"change"`
 	}
 
 	processedSiacoinInput struct {
@@ -77,16 +82,45 @@ type (
 		Owned bool `json:"owned"`
 	}
 
+	//siacoinFlow the wallet's processed siacoin inputs and outputs of a transaction and the net
+	//value moved
+	siacoinFlow struct {
+		Inputs       []processedSiacoinInput
+		Outputs      []processedSiacoinOutput
+		OwnedInputs  int
+		OwnedOutputs int
+		Value        processedTxnValue
+	}
+
+	//immatureOutput a block reward or contract payout that cannot be spent until it matures
+	immatureOutput struct {
+		OutputID       string            `json:"output_id"`
+		Source         string            `json:"source"`
+		Value          siatypes.Currency `json:"value"`
+		MaturityHeight uint64            `json:"maturity_height"`
+	}
+
 	transactionResp struct {
 		Transactions            []processedTransaction   `json:"transactions"`
 		UnspentSiacoinOutputs   []apitypes.SiacoinOutput `json:"unspent_siacoin_outputs"`
 		UnspentSiafundOutputs   []apitypes.SiafundOutput `json:"unspent_siafund_outputs"`
+		ImmatureSiacoinOutputs  []apitypes.SiacoinOutput `json:"immature_siacoin_outputs"`
 		SpentSiacoinOutputs     []string                 `json:"spent_siacoin_outputs"`
+		DustSiacoinOutputs      []string                 `json:"dust_siacoin_outputs,omitempty"`
+		InvalidatedTransactions []string                 `json:"invalidated_transactions,omitempty"`
 		SpentSiafundOutputs     []string                 `json:"spent_siafund_outputs"`
 		ConfirmedSiafundBalance siatypes.Currency        `json:"confirmed_siafund_balance"`
 		ConfirmedSiacoinBalance siatypes.Currency        `json:"confirmed_siacoin_balance"`
+		ImmatureSiacoinBalance  siatypes.Currency        `json:"immature_siacoin_balance"`
+		AvailableSiacoinBalance siatypes.Currency        `json:"available_siacoin_balance"`
 		UnconfirmedSiacoinDelta string                   `json:"unconfirmed_siacoin_delta"`
 		UnconfirmedSiafundDelta string                   `json:"unconfirmed_siafund_delta"`
+		Labels                  map[string]string        `json:"labels,omitempty"`
+		//OutputConfirmations the confirmations of each unspent and immature output by output ID
+		OutputConfirmations map[string]uint64 `json:"output_confirmations"`
+
+		// the height the loaded history is complete from, see historyFloor
+		historyFloor uint64
 	}
 
 	// UnsignedTransaction a transaction and the required signature indices to sign that transaction
@@ -94,4 +128,50 @@ type (
 		Transaction  siatypes.Transaction `json:"transaction"`
 		RequiredSigs []uint64             `json:"requiredSignatures"`
 	}
+
+	// SeenTransaction a transaction from a previous GetTransactions response
+	SeenTransaction struct {
+		TransactionID string `json:"transaction_id"`
+		BlockHeight   uint64 `json:"block_height"`
+		Pending       bool   `json:"pending"`
+	}
+
+	// SendRecipient an address and the amount of siacoins to send to it
+	SendRecipient struct {
+		Address string            `json:"address"`
+		Amount  siatypes.Currency `json:"amount"`
+	}
+
+	// IndexRange an inclusive range of address indices
+	IndexRange struct {
+		Start uint64 `json:"start"`
+		End   uint64 `json:"end"`
+	}
+
+	// RecoveryOptions the options of a RecoverAddresses scan, see RecoverAddresses
+	RecoveryOptions struct {
+		StartIndex       uint64       `json:"start_index"`
+		MaxEmptyRounds   uint64       `json:"max_empty_rounds"`
+		AddressCount     uint64       `json:"address_count"`
+		LastKnownIndex   uint64       `json:"last_known_index"`
+		Additional       []IndexRange `json:"ranges"`
+		Compress         bool         `json:"compress"`
+		ProgressInterval uint64       `json:"progress_interval_ms"`
+		SkipLookahead    bool         `json:"skip_lookahead"`
+		ResendAll        bool         `json:"resend_all"`
+		MinRoundSize     uint64       `json:"min_round_size"`
+		MaxRetries       uint64       `json:"max_retries"`
+		AddressGapLimit  uint64       `json:"address_gap_limit"`
+		Verify           bool         `json:"verify"`
+		AccountOffset    uint64       `json:"account_offset"`
+		Incremental      bool         `json:"incremental"`
+		ScanID           string       `json:"scan_id"`
+	}
+
+	// SpendableOutput an unspent siacoin output joined with the wallet address that can spend it
+	SpendableOutput struct {
+		apitypes.SiacoinOutput
+		Index            uint64                  `json:"index"`
+		UnlockConditions wallet.UnlockConditions `json:"unlock_conditions"`
+	}
 )
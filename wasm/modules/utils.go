@@ -1,31 +1,37 @@
 package modules
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
+	"syscall/js"
 
-	"github.com/siacentral/apisdkgo"
 	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
 	siatypes "gitlab.com/NebulousLabs/Sia/types"
 )
 
 const (
 	workers = 5
-)
 
-func siacentralAPIClient(currency string) *apisdkgo.APIClient {
-	var baseAddress string
+	//progressChunkSize the maximum number of addresses sent to JS in a single progress event
+	progressChunkSize = 1000
 
-	switch currency {
-	case "scp":
-		baseAddress = "https://api.siacentral.com/v2/scprime"
-	default:
-		baseAddress = "https://api.siacentral.com/v2"
-	}
+	//maxDerivedIndex the highest index a search deriving every address up to a caller's maximum
+	//index will derive. Each address takes a key derivation, a larger maximum never finishes
+	maxDerivedIndex = 1000000
+)
 
-	return &apisdkgo.APIClient{
-		BaseAddress: baseAddress,
+//checkDerivedIndex returns an error if maxIndex is above maxDerivedIndex. The search is refused
+//instead of capped, an address past the cap would otherwise be reported as not the wallet's
+func checkDerivedIndex(maxIndex uint64) error {
+	if maxIndex > maxDerivedIndex {
+		return fmt.Errorf("max index %d is above the maximum of %d", maxIndex, maxDerivedIndex)
 	}
+
+	return nil
 }
 
 func interfaceToJSON(obj interface{}) (con map[string]interface{}, err error) {
@@ -40,12 +46,73 @@ func interfaceToJSON(obj interface{}) (con map[string]interface{}, err error) {
 	return
 }
 
-func recoverWallet(seed, currency string) (*wallet.SeedWallet, error) {
+//encodePayload converts obj into a value that can be passed to JS. When compress is true the
+//object is JSON encoded, gzipped, and returned as a Uint8Array for the frontend to decompress
+//which is much cheaper to copy across the boundary for large payloads
+func encodePayload(obj interface{}, compress bool) (interface{}, error) {
+	if !compress {
+		return interfaceToJSON(obj)
+	}
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+
+	if err := json.NewEncoder(gz).Encode(obj); err != nil {
+		return nil, err
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	arr := js.Global().Get("Uint8Array").New(buf.Len())
+	js.CopyBytesToJS(arr, buf.Bytes())
+
+	return arr, nil
+}
+
+//normalizeSeed trims the seed, collapses any whitespace between words to a single space, and
+//lowercases the words so a sloppy paste still recovers the same wallet
+func normalizeSeed(seed string) (string, error) {
+	words := strings.Fields(seed)
+
+	if len(words) == 0 {
+		return "", errors.New("seed is empty")
+	}
+
+	return strings.ToLower(strings.Join(words, " ")), nil
+}
+
+//recoverWallet recovers a BIP39 or Sia seed. Every address of the wallet is derived at its index plus
+//accountOffset, 0 for the default account
+func recoverWallet(seed, currency string, accountOffset uint64) (*wallet.SeedWallet, error) {
+	seed, err := normalizeSeed(seed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// a watch-only export passed in place of the seed has no secret keys to recover
+	if strings.HasPrefix(seed, "{") {
+		return nil, wallet.ErrWatchOnly
+	}
+
+	var w *wallet.SeedWallet
+
 	if len(strings.Split(seed, " ")) < 20 {
-		return wallet.RecoverBIP39Seed(seed, currency)
+		w, err = wallet.RecoverBIP39Seed(seed, currency)
+	} else {
+		w, err = wallet.RecoverSiaSeed(seed, currency)
+	}
+
+	if err != nil {
+		return nil, err
 	}
 
-	return wallet.RecoverSiaSeed(seed, currency)
+	w.AccountOffset = accountOffset
+
+	return w, nil
 }
 
 func mapUnlockConditions(sia siatypes.UnlockConditions) (unlockConds wallet.UnlockConditions) {
@@ -60,3 +127,23 @@ func mapUnlockConditions(sia siatypes.UnlockConditions) (unlockConds wallet.Unlo
 
 	return
 }
+
+//unmapUnlockConditions converts the managable unlock conditions type back to sia unlock conditions
+func unmapUnlockConditions(unlockConds wallet.UnlockConditions) (sia siatypes.UnlockConditions, err error) {
+	sia = siatypes.UnlockConditions{
+		Timelock:           siatypes.BlockHeight(unlockConds.Timelock),
+		SignaturesRequired: unlockConds.SignaturesRequired,
+	}
+
+	for _, str := range unlockConds.PublicKeys {
+		var pubkey siatypes.SiaPublicKey
+
+		if err = pubkey.LoadString(str); err != nil {
+			return sia, fmt.Errorf("unable to parse public key %q: %w", str, err)
+		}
+
+		sia.PublicKeys = append(sia.PublicKeys, pubkey)
+	}
+
+	return
+}
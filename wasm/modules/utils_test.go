@@ -0,0 +1,149 @@
+package modules
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"syscall/js"
+	"testing"
+
+	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
+)
+
+func TestRecoverWalletNormalization(t *testing.T) {
+	siaPhrase, err := wallet.NewSiaRecoveryPhrase()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		phrase  string
+		variant string
+	}{
+		{"bip39 surrounding spaces", testPhrase, "  " + testPhrase + " \n"},
+		{"bip39 tabs", testPhrase, "abandon\tabandon abandon  abandon abandon abandon\t\tabandon abandon abandon abandon abandon about"},
+		{"bip39 mixed case", testPhrase, "Abandon ABANDON abandon abandon abandon abandon abandon abandon abandon abandon abandon About"},
+		{"sia surrounding spaces", siaPhrase, "\t" + siaPhrase + "  "},
+		{"sia mixed case", siaPhrase, "  " + strings.ToUpper(siaPhrase[:10]) + siaPhrase[10:]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, err := recoverWallet(tt.phrase, "sc", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w, err := recoverWallet(tt.variant, "sc", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if w.GetAddress(0).UnlockConditions.UnlockHash() != expected.GetAddress(0).UnlockConditions.UnlockHash() {
+				t.Fatal("expected the normalized seed to recover the same wallet")
+			}
+		})
+	}
+
+	for _, seed := range []string{"", "   ", "\t\n "} {
+		if _, err := recoverWallet(seed, "sc", 0); err == nil || err.Error() != "seed is empty" {
+			t.Fatalf("expected empty seed error for %q, got %v", seed, err)
+		}
+	}
+}
+
+func TestRecoverWalletWatchOnly(t *testing.T) {
+	w, err := recoverWallet(testPhrase, "sc", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	export, err := w.ExportWatchOnly(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := json.Marshal(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := recoverWallet(string(buf), "sc", 0); err != wallet.ErrWatchOnly {
+		t.Fatalf("expected watch-only error, got %v", err)
+	}
+}
+
+func TestRecoverWalletAccountOffset(t *testing.T) {
+	base, err := recoverWallet(testPhrase, "sc", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := recoverWallet(testPhrase, "sc", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the account's addresses keep their own indices
+	addr := generateAddress(account, 0)
+	if addr.Index != 0 {
+		t.Fatalf("expected index 0, got %d", addr.Index)
+	} else if addr.Address != generateAddress(base, 20).Address {
+		t.Fatal("expected the account's first address to be the seed's address at index 20")
+	}
+}
+
+func TestEncodePayloadRoundTrip(t *testing.T) {
+	payload := map[string]interface{}{
+		"found":     2,
+		"addresses": syntheticRound(2),
+		"index":     uint64(1),
+	}
+
+	expected, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compressed, err := encodePayload(payload, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	arr := compressed.(js.Value)
+	buf := make([]byte, arr.Length())
+	js.CopyBytesToGo(buf, arr)
+
+	gz, err := gzip.NewReader(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decompressed, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	} else if strings.TrimSpace(string(decompressed)) != string(expected) {
+		t.Fatalf("expected %s, got %s", expected, decompressed)
+	}
+
+	// the uncompressed payload is the same JSON as a JS object, the key order may differ
+	plain, err := encodePayload(payload, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want, got interface{}
+
+	str := js.Global().Get("JSON").Call("stringify", plain).String()
+	if err := json.Unmarshal(expected, &want); err != nil {
+		t.Fatal(err)
+	} else if err := json.Unmarshal([]byte(str), &got); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected %s, got %s", expected, str)
+	}
+}
@@ -0,0 +1,365 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+
+	apitypes "github.com/siacentral/apisdkgo/types"
+	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
+	siacrypto "gitlab.com/NebulousLabs/Sia/crypto"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+const (
+	//transactionSizeLimit the maximum encoded size of a transaction accepted by the transaction pool
+	//https://gitlab.com/NebulousLabs/Sia/blob/fb65620/modules/transactionpool.go#L46
+	transactionSizeLimit = 32e3
+	//timelockLimit the highest timelock considered intentional, about 190 years of blocks
+	timelockLimit = 10e6
+)
+
+type (
+	//balanceCheck the value moved by a transaction. Excess is the value of the inputs not spent by
+	//the outputs and fees, shortfall is the value of the outputs and fees not covered by the inputs.
+	//The sums are only set if the value of every spent output is known
+	balanceCheck struct {
+		Balanced         bool              `json:"balanced"`
+		SiacoinInputs    siatypes.Currency `json:"siacoin_inputs"`
+		SiacoinOutputs   siatypes.Currency `json:"siacoin_outputs"`
+		Fees             siatypes.Currency `json:"fees"`
+		SiacoinExcess    siatypes.Currency `json:"siacoin_excess"`
+		SiacoinShortfall siatypes.Currency `json:"siacoin_shortfall"`
+		SiafundInputs    siatypes.Currency `json:"siafund_inputs"`
+		SiafundOutputs   siatypes.Currency `json:"siafund_outputs"`
+		SiafundExcess    siatypes.Currency `json:"siafund_excess"`
+		SiafundShortfall siatypes.Currency `json:"siafund_shortfall"`
+		Missing          []string          `json:"missing"`
+	}
+)
+
+//validateTransaction checks the transaction against the consensus rules that can be checked
+//without the blockchain and returns every violation instead of stopping at the first. spent are the
+//outputs spent by the transaction's siacoin inputs, they are required to check the input values and
+//unlock conditions
+func validateTransaction(txn siatypes.Transaction, spent []apitypes.SiacoinOutput, asicHardForkHeight siatypes.BlockHeight) (violations []string) {
+	var buf bytes.Buffer
+	var inputSum siatypes.Currency
+
+	known := true
+
+	violate := func(format string, args ...interface{}) {
+		violations = append(violations, fmt.Sprintf(format, args...))
+	}
+
+	txn.MarshalSia(&buf)
+
+	if buf.Len() > transactionSizeLimit {
+		violate("transaction size %d bytes exceeds the limit of %d bytes", buf.Len(), int(transactionSizeLimit))
+	}
+
+	for i, output := range txn.SiacoinOutputs {
+		if output.Value.Big().Sign() < 0 {
+			violate("siacoin output %d has a negative value", i)
+		} else if output.Value.IsZero() {
+			violate("siacoin output %d has a zero value", i)
+		}
+	}
+
+	for i, fee := range txn.MinerFees {
+		if fee.Big().Sign() < 0 {
+			violate("miner fee %d has a negative value", i)
+		} else if fee.IsZero() {
+			violate("miner fee %d has a zero value", i)
+		}
+	}
+
+	outputs := make(map[string]apitypes.SiacoinOutput)
+
+	for _, output := range spent {
+		outputs[output.OutputID] = output
+	}
+
+	seen := make(map[siatypes.SiacoinOutputID]bool)
+
+	for i, input := range txn.SiacoinInputs {
+		if seen[input.ParentID] {
+			violate("siacoin input %d spends output %s more than once", i, input.ParentID)
+		}
+
+		seen[input.ParentID] = true
+
+		output, exists := outputs[input.ParentID.String()]
+
+		if !exists {
+			violate("siacoin input %d spends unknown output %s", i, input.ParentID)
+			known = false
+			continue
+		}
+
+		if input.UnlockConditions.UnlockHash().String() != output.UnlockHash {
+			violate("siacoin input %d unlock conditions do not match the address of output %s", i, input.ParentID)
+		}
+
+		inputSum = inputSum.Add(output.Value)
+	}
+
+	// the input value is only known if every spent output is
+	if known {
+		if outputSum := txn.SiacoinOutputSum(); !inputSum.Equals(outputSum) {
+			violate("siacoin inputs %s H do not equal siacoin outputs and fees %s H", inputSum, outputSum)
+		}
+	}
+
+	violations = append(violations, validateSignatures(txn, asicHardForkHeight)...)
+
+	return
+}
+
+//validateSignatures checks that every siacoin input has enough valid signatures from its unlock
+//conditions. Only ed25519 keys are verified
+func validateSignatures(txn siatypes.Transaction, asicHardForkHeight siatypes.BlockHeight) (violations []string) {
+	signatures := make(map[siacrypto.Hash]uint64)
+
+	for i, sig := range txn.TransactionSignatures {
+		var input *siatypes.SiacoinInput
+
+		for j := range txn.SiacoinInputs {
+			if siacrypto.Hash(txn.SiacoinInputs[j].ParentID) == sig.ParentID {
+				input = &txn.SiacoinInputs[j]
+				break
+			}
+		}
+
+		if input == nil {
+			violations = append(violations, fmt.Sprintf("signature %d does not sign a siacoin input", i))
+			continue
+		}
+
+		if sig.PublicKeyIndex >= uint64(len(input.UnlockConditions.PublicKeys)) {
+			violations = append(violations, fmt.Sprintf("signature %d public key index %d out of range", i, sig.PublicKeyIndex))
+			continue
+		}
+
+		pk := input.UnlockConditions.PublicKeys[sig.PublicKeyIndex]
+
+		if pk.Algorithm != siatypes.SignatureEd25519 {
+			violations = append(violations, fmt.Sprintf("signature %d uses unsupported algorithm %s", i, pk.Algorithm))
+			continue
+		}
+
+		var edPK siacrypto.PublicKey
+		var edSig siacrypto.Signature
+
+		if len(sig.Signature) == 0 {
+			violations = append(violations, fmt.Sprintf("signature %d is empty", i))
+			continue
+		} else if len(pk.Key) != len(edPK) || len(sig.Signature) != len(edSig) {
+			violations = append(violations, fmt.Sprintf("signature %d is malformed", i))
+			continue
+		}
+
+		copy(edPK[:], pk.Key)
+		copy(edSig[:], sig.Signature)
+
+		if err := siacrypto.VerifyHash(txn.SigHash(i, asicHardForkHeight), edPK, edSig); err != nil {
+			violations = append(violations, fmt.Sprintf("signature %d is not valid", i))
+			continue
+		}
+
+		signatures[sig.ParentID]++
+	}
+
+	for i, input := range txn.SiacoinInputs {
+		if required := input.UnlockConditions.SignaturesRequired; signatures[siacrypto.Hash(input.ParentID)] < required {
+			violations = append(violations, fmt.Sprintf("siacoin input %d is missing signatures: has %d valid of %d required", i, signatures[siacrypto.Hash(input.ParentID)], required))
+		}
+	}
+
+	return
+}
+
+//ValidateTransaction checks a built transaction against the consensus rules before it is
+//broadcast and returns the list of violations, an empty list if the transaction is valid. spent
+//must contain the outputs spent by the transaction to check the input values and addresses.
+//Rules that depend on the blockchain, like whether the outputs are still unspent, are not checked
+func ValidateTransaction(txn siatypes.Transaction, spent []apitypes.SiacoinOutput, currency string, callback js.Value) {
+	violations := validateTransaction(txn, spent, wallet.ASICHardForkHeight(currency))
+
+	resp := make([]interface{}, len(violations))
+
+	for i, v := range violations {
+		resp[i] = v
+	}
+
+	callback.Invoke(js.Null(), resp)
+}
+
+//validateMultisig checks that the unlock conditions can be spent from. Every violation is returned
+//instead of stopping at the first. Only ed25519 keys are counted as able to sign
+func validateMultisig(uc wallet.UnlockConditions) (violations []string) {
+	violate := func(format string, args ...interface{}) {
+		violations = append(violations, fmt.Sprintf(format, args...))
+	}
+
+	if len(uc.PublicKeys) == 0 {
+		violate("unlock conditions have no public keys")
+	}
+
+	if uc.SignaturesRequired == 0 {
+		violate("unlock conditions require no signatures, anyone can spend from the address")
+	}
+
+	signers := 0
+	seen := make(map[string]int)
+
+	for i, str := range uc.PublicKeys {
+		var pk siatypes.SiaPublicKey
+
+		if err := pk.LoadString(str); err != nil {
+			violate("public key %d is invalid: %s", i, err)
+			continue
+		}
+
+		if j, exists := seen[pk.String()]; exists {
+			violate("public key %d duplicates public key %d", i, j)
+			continue
+		}
+
+		seen[pk.String()] = i
+
+		if pk.Algorithm != siatypes.SignatureEd25519 {
+			violate("public key %d uses unsupported algorithm %s", i, pk.Algorithm)
+			continue
+		} else if len(pk.Key) != siacrypto.PublicKeySize {
+			violate("public key %d is %d bytes, expected %d", i, len(pk.Key), siacrypto.PublicKeySize)
+			continue
+		}
+
+		signers++
+	}
+
+	if uc.SignaturesRequired > uint64(signers) {
+		violate("unlock conditions require %d signatures but only %d keys can sign, the address can never be spent from", uc.SignaturesRequired, signers)
+	}
+
+	if uc.Timelock > timelockLimit {
+		violate("timelock %d is too far in the future, the address could not be spent from until then", uc.Timelock)
+	}
+
+	return
+}
+
+//ValidateMultisig checks multisig unlock conditions before they are used to create a shared
+//address. Returns the list of violations, an empty list if an address with the unlock conditions
+//can be spent from
+func ValidateMultisig(unlockConditionsJSON string, callback js.Value) {
+	var uc wallet.UnlockConditions
+
+	if err := json.Unmarshal([]byte(unlockConditionsJSON), &uc); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding unlock conditions: %s", err), js.Null())
+		return
+	}
+
+	violations := validateMultisig(uc)
+	resp := make([]interface{}, len(violations))
+
+	for i, v := range violations {
+		resp[i] = v
+	}
+
+	callback.Invoke(js.Null(), resp)
+}
+
+//difference returns how much a exceeds b and how much b exceeds a, at most one is not zero
+func difference(a, b siatypes.Currency) (excess, shortfall siatypes.Currency) {
+	if a.Cmp(b) > 0 {
+		return a.Sub(b), siatypes.ZeroCurrency
+	}
+
+	return siatypes.ZeroCurrency, b.Sub(a)
+}
+
+//checkBalance sums the inputs and outputs of the transaction. values are the values of the outputs
+//spent by the transaction's siacoin and siafund inputs keyed by output id. Consensus requires the
+//siacoin inputs to equal the siacoin outputs and fees and the siafund inputs to equal the siafund
+//outputs
+func checkBalance(txn siatypes.Transaction, values map[string]siatypes.Currency) (check balanceCheck) {
+	check.Missing = []string{}
+
+	for _, input := range txn.SiacoinInputs {
+		value, exists := values[input.ParentID.String()]
+
+		if !exists {
+			check.Missing = append(check.Missing, input.ParentID.String())
+			continue
+		}
+
+		check.SiacoinInputs = check.SiacoinInputs.Add(value)
+	}
+
+	for _, input := range txn.SiafundInputs {
+		value, exists := values[input.ParentID.String()]
+
+		if !exists {
+			check.Missing = append(check.Missing, input.ParentID.String())
+			continue
+		}
+
+		check.SiafundInputs = check.SiafundInputs.Add(value)
+	}
+
+	// the balance cannot be known without every input value
+	if len(check.Missing) != 0 {
+		check.SiacoinInputs = siatypes.ZeroCurrency
+		check.SiafundInputs = siatypes.ZeroCurrency
+		return
+	}
+
+	for _, output := range txn.SiacoinOutputs {
+		check.SiacoinOutputs = check.SiacoinOutputs.Add(output.Value)
+	}
+
+	for _, fee := range txn.MinerFees {
+		check.Fees = check.Fees.Add(fee)
+	}
+
+	for _, output := range txn.SiafundOutputs {
+		check.SiafundOutputs = check.SiafundOutputs.Add(output.Value)
+	}
+
+	check.SiacoinExcess, check.SiacoinShortfall = difference(check.SiacoinInputs, check.SiacoinOutputs.Add(check.Fees))
+	check.SiafundExcess, check.SiafundShortfall = difference(check.SiafundInputs, check.SiafundOutputs)
+	check.Balanced = check.SiacoinExcess.IsZero() && check.SiacoinShortfall.IsZero() &&
+		check.SiafundExcess.IsZero() && check.SiafundShortfall.IsZero()
+
+	return
+}
+
+//BalanceCheck checks that a transaction balances before it is broadcast. Consensus rejects an
+//unbalanced transaction without saying by how much, the check returns the discrepancy.
+//outputValuesJSON maps the id of each output spent by the transaction to its value
+func BalanceCheck(txnJSON, outputValuesJSON string, callback js.Value) {
+	var txn siatypes.Transaction
+	var values map[string]siatypes.Currency
+
+	if err := json.Unmarshal([]byte(txnJSON), &txn); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding transaction: %s", err), js.Null())
+		return
+	}
+
+	if err := json.Unmarshal([]byte(outputValuesJSON), &values); err != nil {
+		callback.Invoke(fmt.Sprintf("error decoding output values: %s", err), js.Null())
+		return
+	}
+
+	data, err := interfaceToJSON(checkBalance(txn, values))
+
+	if err != nil {
+		callback.Invoke(err.Error(), js.Null())
+		return
+	}
+
+	callback.Invoke(js.Null(), data)
+}
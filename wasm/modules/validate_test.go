@@ -0,0 +1,176 @@
+package modules
+
+import (
+	"strings"
+	"testing"
+
+	apitypes "github.com/siacentral/apisdkgo/types"
+	"github.com/siacentral/sia-lite-wallet-web/wasm/wallet"
+	siatypes "gitlab.com/NebulousLabs/Sia/types"
+)
+
+func TestValidateTransaction(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := testOutputs(t, 10, 20)
+	spent := make([]apitypes.SiacoinOutput, len(outputs))
+
+	for i, output := range outputs {
+		spent[i] = output.SiacoinOutput
+	}
+
+	recipient, err := parseAddress(outputs[0].UnlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fee := siatypes.SiacoinPrecision
+	unsigned, err := buildTransaction(outputs, []siatypes.SiacoinOutput{
+		{Value: sumOutputs(outputs).Sub(fee), UnlockHash: recipient},
+	}, fee)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	height := wallet.ASICHardForkHeight("sc")
+
+	violations := validateTransaction(unsigned.Transaction, spent, height)
+	if len(violations) != 4 || !strings.Contains(violations[0], "signature 0 is empty") || !strings.Contains(violations[3], "missing signatures") {
+		t.Fatalf("expected empty and missing signature violations for the unsigned transaction, got %v", violations)
+	}
+
+	txn := unsigned.Transaction
+	if err := w.SignTransaction(&txn, unsigned.RequiredSigs); err != nil {
+		t.Fatal(err)
+	}
+
+	if violations := validateTransaction(txn, spent, height); len(violations) != 0 {
+		t.Fatalf("expected the signed transaction to be valid, got %v", violations)
+	}
+
+	if violations := validateTransaction(txn, spent[:1], height); len(violations) != 1 || !strings.Contains(violations[0], "unknown output") {
+		t.Fatalf("expected an unknown output violation, got %v", violations)
+	}
+
+	// changing the outputs invalidates the signatures and the input sum
+	tampered := txn
+	tampered.SiacoinOutputs = []siatypes.SiacoinOutput{
+		{Value: sumOutputs(outputs), UnlockHash: recipient},
+		{Value: siatypes.ZeroCurrency, UnlockHash: recipient},
+	}
+
+	violations = validateTransaction(tampered, spent, height)
+
+	expected := []string{"zero value", "do not equal", "signature 0 is not valid", "signature 1 is not valid", "input 0 is missing", "input 1 is missing"}
+	if len(violations) != len(expected) {
+		t.Fatalf("expected %d violations, got %v", len(expected), violations)
+	}
+
+	for i, e := range expected {
+		if !strings.Contains(violations[i], e) {
+			t.Fatalf("expected violation %d to contain %q, got %q", i, e, violations[i])
+		}
+	}
+
+	// spending an output with unlock conditions from another address
+	mismatched := append([]apitypes.SiacoinOutput(nil), spent...)
+	mismatched[1].UnlockHash = outputs[0].UnlockHash
+
+	if violations := validateTransaction(txn, mismatched, height); len(violations) != 1 || !strings.Contains(violations[0], "unlock conditions") {
+		t.Fatalf("expected an unlock conditions violation, got %v", violations)
+	}
+}
+
+func TestValidateMultisig(t *testing.T) {
+	w, err := wallet.RecoverBIP39Seed(testPhrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := make([]string, 3)
+	for i := range keys {
+		keys[i] = w.GetAddress(uint64(i)).UnlockConditions.PublicKeys[0].String()
+	}
+
+	if violations := validateMultisig(wallet.UnlockConditions{PublicKeys: keys, SignaturesRequired: 2}); len(violations) != 0 {
+		t.Fatalf("expected 2-of-3 to be valid, got %v", violations)
+	}
+
+	tests := []struct {
+		uc       wallet.UnlockConditions
+		expected []string
+	}{
+		{wallet.UnlockConditions{PublicKeys: keys[:2], SignaturesRequired: 3}, []string{"can never be spent"}},
+		{wallet.UnlockConditions{PublicKeys: keys, SignaturesRequired: 0}, []string{"anyone can spend"}},
+		{wallet.UnlockConditions{SignaturesRequired: 1}, []string{"no public keys", "can never be spent"}},
+		// the duplicate does not count as a second signer
+		{wallet.UnlockConditions{PublicKeys: []string{keys[0], keys[0]}, SignaturesRequired: 2}, []string{"duplicates public key 0", "can never be spent"}},
+		{wallet.UnlockConditions{PublicKeys: []string{keys[0], "ed25519:abcd"}, SignaturesRequired: 1}, []string{"public key 1 is 2 bytes"}},
+		{wallet.UnlockConditions{PublicKeys: []string{keys[0], "notakey"}, SignaturesRequired: 1}, []string{"public key 1 is invalid"}},
+		{wallet.UnlockConditions{PublicKeys: keys, SignaturesRequired: 1, Timelock: timelockLimit + 1}, []string{"timelock"}},
+	}
+
+	for i, test := range tests {
+		violations := validateMultisig(test.uc)
+
+		if len(violations) != len(test.expected) {
+			t.Errorf("test %d: expected %d violations, got %v", i, len(test.expected), violations)
+			continue
+		}
+
+		for j, exp := range test.expected {
+			if !strings.Contains(violations[j], exp) {
+				t.Errorf("test %d: expected violation %q, got %q", i, exp, violations[j])
+			}
+		}
+	}
+}
+
+func TestCheckBalance(t *testing.T) {
+	txn := siatypes.Transaction{
+		SiacoinInputs: []siatypes.SiacoinInput{
+			{ParentID: siatypes.SiacoinOutputID{1}},
+			{ParentID: siatypes.SiacoinOutputID{2}},
+		},
+		SiacoinOutputs: []siatypes.SiacoinOutput{
+			{Value: siatypes.NewCurrency64(25)},
+		},
+		SiafundInputs: []siatypes.SiafundInput{
+			{ParentID: siatypes.SiafundOutputID{3}},
+		},
+		SiafundOutputs: []siatypes.SiafundOutput{
+			{Value: siatypes.NewCurrency64(100)},
+		},
+		MinerFees: []siatypes.Currency{siatypes.NewCurrency64(5)},
+	}
+
+	values := map[string]siatypes.Currency{
+		siatypes.SiacoinOutputID{1}.String(): siatypes.NewCurrency64(10),
+		siatypes.SiacoinOutputID{2}.String(): siatypes.NewCurrency64(20),
+		siatypes.SiafundOutputID{3}.String(): siatypes.NewCurrency64(100),
+	}
+
+	if check := checkBalance(txn, values); !check.Balanced || check.SiacoinInputs.Cmp64(30) != 0 || check.Fees.Cmp64(5) != 0 {
+		t.Fatalf("expected the transaction to balance, got %+v", check)
+	}
+
+	// a forgotten change output leaves value unaccounted for
+	txn.SiacoinOutputs[0].Value = siatypes.NewCurrency64(20)
+	if check := checkBalance(txn, values); check.Balanced || check.SiacoinExcess.Cmp64(5) != 0 || !check.SiacoinShortfall.IsZero() {
+		t.Fatalf("expected an excess of 5, got %+v", check)
+	}
+
+	txn.SiacoinOutputs[0].Value = siatypes.NewCurrency64(25)
+	txn.SiafundOutputs[0].Value = siatypes.NewCurrency64(150)
+	if check := checkBalance(txn, values); check.Balanced || check.SiafundShortfall.Cmp64(50) != 0 || !check.SiafundExcess.IsZero() {
+		t.Fatalf("expected a siafund shortfall of 50, got %+v", check)
+	}
+
+	delete(values, siatypes.SiacoinOutputID{2}.String())
+	if check := checkBalance(txn, values); check.Balanced || len(check.Missing) != 1 || check.Missing[0] != (siatypes.SiacoinOutputID{2}).String() {
+		t.Fatalf("expected the missing output to be reported, got %+v", check)
+	}
+}
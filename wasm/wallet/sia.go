@@ -19,6 +19,20 @@ var englishWordMap = func() map[string]bool {
 	return m
 }()
 
+//Wordlist returns the ordered wordlist used to encode seeds of the seed type. "walrus" seeds use the
+//BIP39 English wordlist, any other type uses the Sia English dictionary. The index of each word is
+//the value it encodes
+func Wordlist(seedType string) []string {
+	if strings.ToLower(seedType) == "walrus" {
+		return append([]string(nil), bip39EnglishWordList...)
+	}
+
+	words := make([]string, len(mnemonics.EnglishDictionary))
+	copy(words, mnemonics.EnglishDictionary[:])
+
+	return words
+}
+
 //NewSiaRecoveryPhrase creates a new unique 28 or 29 word wallet seed
 func NewSiaRecoveryPhrase() (string, error) {
 	var entropy [siacrypto.EntropySize]byte
@@ -0,0 +1,118 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	siacrypto "gitlab.com/NebulousLabs/Sia/crypto"
+	mnemonics "gitlab.com/NebulousLabs/entropy-mnemonics"
+)
+
+type (
+	//SeedReport describes the format and strength of a seed phrase
+	SeedReport struct {
+		Type        string `json:"type"`
+		Words       int    `json:"words"`
+		EntropyBits int    `json:"entropyBits"`
+		Weak        bool   `json:"weak"`
+	}
+)
+
+//weakEntropy returns true if the entropy has so few distinct bytes it was almost certainly not
+//randomly generated, such as the all zero or repeating patterns used by test vectors and demos
+func weakEntropy(entropy []byte) bool {
+	distinct := make(map[byte]bool)
+
+	for _, b := range entropy {
+		distinct[b] = true
+	}
+
+	return len(distinct) < len(entropy)/4
+}
+
+//AnalyzeSeed reports the format and expected strength of a seed phrase. Phrases that decode to
+//obviously non-random entropy are flagged as weak
+func AnalyzeSeed(phrase string) (report SeedReport, err error) {
+	var entropy []byte
+
+	words := strings.Fields(phrase)
+	report.Words = len(words)
+
+	switch len(words) {
+	case 12:
+		var buf [16]byte
+
+		if buf, err = decodeBIP39Phrase(phrase); err != nil {
+			return
+		}
+
+		report.Type = "walrus"
+		entropy = buf[:]
+	case 28, 29:
+		var buf []byte
+
+		if buf, err = mnemonics.FromString(phrase, mnemonics.DictionaryID("english")); err != nil {
+			return
+		}
+
+		if len(buf) < siacrypto.EntropySize {
+			err = errors.New("seed is not valid: not enough entropy")
+			return
+		}
+
+		report.Type = "sia"
+		entropy = buf[:siacrypto.EntropySize]
+	default:
+		err = errors.New("seed is not valid: must be 12, 28, or 29 words")
+		return
+	}
+
+	report.EntropyBits = len(entropy) * 8
+	report.Weak = weakEntropy(entropy)
+
+	return
+}
+
+//VerifySeedChecksum checks the words and checksum of a seed phrase and returns its type. Only the
+//phrase is decoded, the seed and its keys are not derived. BIP39 phrases have a 4 bit checksum in
+//the last word, Sia phrases end with the first 6 bytes of the hash of the entropy
+func VerifySeedChecksum(phrase string) (seedType string, err error) {
+	words := strings.Fields(phrase)
+
+	switch len(words) {
+	case 12:
+		_, err = decodeBIP39Phrase(phrase)
+		return "walrus", err
+	case 28, 29:
+		var entropy [siacrypto.EntropySize]byte
+
+		for _, word := range words {
+			if _, ok := englishWordMap[word]; !ok {
+				return "sia", fmt.Errorf("unrecognized word %q in seed phrase", word)
+			}
+		}
+
+		buf, err := mnemonics.FromString(phrase, mnemonics.DictionaryID("english"))
+
+		if err != nil {
+			return "sia", err
+		}
+
+		if len(buf) != siacrypto.EntropySize+SeedChecksumSize {
+			return "sia", errors.New("seed is not valid: wrong length")
+		}
+
+		copy(entropy[:], buf)
+		checksum := siacrypto.HashObject(entropy)
+
+		if !bytes.Equal(checksum[:SeedChecksumSize], buf[siacrypto.EntropySize:]) {
+			return "sia", errors.New("invalid checksum")
+		}
+
+		return "sia", nil
+	default:
+		return "", errors.New("seed is not valid: must be 12, 28, or 29 words")
+	}
+}
@@ -0,0 +1,110 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeSeed(t *testing.T) {
+	tests := []struct {
+		phrase string
+		bits   int
+		weak   bool
+	}{
+		{"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", 128, true},
+		{"legal winner thank year wave sausage worth useful legal winner thank yellow", 128, true},
+		{"jelly better achieve collect unaware mountain thought cargo oxygen act hood bridge", 128, false},
+		{"board flee heavy tunnel powder denial science ski answer betray cargo cat", 128, false},
+	}
+
+	for _, test := range tests {
+		report, err := AnalyzeSeed(test.phrase)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if report.EntropyBits != test.bits {
+			t.Errorf("expected %d bits for %q, got %d", test.bits, test.phrase, report.EntropyBits)
+		}
+
+		if report.Weak != test.weak {
+			t.Errorf("expected weak %t for %q", test.weak, test.phrase)
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		phrase, err := NewSiaRecoveryPhrase()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		report, err := AnalyzeSeed(phrase)
+		if err != nil {
+			t.Fatal(err)
+		} else if report.EntropyBits != 256 || report.Weak {
+			t.Errorf("unexpected report %+v for %q", report, phrase)
+		}
+
+		phrase, err = NewBIP39RecoveryPhrase()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		report, err = AnalyzeSeed(phrase)
+		if err != nil {
+			t.Fatal(err)
+		} else if report.EntropyBits != 128 || report.Weak {
+			t.Errorf("unexpected report %+v for %q", report, phrase)
+		}
+	}
+
+	if _, err := AnalyzeSeed("abandon abandon abandon"); err == nil {
+		t.Error("expected error for short phrase")
+	}
+}
+
+func TestVerifySeedChecksum(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		phrase, err := NewSiaRecoveryPhrase()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if seedType, err := VerifySeedChecksum(phrase); err != nil {
+			t.Fatalf("expected %q to be valid, got %s", phrase, err)
+		} else if seedType != "sia" {
+			t.Fatalf("expected sia seed, got %s", seedType)
+		}
+
+		// a typo in the first word changes the entropy the checksum covers
+		words := strings.Fields(phrase)
+		if words[0] == "abbey" {
+			words[0] = "abducts"
+		} else {
+			words[0] = "abbey"
+		}
+
+		if _, err := VerifySeedChecksum(strings.Join(words, " ")); err == nil {
+			t.Fatalf("expected %q to have an invalid checksum", phrase)
+		}
+
+		phrase, err = NewBIP39RecoveryPhrase()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if seedType, err := VerifySeedChecksum(phrase); err != nil {
+			t.Fatalf("expected %q to be valid, got %s", phrase, err)
+		} else if seedType != "walrus" {
+			t.Fatalf("expected walrus seed, got %s", seedType)
+		}
+	}
+
+	if _, err := VerifySeedChecksum("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"); err == nil || err.Error() != "invalid checksum" {
+		t.Fatalf("expected invalid checksum, got %v", err)
+	}
+
+	if _, err := VerifySeedChecksum("abandon abandon abandon"); err == nil {
+		t.Fatal("expected the wrong number of words to fail")
+	}
+}
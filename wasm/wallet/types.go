@@ -18,6 +18,9 @@ var (
 	siaASICHardForkHeight     = types.BlockHeight(179001)
 	scprimeASICHardForkHeight = types.BlockHeight(0)
 	fullCoveredFields         = types.CoveredFields{WholeTransaction: true}
+	fingerprintSpecifier      = types.NewSpecifier("fingerprint")
+	labelKeySpecifier         = types.NewSpecifier("label key")
+	addressPoolSpecifier      = types.NewSpecifier("address pool")
 )
 
 type (
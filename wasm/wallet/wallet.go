@@ -1,22 +1,33 @@
 package wallet
 
 import (
+	"encoding/hex"
 	"errors"
+	"fmt"
 
 	siacrypto "gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/Sia/types"
 )
 
 type (
-	//SeedWallet creates keys and addresses for the generated seed. Wallet is stateless for ease of use
+	//SeedWallet creates keys and addresses for the generated seed. Wallet is stateless for ease of use.
+	//AccountOffset is added to every index so separate accounts can be derived from the same seed
 	SeedWallet struct {
-		s        [siacrypto.EntropySize]byte
-		Currency string
+		s             [siacrypto.EntropySize]byte
+		Currency      string
+		AccountOffset uint64
 	}
 )
 
-//GetAddress returns the spendable address at the specified index
+//GetAddress returns the spendable address at the specified index of the wallet's account
 func (wallet *SeedWallet) GetAddress(index uint64) SpendableKey {
+	return wallet.deriveKey(wallet.AccountOffset + index)
+}
+
+//deriveKey returns the spendable address at the index of the seed, ignoring the account offset.
+//Sia seeds have a single index space, there are no separate receive and change branches. A key
+//derived any other way would not be found by other wallets recovering the same seed
+func (wallet *SeedWallet) deriveKey(index uint64) SpendableKey {
 	sk, pk := siacrypto.GenerateKeyPairDeterministic(siacrypto.HashAll(wallet.s, index))
 
 	return SpendableKey{
@@ -28,6 +39,30 @@ func (wallet *SeedWallet) GetAddress(index uint64) SpendableKey {
 	}
 }
 
+//Fingerprint returns a short identifier for the wallet. The fingerprint is derived only from the
+//public key of the seed's first address so it is safe to store and display without revealing the
+//seed. Every account of the seed has the same fingerprint
+func (wallet *SeedWallet) Fingerprint() string {
+	key := wallet.deriveKey(0)
+	h := siacrypto.HashAll(fingerprintSpecifier, key.UnlockConditions.PublicKeys[0])
+
+	return hex.EncodeToString(h[:8])
+}
+
+//LabelKey returns a deterministic key for encrypting the wallet's local metadata, like labels.
+//The key is hashed from the seed with its own specifier so it never matches, and cannot be used to
+//derive, any of the keys that sign transactions. The currency is included so each currency sharing
+//the seed gets a different key
+func (wallet *SeedWallet) LabelKey() [32]byte {
+	return siacrypto.HashAll(labelKeySpecifier, wallet.Currency, wallet.s)
+}
+
+//AddressPoolKey returns a deterministic key for authenticating a cached pool of the wallet's
+//addresses. Like LabelKey it has its own specifier so it cannot be used to derive the signing keys
+func (wallet *SeedWallet) AddressPoolKey() [32]byte {
+	return siacrypto.HashAll(addressPoolSpecifier, wallet.Currency, wallet.s)
+}
+
 //GetAddresses returns the n addresses starting at idx and incrementing by 1.
 //Wanted to import this directly from modules, but cannot because of bbolt
 //https://gitlab.com/NebulousLabs/Sia/blob/fb65620/modules/wallet/seed.go#L49
@@ -39,11 +74,119 @@ func (wallet *SeedWallet) GetAddresses(idx uint64, keys []SpendableKey) {
 	}
 }
 
+//ConsensusCoverage checks only the consensus rules for the covered fields of the transaction. The
+//indices of each field must be sorted, unique and in range, and whole transaction coverage cannot
+//list any fields but signatures. Any coverage that passes can be hashed
+func ConsensusCoverage(txn *types.Transaction, cf types.CoveredFields) error {
+	fields := []struct {
+		name    string
+		indices []uint64
+		max     int
+	}{
+		{"siacoin inputs", cf.SiacoinInputs, len(txn.SiacoinInputs)},
+		{"siacoin outputs", cf.SiacoinOutputs, len(txn.SiacoinOutputs)},
+		{"file contracts", cf.FileContracts, len(txn.FileContracts)},
+		{"file contract revisions", cf.FileContractRevisions, len(txn.FileContractRevisions)},
+		{"storage proofs", cf.StorageProofs, len(txn.StorageProofs)},
+		{"siafund inputs", cf.SiafundInputs, len(txn.SiafundInputs)},
+		{"siafund outputs", cf.SiafundOutputs, len(txn.SiafundOutputs)},
+		{"miner fees", cf.MinerFees, len(txn.MinerFees)},
+		{"arbitrary data", cf.ArbitraryData, len(txn.ArbitraryData)},
+		{"transaction signatures", cf.TransactionSignatures, len(txn.TransactionSignatures)},
+	}
+
+	for _, field := range fields {
+		for i, index := range field.indices {
+			if index >= uint64(field.max) {
+				return fmt.Errorf("covered %s index %d out of range", field.name, index)
+			} else if i > 0 && index <= field.indices[i-1] {
+				return fmt.Errorf("covered %s must be sorted and unique", field.name)
+			}
+		}
+	}
+
+	if cf.WholeTransaction {
+		for _, field := range fields[:len(fields)-1] {
+			if len(field.indices) != 0 {
+				return errors.New("whole transaction coverage cannot specify covered fields")
+			}
+		}
+	}
+
+	return nil
+}
+
+//ValidCoverage checks that the covered fields follow the consensus rules for the transaction, see
+//ConsensusCoverage. Partial coverage must also commit to every siacoin input so the signature
+//cannot be reused with a different set of inputs
+func ValidCoverage(txn *types.Transaction, cf types.CoveredFields) error {
+	if err := ConsensusCoverage(txn, cf); err != nil {
+		return err
+	}
+
+	if !cf.WholeTransaction && len(cf.SiacoinInputs) != len(txn.SiacoinInputs) {
+		return errors.New("partial coverage must cover every siacoin input")
+	}
+
+	return nil
+}
+
+//SignTransactionCoverage signs a transaction with signatures that only commit to the covered
+//fields instead of the whole transaction.
+//
+//Any field that is not covered can be changed after signing without invalidating the signatures.
+//An output that is not covered can be redirected or removed and new outputs can be added by
+//anyone who sees the transaction before it is confirmed. The coverage must commit to every siacoin
+//input, but it is only safe to leave an output uncovered if the other covered outputs and fees
+//already account for the value of every input. Otherwise the remaining value can be claimed by
+//whoever adds the uncovered outputs. Use SignTransaction unless a third party needs to modify the
+//transaction after it has been signed
+func (wallet *SeedWallet) SignTransactionCoverage(txn *types.Transaction, requiredSigIndices []uint64, cf types.CoveredFields) error {
+	if err := ValidCoverage(txn, cf); err != nil {
+		return err
+	}
+
+	for i := range txn.TransactionSignatures {
+		txn.TransactionSignatures[i].CoveredFields = cf
+	}
+
+	if err := wallet.SignTransaction(txn, requiredSigIndices); err != nil {
+		return err
+	}
+
+	// verify the signatures against the sig hash consensus will use to validate them
+	for i, input := range txn.SiacoinInputs {
+		var sig siacrypto.Signature
+		var pk siacrypto.PublicKey
+
+		copy(sig[:], txn.TransactionSignatures[i].Signature)
+		copy(pk[:], input.UnlockConditions.PublicKeys[0].Key)
+
+		if err := siacrypto.VerifyHash(txn.SigHash(i, wallet.asicHardForkHeight()), pk, sig); err != nil {
+			return fmt.Errorf("signature %d is not valid: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (wallet *SeedWallet) asicHardForkHeight() types.BlockHeight {
+	return ASICHardForkHeight(wallet.Currency)
+}
+
+//ASICHardForkHeight returns the height of the currency's ASIC hard fork. Signatures are always
+//created with the post fork sig hash
+func ASICHardForkHeight(currency string) types.BlockHeight {
+	if currency == "scp" {
+		return scprimeASICHardForkHeight
+	}
+
+	return siaASICHardForkHeight
+}
+
 //SignTransaction signs a transaction, for simplicity only supports standard 1 signature keys
 //and siacoin inputs
 func (wallet *SeedWallet) SignTransaction(txn *types.Transaction, requiredSigIndices []uint64) error {
-	var asicHardForkHeight types.BlockHeight
-
 	unlockHashMap := make(map[string]SpendableKey)
 
 	for _, index := range requiredSigIndices {
@@ -60,11 +203,7 @@ func (wallet *SeedWallet) SignTransaction(txn *types.Transaction, requiredSigInd
 		return errors.New("missing signature key indexes")
 	}
 
-	if wallet.Currency == "scp" {
-		asicHardForkHeight = scprimeASICHardForkHeight
-	} else {
-		asicHardForkHeight = siaASICHardForkHeight
-	}
+	asicHardForkHeight := wallet.asicHardForkHeight()
 
 	for i, input := range txn.SiacoinInputs {
 		key, exists := unlockHashMap[input.UnlockConditions.UnlockHash().String()]
@@ -81,3 +220,9 @@ func (wallet *SeedWallet) SignTransaction(txn *types.Transaction, requiredSigInd
 
 	return nil
 }
+
+//SignHash signs a precomputed hash with the key at the index of the wallet's account. Nothing
+//about the hash is checked, the caller must know what it is signing
+func (wallet *SeedWallet) SignHash(index uint64, hash siacrypto.Hash) siacrypto.Signature {
+	return siacrypto.SignHash(hash, wallet.GetAddress(index).SecretKeys[0])
+}
@@ -0,0 +1,316 @@
+package wallet
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+func TestFingerprint(t *testing.T) {
+	phrase, err := NewSiaRecoveryPhrase()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w1, err := RecoverSiaSeed(phrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w2, err := RecoverSiaSeed(phrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w1.Fingerprint() != w2.Fingerprint() {
+		t.Fatal("expected the same seed to produce the same fingerprint")
+	} else if len(w1.Fingerprint()) != 16 {
+		t.Fatalf("expected 16 character fingerprint, got %q", w1.Fingerprint())
+	}
+
+	seen := map[string]bool{
+		w1.Fingerprint(): true,
+	}
+
+	for i := 0; i < 100; i++ {
+		phrase, err := NewBIP39RecoveryPhrase()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w, err := RecoverBIP39Seed(phrase, "sc")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if seen[w.Fingerprint()] {
+			t.Fatalf("fingerprint collision %q", w.Fingerprint())
+		}
+
+		seen[w.Fingerprint()] = true
+	}
+}
+
+func TestSignTransactionCoverage(t *testing.T) {
+	phrase, err := NewBIP39RecoveryPhrase()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := RecoverBIP39Seed(phrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := w.GetAddress(0)
+	txn := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{
+			{ParentID: types.SiacoinOutputID{1}, UnlockConditions: key.UnlockConditions},
+		},
+		SiacoinOutputs: []types.SiacoinOutput{
+			{Value: types.SiacoinPrecision.Mul64(90), UnlockHash: types.UnlockHash{2}},
+			{Value: types.SiacoinPrecision.Mul64(9), UnlockHash: types.UnlockHash{3}},
+		},
+		MinerFees: []types.Currency{types.SiacoinPrecision},
+		TransactionSignatures: []types.TransactionSignature{
+			{ParentID: [32]byte{1}},
+		},
+	}
+
+	if err := w.SignTransactionCoverage(&txn, []uint64{0}, types.CoveredFields{SiacoinOutputs: []uint64{0}}); err == nil {
+		t.Fatal("expected coverage without the siacoin inputs to fail")
+	} else if err := w.SignTransactionCoverage(&txn, []uint64{0}, types.CoveredFields{SiacoinInputs: []uint64{0}, SiacoinOutputs: []uint64{1, 0}}); err == nil {
+		t.Fatal("expected unsorted coverage to fail")
+	} else if err := w.SignTransactionCoverage(&txn, []uint64{0}, types.CoveredFields{SiacoinInputs: []uint64{0}, SiacoinOutputs: []uint64{2}}); err == nil {
+		t.Fatal("expected out of range coverage to fail")
+	}
+
+	cf := types.CoveredFields{
+		SiacoinInputs:  []uint64{0},
+		SiacoinOutputs: []uint64{0},
+		MinerFees:      []uint64{0},
+	}
+
+	if err := w.SignTransactionCoverage(&txn, []uint64{0}, cf); err != nil {
+		t.Fatal(err)
+	} else if err := txn.StandaloneValid(200000); err != nil {
+		t.Fatal(err)
+	}
+
+	// the uncovered output can be changed without invalidating the signature
+	txn.SiacoinOutputs[1].UnlockHash = types.UnlockHash{4}
+	if err := txn.StandaloneValid(200000); err != nil {
+		t.Fatal(err)
+	}
+
+	txn.SiacoinOutputs[0].UnlockHash = types.UnlockHash{4}
+	if err := txn.StandaloneValid(200000); err == nil {
+		t.Fatal("expected changing a covered output to invalidate the signature")
+	}
+}
+
+func TestAddressUniqueness(t *testing.T) {
+	count := uint64(20000)
+	if testing.Short() {
+		count = 1000
+	}
+
+	phrase, err := NewBIP39RecoveryPhrase()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := RecoverBIP39Seed(phrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[types.UnlockHash]uint64, count)
+	keys := make([]SpendableKey, count)
+
+	w.GetAddresses(0, keys)
+
+	for i, key := range keys {
+		uh := key.UnlockConditions.UnlockHash()
+
+		if existing, exists := seen[uh]; exists {
+			t.Fatalf("index %d and %d produced the same address %s", existing, i, uh)
+		}
+
+		seen[uh] = uint64(i)
+	}
+}
+
+func TestLabelKey(t *testing.T) {
+	phrase, err := NewBIP39RecoveryPhrase()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w1, err := RecoverBIP39Seed(phrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w2, err := RecoverBIP39Seed(phrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scp, err := RecoverBIP39Seed(phrase, "scp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := w1.LabelKey()
+
+	if key != w2.LabelKey() {
+		t.Fatal("expected the same seed to produce the same label key")
+	} else if key == scp.LabelKey() {
+		t.Fatal("expected each currency to produce a different label key")
+	} else if key == w1.s {
+		t.Fatal("expected the label key to differ from the seed")
+	}
+
+	for i := uint64(0); i < 100; i++ {
+		sk := w1.GetAddress(i).SecretKeys[0]
+
+		if bytes.Equal(sk[:32], key[:]) {
+			t.Fatalf("expected the label key to differ from the signing key at index %d", i)
+		}
+	}
+}
+
+func TestWordlist(t *testing.T) {
+	bip39 := Wordlist("walrus")
+	if len(bip39) != 2048 || bip39[0] != "abandon" || bip39[2047] != "zoo" {
+		t.Fatalf("unexpected bip39 wordlist of %d words", len(bip39))
+	}
+
+	sia := Wordlist("sia")
+	if len(sia) != 1626 {
+		t.Fatalf("expected 1626 sia words, got %d", len(sia))
+	}
+
+	// modifying the returned list must not change the dictionary used to recover seeds
+	bip39[0] = "modified"
+	if Wordlist("walrus")[0] != "abandon" {
+		t.Fatal("expected the wordlist to be a copy")
+	}
+
+	phrase, err := NewSiaRecoveryPhrase()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	words := make(map[string]bool)
+	for _, word := range sia {
+		words[word] = true
+	}
+
+	for _, word := range strings.Fields(phrase) {
+		if !words[word] {
+			t.Fatalf("seed word %q is not in the wordlist", word)
+		}
+	}
+}
+
+func TestWatchOnly(t *testing.T) {
+	phrase, err := NewSiaRecoveryPhrase()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := RecoverSiaSeed(phrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	export, err := w.ExportWatchOnly(10, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	watch, err := OpenWatchOnly(export, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := uint64(10); i < 15; i++ {
+		uc, err := watch.GetAddress(i)
+		if err != nil {
+			t.Fatal(err)
+		} else if uc.UnlockHash() != w.GetAddress(i).UnlockConditions.UnlockHash() {
+			t.Fatalf("address %d does not match the seed", i)
+		}
+	}
+
+	// only the exported indices are known
+	if _, err := watch.GetAddress(9); err == nil {
+		t.Fatal("expected error for index before the export")
+	} else if _, err := watch.GetAddress(15); err == nil {
+		t.Fatal("expected error for index after the export")
+	}
+
+	if err := watch.SignTransaction(&types.Transaction{}, nil); err != ErrWatchOnly {
+		t.Fatalf("expected watch-only error, got %v", err)
+	}
+
+	if _, err := OpenWatchOnly(export, "scp"); err == nil {
+		t.Fatal("expected error for a different currency")
+	}
+
+	export.PublicKeys[0] = "ed25519:00"
+	if _, err := OpenWatchOnly(export, "sc"); err == nil {
+		t.Fatal("expected error for an invalid public key")
+	}
+}
+
+func TestAccountOffset(t *testing.T) {
+	phrase, err := NewBIP39RecoveryPhrase()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base, err := RecoverBIP39Seed(phrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := RecoverBIP39Seed(phrase, "sc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account.AccountOffset = 1000
+
+	key := account.GetAddress(5)
+	if key.UnlockConditions.UnlockHash() != base.GetAddress(1005).UnlockConditions.UnlockHash() {
+		t.Fatal("expected the account's index 5 to be the seed's index 1005")
+	} else if account.Fingerprint() != base.Fingerprint() {
+		t.Fatal("expected every account of the seed to have the same fingerprint")
+	}
+
+	txn := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{
+			{ParentID: types.SiacoinOutputID{1}, UnlockConditions: key.UnlockConditions},
+		},
+		SiacoinOutputs: []types.SiacoinOutput{
+			{Value: types.SiacoinPrecision, UnlockHash: types.UnlockHash{2}},
+		},
+		TransactionSignatures: []types.TransactionSignature{
+			{ParentID: [32]byte{1}, CoveredFields: types.CoveredFields{WholeTransaction: true}},
+		},
+	}
+
+	// signing uses the same offset as address generation
+	if err := base.SignTransaction(&txn, []uint64{5}); err == nil {
+		t.Fatal("expected the default account to not find the key")
+	} else if err := account.SignTransaction(&txn, []uint64{5}); err != nil {
+		t.Fatal(err)
+	} else if err := txn.StandaloneValid(200000); err != nil {
+		t.Fatal(err)
+	}
+}
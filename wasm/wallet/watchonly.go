@@ -0,0 +1,106 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+const (
+	//MaxWatchOnlyKeys the maximum number of public keys in a watch-only export
+	MaxWatchOnlyKeys = 10000
+)
+
+//ErrWatchOnly returned when a watch-only wallet is asked to sign
+var ErrWatchOnly = errors.New("watch-only wallet cannot sign transactions, the seed is required")
+
+type (
+	//WatchOnlyExport the public keys of a range of a seed's addresses. Sia derives each key by
+	//hashing the seed with the index, so there is no extended public key that can derive more
+	//addresses. A watch-only wallet only knows the addresses that were exported
+	WatchOnlyExport struct {
+		Currency   string   `json:"currency"`
+		Start      uint64   `json:"start"`
+		PublicKeys []string `json:"public_keys"`
+	}
+
+	//WatchOnlyWallet generates the addresses of a watch-only export without any secret keys
+	WatchOnlyWallet struct {
+		start    uint64
+		keys     []types.SiaPublicKey
+		Currency string
+	}
+)
+
+//ExportWatchOnly exports the public keys of the count addresses starting at start
+func (wallet *SeedWallet) ExportWatchOnly(start, count uint64) (export WatchOnlyExport, err error) {
+	if count == 0 || count > MaxWatchOnlyKeys {
+		err = fmt.Errorf("count must be between 1 and %d", MaxWatchOnlyKeys)
+		return
+	}
+
+	export.Currency = wallet.Currency
+	export.Start = start
+	export.PublicKeys = make([]string, count)
+
+	for i := range export.PublicKeys {
+		key := wallet.GetAddress(start + uint64(i))
+		export.PublicKeys[i] = key.UnlockConditions.PublicKeys[0].String()
+	}
+
+	return
+}
+
+//OpenWatchOnly loads a watch-only export. The export must be for the currency so the addresses are
+//not mistaken for another network's
+func OpenWatchOnly(export WatchOnlyExport, currency string) (*WatchOnlyWallet, error) {
+	if export.Currency != currency {
+		return nil, fmt.Errorf("export is for %q not %q", export.Currency, currency)
+	}
+
+	if len(export.PublicKeys) == 0 || len(export.PublicKeys) > MaxWatchOnlyKeys {
+		return nil, fmt.Errorf("export must have between 1 and %d public keys", MaxWatchOnlyKeys)
+	}
+
+	keys := make([]types.SiaPublicKey, len(export.PublicKeys))
+
+	for i, str := range export.PublicKeys {
+		if err := keys[i].LoadString(str); err != nil {
+			return nil, fmt.Errorf("public key %d: %w", i, err)
+		}
+
+		if keys[i].Algorithm != types.SignatureEd25519 || len(keys[i].Key) != 32 {
+			return nil, fmt.Errorf("public key %d is not an ed25519 key", i)
+		}
+	}
+
+	return &WatchOnlyWallet{
+		start:    export.Start,
+		keys:     keys,
+		Currency: currency,
+	}, nil
+}
+
+//Range returns the first index and one past the last index of the exported addresses
+func (wallet *WatchOnlyWallet) Range() (start, end uint64) {
+	return wallet.start, wallet.start + uint64(len(wallet.keys))
+}
+
+//GetAddress returns the unlock conditions of the address at the specified index. Only exported
+//indices are available
+func (wallet *WatchOnlyWallet) GetAddress(index uint64) (types.UnlockConditions, error) {
+	if start, end := wallet.Range(); index < start || index >= end {
+		return types.UnlockConditions{}, fmt.Errorf("index %d was not exported, export has indices %d-%d", index, start, end-1)
+	}
+
+	return types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{wallet.keys[index-wallet.start]},
+		SignaturesRequired: 1,
+	}, nil
+}
+
+//SignTransaction always returns ErrWatchOnly, a watch-only wallet has no secret keys
+func (wallet *WatchOnlyWallet) SignTransaction(txn *types.Transaction, requiredSigIndices []uint64) error {
+	return ErrWatchOnly
+}